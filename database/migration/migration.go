@@ -3,11 +3,133 @@ package migration
 import (
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 
 	"github.com/DewiKresnawati/DewiWebService/database"
 	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// moneyColumns lists the columns that held float64 amounts before they
+// became models.Money.
+var moneyColumns = []struct {
+	model   interface{}
+	columns []string
+}{
+	{&models.Product{}, []string{"price", "average_cost", "last_cost"}},
+	{&models.Order{}, []string{"total", "cost_total"}},
+	{&models.ProductVariant{}, []string{"price"}},
+	{&models.PriceChange{}, []string{"old_price", "new_price"}},
+	{&models.SupplierPrice{}, []string{"unit_cost"}},
+	{&models.PurchaseOrderItem{}, []string{"unit_cost"}},
+	{&models.GoodsReceiptItem{}, []string{"unit_cost"}},
+}
+
+// convertMoneyColumns turns floating point amount columns into exact
+// decimals. Values are rounded to models.MoneyScale places first, so float
+// noise such as 9.99999999997 becomes 10.0000 instead of being truncated.
+func convertMoneyColumns(db *gorm.DB) error {
+	for _, money := range moneyColumns {
+		if !db.Migrator().HasTable(money.model) {
+			continue
+		}
+		columnTypes, err := db.Migrator().ColumnTypes(money.model)
+		if err != nil {
+			return err
+		}
+		for _, column := range columnTypes {
+			typeName := strings.ToLower(column.DatabaseTypeName())
+			if !slices.Contains(money.columns, column.Name()) || (typeName != "double" && typeName != "float") {
+				continue
+			}
+			err := db.Unscoped().Model(money.model).Where("1 = 1").
+				UpdateColumn(column.Name(), gorm.Expr("ROUND(?, ?)", clause.Column{Name: column.Name()}, models.MoneyScale)).Error
+			if err != nil {
+				return err
+			}
+			if err := db.Migrator().AlterColumn(money.model, column.Name()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// moveOrderLines turns the product, variant, quantity and warehouse of the
+// orders placed before orders had lines into a line of each order, ties
+// their returns to that line, moves the line copied on their invoices
+// likewise and drops the old columns.
+func moveOrderLines(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if migrator.HasColumn(&models.Order{}, "product_id") {
+		// Kolom varian, harga satuan dan gudang baru ada sejak versi
+		// tertentu; tabel yang lebih lama tidak memilikinya
+		variantID, warehouseID := "NULL", "NULL"
+		if migrator.HasColumn(&models.Order{}, "variant_id") {
+			variantID = "variant_id"
+		}
+		if migrator.HasColumn(&models.Order{}, "warehouse_id") {
+			warehouseID = "warehouse_id"
+		}
+		unitPrice := "CASE WHEN quantity > 0 THEN ROUND(total / quantity, ?) ELSE 0 END"
+		if migrator.HasColumn(&models.Order{}, "unit_price") {
+			unitPrice = "CASE WHEN unit_price = 0 AND quantity > 0 THEN ROUND(total / quantity, ?) ELSE unit_price END"
+		}
+		// Diskon baris adalah semua diskon pesanan selain gratis ongkir
+		err := db.Exec(`INSERT INTO order_items (created_at, updated_at, deleted_at, order_id, product_id, variant_id, quantity,
+				unit_price, discount_total, subtotal, tax_total, cost_total, warehouse_id)
+			SELECT created_at, updated_at, deleted_at, id, product_id, `+variantID+`, quantity, `+unitPrice+`,
+				COALESCE((SELECT SUM(amount) FROM order_discounts WHERE order_discounts.order_id = orders.id
+					AND order_discounts.type <> ? AND order_discounts.deleted_at IS NULL), 0),
+				subtotal, tax_total, cost_total, `+warehouseID+`
+			FROM orders WHERE NOT EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id)`,
+			models.MoneyScale, models.PromotionTypeFreeShipping).Error
+		if err != nil {
+			return err
+		}
+		for _, constraint := range []string{"fk_orders_product", "fk_orders_variant", "fk_orders_warehouse"} {
+			if migrator.HasConstraint(&models.Order{}, constraint) {
+				if err := migrator.DropConstraint(&models.Order{}, constraint); err != nil {
+					return err
+				}
+			}
+		}
+		for _, column := range []string{"product_id", "variant_id", "quantity", "unit_price", "warehouse_id"} {
+			if !migrator.HasColumn(&models.Order{}, column) {
+				continue
+			}
+			if err := migrator.DropColumn(&models.Order{}, column); err != nil {
+				return err
+			}
+		}
+	}
+
+	err := db.Exec(`UPDATE order_returns SET order_item_id = (
+			SELECT MIN(id) FROM order_items WHERE order_items.order_id = order_returns.order_id)
+		WHERE order_item_id = 0`).Error
+	if err != nil {
+		return err
+	}
+
+	if migrator.HasColumn(&models.Invoice{}, "description") {
+		err := db.Exec(`INSERT INTO invoice_items (created_at, updated_at, invoice_id, description, sku, quantity, unit_price, subtotal)
+			SELECT created_at, updated_at, id, description, sku, quantity, unit_price, subtotal
+			FROM invoices WHERE NOT EXISTS (SELECT 1 FROM invoice_items WHERE invoice_items.invoice_id = invoices.id)`).Error
+		if err != nil {
+			return err
+		}
+		for _, column := range []string{"description", "sku", "quantity", "unit_price"} {
+			if err := migrator.DropColumn(&models.Invoice{}, column); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // RunMigration migrates the database schema.
 func RunMigration() {
 	db, err := database.InitDB()
@@ -15,11 +137,122 @@ func RunMigration() {
 		log.Fatal(err)
 	}
 
+	// Stok kini unik per gudang, produk dan varian; indeks lama tanpa varian dibuang
+	if db.Migrator().HasIndex(&models.WarehouseStock{}, "idx_warehouse_product") {
+		if err := db.Migrator().DropIndex(&models.WarehouseStock{}, "idx_warehouse_product"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Jumlah uang yang dulu disimpan sebagai float diubah menjadi desimal pasti
+	if err := convertMoneyColumns(db); err != nil {
+		log.Fatal(err)
+	}
+
 	// Auto-migrate models
-	err = db.AutoMigrate(&models.Product{}, &models.Category{}, &models.Order{}, &models.Supplier{})
+	err = db.AutoMigrate(
+		&models.User{},
+		&models.Product{},
+		&models.Category{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.Supplier{},
+		&models.Warehouse{},
+		&models.WarehouseStock{},
+		&models.StockTransfer{},
+		&models.StockTransferItem{},
+		&models.StockMovement{},
+		&models.LowStockAlert{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderItem{},
+		&models.GoodsReceipt{},
+		&models.GoodsReceiptItem{},
+		&models.SupplierPrice{},
+		&models.SupplierContact{},
+		&models.SupplierAddress{},
+		&models.ProductSupplier{},
+		&models.Slug{},
+		&models.OptionType{},
+		&models.OptionValue{},
+		&models.ProductVariant{},
+		&models.Attribute{},
+		&models.ProductAttributeValue{},
+		&models.ProductImage{},
+		&models.ImportJob{},
+		&models.PriceChange{},
+		&models.ExchangeRate{},
+		&models.ProductPrice{},
+		&models.TaxClass{},
+		&models.TaxRate{},
+		&models.OrderTax{},
+		&models.Promotion{},
+		&models.OrderDiscount{},
+		&models.Customer{},
+		&models.CustomerAddress{},
+		&models.Cart{},
+		&models.CartItem{},
+		&models.Payment{},
+		&models.PaymentEvent{},
+		&models.OrderReturn{},
+		&models.CreditNote{},
+		&models.Invoice{},
+		&models.InvoiceItem{},
+		&models.InvoiceTax{},
+		&models.InvoiceSequence{},
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Stok yang sudah ada sebelum ledger dicatat sebagai saldo awal
+	if err := services.SeedOpeningBalances(db); err != nil {
+		log.Fatal(err)
+	}
+
+	// Supplier lama setiap produk menjadi supplier utama di relasi banyak-ke-banyak
+	if err := services.SeedProductSuppliers(db); err != nil {
+		log.Fatal(err)
+	}
+
+	// Pesanan lama tercatat dalam mata uang dasar
+	if err := db.Unscoped().Model(&models.Order{}).Where("currency = ''").UpdateColumn("currency", services.BaseCurrency()).Error; err != nil {
+		log.Fatal(err)
+	}
+
+	// Pesanan lama belum dikenai pajak: subtotalnya sama dengan totalnya
+	if db.Migrator().HasColumn(&models.Order{}, "quantity") {
+		err = db.Unscoped().Model(&models.Order{}).
+			Where("subtotal = 0 AND tax_total = 0 AND total <> 0 AND quantity > 0").
+			UpdateColumn("subtotal", gorm.Expr("total")).Error
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Pesanan lama dengan satu produk menjadi pesanan dengan satu baris
+	if err := moveOrderLines(db); err != nil {
+		log.Fatal(err)
+	}
+
+	// Alamat pertama pelanggan yang belum punya alamat default menjadi default
+	err = db.Exec(`UPDATE customer_addresses SET is_default_shipping = true, is_default_billing = true
+		WHERE id IN (SELECT id FROM (
+			SELECT MIN(id) AS id FROM customer_addresses WHERE deleted_at IS NULL GROUP BY customer_id
+			HAVING MAX(is_default_shipping) = 0 AND MAX(is_default_billing) = 0
+		) AS first_addresses)`).Error
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// Kode gudang yang sudah dihapus dibebaskan untuk gudang baru
+	if err := services.FreeDeletedWarehouseCodes(db); err != nil {
+		log.Fatal(err)
+	}
+
+	// Produk dan kategori lama mendapat slug dari namanya
+	if err := services.BackfillSlugs(db); err != nil {
+		log.Fatal(err)
+	}
+
 	fmt.Println("Migrasi berhasil dijalankan")
 }
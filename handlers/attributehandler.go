@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func attributeResponse(attribute models.Attribute) models.AttributeResponse {
+	options := attribute.Options
+	if options == nil {
+		options = []string{}
+	}
+	return models.AttributeResponse{
+		ID:         attribute.ID,
+		CategoryID: attribute.CategoryID,
+		Code:       attribute.Code,
+		Name:       attribute.Name,
+		Type:       attribute.Type,
+		Required:   attribute.Required,
+		Unit:       attribute.Unit,
+		Options:    options,
+		Min:        attribute.Min,
+		Max:        attribute.Max,
+		Position:   attribute.Position,
+	}
+}
+
+// attributeErrorStatus maps attribute errors to HTTP status codes.
+func attributeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrInvalidAttribute), errors.Is(err, services.ErrInvalidAttributeValue):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// GetCategoryAttributes handles retrieving the attributes of a category.
+// @Summary Get category attributes
+// @Description Retrieve the attributes products of a category can hold, including those inherited from parent categories
+// @Tags Categories
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {array} models.AttributeResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /categories/{id}/attributes [get]
+// @Security BearerAuth
+func GetCategoryAttributes(c *fiber.Ctx) error {
+	db := database.DB
+	var category models.Category
+	if err := db.First(&category, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+
+	attributes, err := services.CategoryAttributes(db, category.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.AttributeResponse, 0, len(attributes))
+	for _, attribute := range attributes {
+		response = append(response, attributeResponse(attribute))
+	}
+
+	return c.JSON(response)
+}
+
+// CreateCategoryAttribute handles adding an attribute to a category.
+// @Summary Create a category attribute
+// @Description Define an attribute (text, number, boolean, date or select) for products of a category and its sub-categories
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param attribute body models.AttributeRequest true "Attribute data"
+// @Success 201 {object} models.AttributeResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /categories/{id}/attributes [post]
+// @Security BearerAuth
+func CreateCategoryAttribute(c *fiber.Ctx) error {
+	var req models.AttributeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	var category models.Category
+	if err := database.DB.First(&category, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+	return saveAttribute(c, &models.Attribute{CategoryID: category.ID}, req, fiber.StatusCreated)
+}
+
+// UpdateCategoryAttribute handles updating an attribute of a category.
+// @Summary Update a category attribute
+// @Description Update an attribute definition. Existing product values are not re-validated.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param attributeId path int true "Attribute ID"
+// @Param attribute body models.AttributeRequest true "Attribute data"
+// @Success 200 {object} models.AttributeResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /categories/{id}/attributes/{attributeId} [put]
+// @Security BearerAuth
+func UpdateCategoryAttribute(c *fiber.Ctx) error {
+	var req models.AttributeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	var attribute models.Attribute
+	if err := database.DB.Where("category_id = ?", c.Params("id")).First(&attribute, c.Params("attributeId")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Attribute not found",
+		})
+	}
+	return saveAttribute(c, &attribute, req, fiber.StatusOK)
+}
+
+func saveAttribute(c *fiber.Ctx, attribute *models.Attribute, req models.AttributeRequest, status int) error {
+	db := database.DB
+	attribute.Code = req.Code
+	attribute.Name = req.Name
+	attribute.Type = req.Type
+	attribute.Required = req.Required
+	attribute.Unit = req.Unit
+	attribute.Options = req.Options
+	attribute.Min = req.Min
+	attribute.Max = req.Max
+	attribute.Position = req.Position
+	if err := services.ValidateAttribute(attribute); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var taken int64
+	db.Model(&models.Attribute{}).Where("category_id = ? AND code = ? AND id <> ?", attribute.CategoryID, attribute.Code, attribute.ID).Count(&taken)
+	if taken > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Code is already used by another attribute of this category",
+		})
+	}
+
+	if err := db.Save(attribute).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(status).JSON(attributeResponse(*attribute))
+}
+
+// DeleteCategoryAttribute handles deleting an attribute of a category.
+// @Summary Delete a category attribute
+// @Description Delete an attribute definition together with the product values stored for it
+// @Tags Categories
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param attributeId path int true "Attribute ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /categories/{id}/attributes/{attributeId} [delete]
+// @Security BearerAuth
+func DeleteCategoryAttribute(c *fiber.Ctx) error {
+	db := database.DB
+	var attribute models.Attribute
+	if err := db.Where("category_id = ?", c.Params("id")).First(&attribute, c.Params("attributeId")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Attribute not found",
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("attribute_id = ?", attribute.ID).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+		// Dihapus permanen agar kodenya bisa dipakai lagi
+		return tx.Unscoped().Delete(&attribute).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetProductAttributes handles retrieving the attribute values of a product.
+// @Summary Get product attributes
+// @Description Retrieve the custom attribute values of a product
+// @Tags Products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.ProductAttributeResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/attributes [get]
+// @Security BearerAuth
+func GetProductAttributes(c *fiber.Ctx) error {
+	db := database.DB
+	var product models.Product
+	if err := db.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+	return productAttributes(c, product)
+}
+
+// SetProductAttributes handles updating the attribute values of a product.
+// @Summary Set product attributes
+// @Description Set custom attribute values of a product by attribute code. Codes left out keep their value; an empty value removes it.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param attributes body map[string]string true "Values by attribute code"
+// @Success 200 {array} models.ProductAttributeResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/attributes [put]
+// @Security BearerAuth
+func SetProductAttributes(c *fiber.Ctx) error {
+	db := database.DB
+	values := map[string]string{}
+	if err := c.BodyParser(&values); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	var product models.Product
+	if err := db.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.SetProductAttributes(tx, &product, values)
+	})
+	if err != nil {
+		return c.Status(attributeErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return productAttributes(c, product)
+}
+
+func productAttributes(c *fiber.Ctx, product models.Product) error {
+	var values []models.ProductAttributeValue
+	err := database.DB.Preload("Attribute").
+		Joins("JOIN attributes ON attributes.id = product_attribute_values.attribute_id").
+		Where("product_attribute_values.product_id = ?", product.ID).
+		Order("attributes.position, attributes.id").
+		Find(&values).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.ProductAttributeResponse, 0, len(values))
+	for _, value := range values {
+		response = append(response, models.ProductAttributeResponse{
+			AttributeID: value.AttributeID,
+			Code:        value.Attribute.Code,
+			Name:        value.Attribute.Name,
+			Type:        value.Attribute.Type,
+			Unit:        value.Attribute.Unit,
+			Value:       value.Value,
+		})
+	}
+
+	return c.JSON(response)
+}
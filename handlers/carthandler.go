@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// cartTokenHeader carries the token of an anonymous cart.
+const cartTokenHeader = "X-Cart-Token"
+
+func cartResponse(cart models.Cart) models.CartResponse {
+	response := models.CartResponse{
+		ID:       cart.ID,
+		Token:    cart.Token,
+		Items:    make([]models.CartItemResponse, 0, len(cart.Items)),
+		Currency: services.BaseCurrency(),
+	}
+	for _, item := range cart.Items {
+		price := services.CartItemPrice(item)
+		lineTotal := price.Times(int64(item.Quantity))
+		itemResponse := models.CartItemResponse{
+			ID:             item.ID,
+			ProductID:      item.ProductID,
+			VariantID:      item.VariantID,
+			Name:           item.Product.Name,
+			Quantity:       item.Quantity,
+			UnitPrice:      price,
+			LineTotal:      lineTotal,
+			PriceWhenAdded: item.PriceWhenAdded,
+			PriceChanged:   !price.Equal(item.PriceWhenAdded),
+		}
+		if item.Variant != nil {
+			itemResponse.SKU = item.Variant.SKU
+		} else if item.Product.SKU != nil {
+			itemResponse.SKU = *item.Product.SKU
+		}
+		response.Items = append(response.Items, itemResponse)
+		response.Quantity += item.Quantity
+		response.Subtotal = response.Subtotal.Add(lineTotal)
+	}
+	return response
+}
+
+// cartErrorStatus maps cart errors to HTTP status codes.
+func cartErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrEmptyCart), errors.Is(err, services.ErrInvalidQuantity):
+		return fiber.StatusBadRequest
+	default:
+		return orderErrorStatus(err)
+	}
+}
+
+// withCart finds the cart of the shopper, merging in their anonymous cart
+// when they are logged in, applies change to it and loads its items, all
+// in one transaction.
+func withCart(c *fiber.Ctx, change func(tx *gorm.DB, cart *models.Cart) error) (*models.Cart, error) {
+	var cart *models.Cart
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if cart, err = services.FindCart(tx, currentUserID(c), c.Get(cartTokenHeader)); err != nil {
+			return err
+		}
+		if change != nil {
+			if err := change(tx, cart); err != nil {
+				return err
+			}
+		}
+		return services.LoadCartItems(tx, cart)
+	})
+	return cart, err
+}
+
+// GetCart handles retrieving the cart of the shopper.
+// @Summary Get cart
+// @Description Retrieve the cart of the shopper priced at current prices, starting an empty one when there is none. Anonymous shoppers are identified by the token of their cart in the X-Cart-Token header; logged in users get their own cart, into which the anonymous cart whose token they send is merged.
+// @Tags Cart
+// @Produce json
+// @Param X-Cart-Token header string false "Token of an anonymous cart"
+// @Success 200 {object} models.CartResponse
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /cart [get]
+// @Security BearerAuth
+func GetCart(c *fiber.Ctx) error {
+	cart, err := withCart(c, nil)
+	if err != nil {
+		return c.Status(cartErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(cartResponse(*cart))
+}
+
+// AddCartItem handles putting a product in the cart.
+// @Summary Add cart item
+// @Description Put a product or variant in the cart of the shopper, adding to the quantity of the item already holding it
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Token of an anonymous cart"
+// @Param item body models.CartItemRequest true "Item"
+// @Success 201 {object} models.CartResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /cart/items [post]
+// @Security BearerAuth
+func AddCartItem(c *fiber.Ctx) error {
+	var req models.CartItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	cart, err := withCart(c, func(tx *gorm.DB, cart *models.Cart) error {
+		_, err := services.AddCartItem(tx, cart, req)
+		return err
+	})
+	if err != nil {
+		return c.Status(cartErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(cartResponse(*cart))
+}
+
+// UpdateCartItem handles changing the quantity of a cart item.
+// @Summary Update cart item
+// @Description Change the quantity of an item in the cart of the shopper; a quantity of 0 removes it
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Token of an anonymous cart"
+// @Param itemId path int true "Cart item ID"
+// @Param item body models.CartItemQuantityRequest true "Quantity"
+// @Success 200 {object} models.CartResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /cart/items/{itemId} [put]
+// @Security BearerAuth
+func UpdateCartItem(c *fiber.Ctx) error {
+	var req models.CartItemQuantityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	itemID, err := c.ParamsInt("itemId")
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Cart item not found",
+		})
+	}
+
+	cart, err := withCart(c, func(tx *gorm.DB, cart *models.Cart) error {
+		return services.SetCartItemQuantity(tx, cart, uint(itemID), req.Quantity)
+	})
+	if err != nil {
+		return c.Status(cartErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(cartResponse(*cart))
+}
+
+// DeleteCartItem handles removing an item from the cart.
+// @Summary Delete cart item
+// @Description Remove an item from the cart of the shopper
+// @Tags Cart
+// @Produce json
+// @Param X-Cart-Token header string false "Token of an anonymous cart"
+// @Param itemId path int true "Cart item ID"
+// @Success 200 {object} models.CartResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /cart/items/{itemId} [delete]
+// @Security BearerAuth
+func DeleteCartItem(c *fiber.Ctx) error {
+	itemID, err := c.ParamsInt("itemId")
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Cart item not found",
+		})
+	}
+
+	cart, err := withCart(c, func(tx *gorm.DB, cart *models.Cart) error {
+		return services.SetCartItemQuantity(tx, cart, uint(itemID), 0)
+	})
+	if err != nil {
+		return c.Status(cartErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(cartResponse(*cart))
+}
+
+// ClearCart handles emptying the cart.
+// @Summary Clear cart
+// @Description Remove every item from the cart of the shopper
+// @Tags Cart
+// @Produce json
+// @Param X-Cart-Token header string false "Token of an anonymous cart"
+// @Success 200 {object} models.CartResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /cart [delete]
+// @Security BearerAuth
+func ClearCart(c *fiber.Ctx) error {
+	cart, err := withCart(c, services.ClearCart)
+	if err != nil {
+		return c.Status(cartErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(cartResponse(*cart))
+}
+
+// CheckoutCart handles turning the cart into an order.
+// @Summary Check out cart
+// @Description Place one order with a line for every item of the cart of the logged in user and empty the cart. The lines are priced at current prices and promotions with the coupon given, the shipping fee is charged once, and their stock is taken, in one transaction: when any item cannot be ordered, for example for lack of stock, no order is placed and the cart is kept. Anonymous shoppers have to log in first; their cart is merged into the cart of their account.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Token of the anonymous cart to merge in"
+// @Param checkout body models.CheckoutRequest true "Checkout"
+// @Success 201 {object} models.OrderResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /cart/checkout [post]
+// @Security BearerAuth
+func CheckoutCart(c *fiber.Ctx) error {
+	var req models.CheckoutRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	userID := currentUserID(c)
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Log in to check out",
+		})
+	}
+	customer, err := currentCustomer(c)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+	if customer != nil {
+		req.CustomerID = &customer.ID
+	}
+
+	var order models.Order
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		cart, err := services.FindCart(tx, userID, c.Get(cartTokenHeader))
+		if err != nil {
+			return err
+		}
+		if err := services.LoadCartItems(tx, cart); err != nil {
+			return err
+		}
+		if len(cart.Items) == 0 {
+			return services.ErrEmptyCart
+		}
+		lines := make([]models.OrderItemRequest, 0, len(cart.Items))
+		for _, item := range cart.Items {
+			lines = append(lines, models.OrderItemRequest{
+				ProductID: item.ProductID,
+				VariantID: item.VariantID,
+				Quantity:  item.Quantity,
+			})
+		}
+		order, err = placeOrder(c, tx, models.OrderRequest{
+			Items:             lines,
+			TaxRegion:         req.TaxRegion,
+			CouponCode:        req.CouponCode,
+			Currency:          req.Currency,
+			CustomerID:        req.CustomerID,
+			ShippingAddressID: req.ShippingAddressID,
+			BillingAddressID:  req.BillingAddressID,
+			Strategy:          req.Strategy,
+			Latitude:          req.Latitude,
+			Longitude:         req.Longitude,
+		})
+		if err != nil {
+			return err
+		}
+		return services.ClearCart(tx, cart)
+	})
+	if err != nil {
+		return c.Status(cartErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(orderResponse(order))
+}
@@ -1,11 +1,56 @@
 package handlers
 
 import (
+    "errors"
+
     "github.com/DewiKresnawati/DewiWebService/database"
     "github.com/DewiKresnawati/DewiWebService/models"
+    "github.com/DewiKresnawati/DewiWebService/services"
     "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
 )
 
+func categoryResponse(category models.Category) models.CategoryResponse {
+    return models.CategoryResponse{
+        ID:              category.ID,
+        Name:            category.Name,
+        ParentID:        category.ParentID,
+        Position:        category.Position,
+        TaxClassID:      category.TaxClassID,
+        Slug:            category.Slug,
+        MetaTitle:       category.MetaTitle,
+        MetaDescription: category.MetaDescription,
+    }
+}
+
+// assignCategorySlug gives the category the requested slug, or one derived
+// from its name.
+func assignCategorySlug(tx *gorm.DB, category *models.Category, desired string) error {
+    if desired == "" {
+        desired = category.Name
+    }
+    slug, err := services.AssignSlug(tx, models.SlugTypeCategory, category.ID, desired)
+    if err != nil {
+        return err
+    }
+    category.Slug = slug
+    return tx.Model(category).UpdateColumn("slug", slug).Error
+}
+
+// categoryErrorStatus maps category service errors to HTTP status codes.
+func categoryErrorStatus(err error) int {
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        return fiber.StatusNotFound
+    case errors.Is(err, services.ErrCategoryCycle), errors.Is(err, services.ErrCategoryHasChildren):
+        return fiber.StatusConflict
+    case errors.Is(err, services.ErrUnknownTaxClass):
+        return fiber.StatusBadRequest
+    default:
+        return fiber.StatusInternalServerError
+    }
+}
+
 // CreateCategory handles creating a new category.
 // @Summary Create a new category
 // @Description Create a new category
@@ -29,21 +74,41 @@ func CreateCategory(c *fiber.Ctx) error {
     }
 
     category := models.Category{
-        Name: req.Name,
+        Name:            req.Name,
+        ParentID:        req.ParentID,
+        TaxClassID:      req.TaxClassID,
+        MetaTitle:       req.MetaTitle,
+        MetaDescription: req.MetaDescription,
     }
 
-    if err := db.Create(&category).Error; err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+    // Kategori baru ditempatkan paling akhir di bawah induknya
+    err := db.Transaction(func(tx *gorm.DB) error {
+        if category.ParentID != nil {
+            var parent models.Category
+            if err := tx.First(&parent, *category.ParentID).Error; err != nil {
+                return err
+            }
+        }
+        if err := services.CheckTaxClass(tx, category.TaxClassID); err != nil {
+            return err
+        }
+        position, err := services.NextCategoryPosition(tx, category.ParentID)
+        if err != nil {
+            return err
+        }
+        category.Position = position
+        if err := tx.Create(&category).Error; err != nil {
+            return err
+        }
+        return assignCategorySlug(tx, &category, req.Slug)
+    })
+    if err != nil {
+        return c.Status(categoryErrorStatus(err)).JSON(fiber.Map{
             "error": err.Error(),
         })
     }
 
-    response := models.CategoryResponse{
-        ID:   category.ID,
-        Name: category.Name,
-    }
-
-    return c.Status(fiber.StatusCreated).JSON(response)
+    return c.Status(fiber.StatusCreated).JSON(categoryResponse(category))
 }
 
 // GetAllCategories handles retrieving all categories.
@@ -60,7 +125,7 @@ func CreateCategory(c *fiber.Ctx) error {
 func GetAllCategories(c *fiber.Ctx) error {
     db := database.DB
     var categories []models.Category
-    if err := db.Find(&categories).Error; err != nil {
+    if err := db.Order("position, id").Find(&categories).Error; err != nil {
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
             "error": err.Error(),
         })
@@ -68,10 +133,7 @@ func GetAllCategories(c *fiber.Ctx) error {
 
     var response []models.CategoryResponse
     for _, category := range categories {
-        response = append(response, models.CategoryResponse{
-            ID:   category.ID,
-            Name: category.Name,
-        })
+        response = append(response, categoryResponse(category))
     }
 
     return c.JSON(response)
@@ -100,12 +162,7 @@ func GetCategoryByID(c *fiber.Ctx) error {
         })
     }
 
-    response := models.CategoryResponse{
-        ID:   category.ID,
-        Name: category.Name,
-    }
-
-    return c.JSON(response)
+    return c.JSON(categoryResponse(category))
 }
 
 // UpdateCategory handles updating an existing category.
@@ -141,24 +198,34 @@ func UpdateCategory(c *fiber.Ctx) error {
     }
 
     category.Name = req.Name
+    category.TaxClassID = req.TaxClassID
+    category.MetaTitle = req.MetaTitle
+    category.MetaDescription = req.MetaDescription
 
-    if err := db.Save(&category).Error; err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+    err := db.Transaction(func(tx *gorm.DB) error {
+        if err := services.CheckTaxClass(tx, category.TaxClassID); err != nil {
+            return err
+        }
+        if err := tx.Save(&category).Error; err != nil {
+            return err
+        }
+        if req.Slug != "" && services.Slugify(req.Slug) != category.Slug {
+            return assignCategorySlug(tx, &category, req.Slug)
+        }
+        return nil
+    })
+    if err != nil {
+        return c.Status(categoryErrorStatus(err)).JSON(fiber.Map{
             "error": err.Error(),
         })
     }
 
-    response := models.CategoryResponse{
-        ID:   category.ID,
-        Name: category.Name,
-    }
-
-    return c.JSON(response)
+    return c.JSON(categoryResponse(category))
 }
 
 // DeleteCategory handles deleting a category.
 // @Summary Delete category
-// @Description Delete a category by its ID
+// @Description Delete a category by its ID; categories with sub-categories cannot be deleted
 // @Tags Categories
 // @Accept json
 // @Produce json
@@ -166,17 +233,130 @@ func UpdateCategory(c *fiber.Ctx) error {
 // @Success 204 {object} nil
 // @Failure 401 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /categories/{id} [delete]
 // @Security BearerAuth
 func DeleteCategory(c *fiber.Ctx) error {
     db := database.DB
     id := c.Params("id")
-    if err := db.Delete(&models.Category{}, id).Error; err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+    err := db.Transaction(func(tx *gorm.DB) error {
+        var category models.Category
+        if err := tx.First(&category, id).Error; err != nil {
+            return err
+        }
+        return services.DeleteCategory(tx, &category)
+    })
+    if err != nil {
+        return c.Status(categoryErrorStatus(err)).JSON(fiber.Map{
             "error": err.Error(),
         })
     }
 
     return c.SendStatus(fiber.StatusNoContent)
 }
+
+// GetCategoryTree handles retrieving the category hierarchy.
+// @Summary Get category tree
+// @Description Retrieve all categories as a nested tree ordered by position
+// @Tags Categories
+// @Produce json
+// @Success 200 {array} models.CategoryTreeNode
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /categories/tree [get]
+// @Security BearerAuth
+func GetCategoryTree(c *fiber.Ctx) error {
+    tree, err := services.CategoryTree(database.DB)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.JSON(tree)
+}
+
+// MoveCategory handles moving a category in the hierarchy.
+// @Summary Move category
+// @Description Move a category under another parent (or to the top level) and/or to another position among its siblings
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param move body models.CategoryMoveRequest true "New parent and position"
+// @Success 200 {object} models.CategoryResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /categories/{id}/move [post]
+// @Security BearerAuth
+func MoveCategory(c *fiber.Ctx) error {
+    db := database.DB
+    id := c.Params("id")
+    var req models.CategoryMoveRequest
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    var category models.Category
+    err := db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.First(&category, id).Error; err != nil {
+            return err
+        }
+        return services.MoveCategory(tx, &category, req.ParentID, req.Position)
+    })
+    if err != nil {
+        return c.Status(categoryErrorStatus(err)).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.JSON(categoryResponse(category))
+}
+
+// GetCategoryBySlug handles retrieving a category by its slug.
+// @Summary Get category by slug
+// @Description Retrieve a category by its URL slug. Old slugs answer with a 301 redirect to the current one.
+// @Tags Categories
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Success 200 {object} models.CategoryResponse
+// @Success 301 {object} nil
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /categories/by-slug/{slug} [get]
+// @Security BearerAuth
+func GetCategoryBySlug(c *fiber.Ctx) error {
+    db := database.DB
+    slug := c.Params("slug")
+    id, current, err := services.ResolveSlug(db, models.SlugTypeCategory, slug)
+    if err != nil {
+        status := categoryErrorStatus(err)
+        if status == fiber.StatusNotFound {
+            return c.Status(status).JSON(fiber.Map{
+                "error": "Category not found",
+            })
+        }
+        return c.Status(status).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+    if current != slug {
+        return redirectToSlug(c, "/api/v1/categories/by-slug/"+current)
+    }
+
+    var category models.Category
+    if err := db.First(&category, id).Error; err != nil {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "error": "Category not found",
+        })
+    }
+
+    return c.JSON(categoryResponse(category))
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func exchangeRateResponse(rate models.ExchangeRate) models.ExchangeRateResponse {
+	return models.ExchangeRateResponse{
+		ID:            rate.ID,
+		BaseCurrency:  services.BaseCurrency(),
+		Currency:      rate.Currency,
+		Rate:          rate.Rate,
+		EffectiveFrom: rate.EffectiveFrom,
+		Source:        rate.Source,
+		UserID:        rate.UserID,
+	}
+}
+
+func productPriceResponse(price models.ProductPrice) models.ProductPriceResponse {
+	return models.ProductPriceResponse{
+		ProductID: price.ProductID,
+		Currency:  price.Currency,
+		Price:     price.Price,
+	}
+}
+
+// currencyErrorStatus maps currency errors to HTTP status codes.
+func currencyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrUnknownCurrency),
+		errors.Is(err, services.ErrInvalidRate),
+		errors.Is(err, services.ErrBaseCurrencyRate),
+		errors.Is(err, services.ErrBaseCurrencyPrice),
+		errors.Is(err, services.ErrNoExchangeRate),
+		errors.Is(err, services.ErrInvalidPrice):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// GetExchangeRates handles listing exchange rates.
+// @Summary Get exchange rates
+// @Description Retrieve the exchange rates against the base currency, newest first. A rate is the number of units of the currency one unit of the base currency buys, from its effective date until the next rate of that currency.
+// @Tags Currencies
+// @Produce json
+// @Param currency query string false "Only rates of this currency"
+// @Success 200 {array} models.ExchangeRateResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /exchange-rates [get]
+// @Security BearerAuth
+func GetExchangeRates(c *fiber.Ctx) error {
+	query := database.DB.Order("effective_from DESC, currency")
+	if code := c.Query("currency"); code != "" {
+		currency, err := services.NormaliseCurrency(code)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		query = query.Where("currency = ?", currency)
+	}
+
+	var rates []models.ExchangeRate
+	if err := query.Find(&rates).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.ExchangeRateResponse, 0, len(rates))
+	for _, rate := range rates {
+		response = append(response, exchangeRateResponse(rate))
+	}
+	return c.JSON(response)
+}
+
+// CreateExchangeRate handles recording an exchange rate.
+// @Summary Create exchange rate
+// @Description Record the rate of a currency from a date on; a rate already recorded for that currency and date is replaced
+// @Tags Currencies
+// @Accept json
+// @Produce json
+// @Param rate body models.ExchangeRateRequest true "Exchange rate"
+// @Success 201 {object} models.ExchangeRateResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /exchange-rates [post]
+// @Security BearerAuth
+func CreateExchangeRate(c *fiber.Ctx) error {
+	var req models.ExchangeRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	effectiveFrom := time.Now().Truncate(24 * time.Hour)
+	if req.EffectiveFrom != "" {
+		date, err := time.ParseInLocation("2006-01-02", req.EffectiveFrom, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid effective_from date, expected YYYY-MM-DD",
+			})
+		}
+		effectiveFrom = date
+	}
+
+	rate := models.ExchangeRate{
+		Currency:      req.Currency,
+		Rate:          req.Rate,
+		EffectiveFrom: effectiveFrom,
+		Source:        "manual",
+		UserID:        currentUserID(c),
+	}
+	if err := services.SaveExchangeRate(database.DB, &rate); err != nil {
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(exchangeRateResponse(rate))
+}
+
+// ImportExchangeRates handles importing exchange rates from a file.
+// @Summary Import exchange rates
+// @Description Record exchange rates from a CSV or XLSX file with the columns currency, rate and effective_from (YYYY-MM-DD). Valid rows are saved, replacing rates of the same currency and date; rejected rows are listed in the response.
+// @Tags Currencies
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "CSV or XLSX file"
+// @Success 200 {object} models.ExchangeRateImportResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /exchange-rates/import [post]
+// @Security BearerAuth
+func ImportExchangeRates(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A CSV or XLSX file is required in the file field",
+		})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	rows, err := services.ReadImportFile(fileHeader.Filename, data)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, services.ErrImportFile) {
+			status = fiber.StatusBadRequest
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := models.ExchangeRateImportResponse{Errors: []models.ImportRowError{}}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		imported, rowErrors, err := services.ImportExchangeRates(tx, rows, currentUserID(c))
+		response.Imported = imported
+		if rowErrors != nil {
+			response.Errors = rowErrors
+		}
+		return err
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// DeleteExchangeRate handles deleting an exchange rate.
+// @Summary Delete exchange rate
+// @Description Delete an exchange rate; the previous rate of the currency applies again from its date
+// @Tags Currencies
+// @Produce json
+// @Param id path int true "Exchange rate ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /exchange-rates/{id} [delete]
+// @Security BearerAuth
+func DeleteExchangeRate(c *fiber.Ctx) error {
+	db := database.DB
+	var rate models.ExchangeRate
+	if err := db.First(&rate, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Exchange rate not found",
+		})
+	}
+	// Dihapus permanen agar kurs baru bisa dicatat lagi pada tanggal yang sama
+	if err := db.Unscoped().Delete(&rate).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetProductPrices handles listing the currency prices of a product.
+// @Summary Get product currency prices
+// @Description Retrieve the prices of a product set by hand for currencies other than the base currency. Currencies without one use the converted base price.
+// @Tags Products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.ProductPriceResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/prices [get]
+// @Security BearerAuth
+func GetProductPrices(c *fiber.Ctx) error {
+	db := database.DB
+	var product models.Product
+	if err := db.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+
+	var prices []models.ProductPrice
+	if err := db.Where("product_id = ?", product.ID).Order("currency").Find(&prices).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.ProductPriceResponse, 0, len(prices))
+	for _, price := range prices {
+		response = append(response, productPriceResponse(price))
+	}
+	return c.JSON(response)
+}
+
+// SetProductPrice handles setting the price of a product in a currency.
+// @Summary Set product currency price
+// @Description Set the price of a product in a currency other than the base currency, instead of converting the base price
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param currency path string true "Currency code"
+// @Param price body models.ProductPriceRequest true "Price"
+// @Success 200 {object} models.ProductPriceResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/prices/{currency} [put]
+// @Security BearerAuth
+func SetProductPrice(c *fiber.Ctx) error {
+	var req models.ProductPriceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	db := database.DB
+	var product models.Product
+	if err := db.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+
+	price, err := services.SetProductPrice(db, product.ID, c.Params("currency"), req.Price)
+	if err != nil {
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(productPriceResponse(*price))
+}
+
+// DeleteProductPrice handles removing the price of a product in a currency.
+// @Summary Delete product currency price
+// @Description Remove the price of a product in a currency, so that the converted base price is used again
+// @Tags Products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param currency path string true "Currency code"
+// @Success 204 {object} nil
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/prices/{currency} [delete]
+// @Security BearerAuth
+func DeleteProductPrice(c *fiber.Ctx) error {
+	currency, err := services.NormaliseCurrency(c.Params("currency"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	err = database.DB.Unscoped().
+		Where("product_id = ? AND currency = ?", c.Params("id"), currency).
+		Delete(&models.ProductPrice{}).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
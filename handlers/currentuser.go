@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+// currentUserID returns the ID of the authenticated user taken from the JWT
+// claims that AuthMiddleware stores in the context, or nil when there is none.
+func currentUserID(c *fiber.Ctx) *uint {
+	claims, ok := c.Locals("user").(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+	id, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil
+	}
+	userID := uint(id)
+	return &userID
+}
+
+// currentSupplierID returns the supplier a supplier portal user is linked to,
+// or nil for other users.
+func currentSupplierID(c *fiber.Ctx) *uint {
+	claims, ok := c.Locals("user").(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+	id, ok := claims["supplier_id"].(float64)
+	if !ok {
+		return nil
+	}
+	supplierID := uint(id)
+	return &supplierID
+}
+
+// currentRole returns the role AuthMiddleware found in the token.
+func currentRole(c *fiber.Ctx) string {
+	role, _ := c.Locals("role").(string)
+	return role
+}
+
+// isStaff reports whether the request was made by a staff user.
+func isStaff(c *fiber.Ctx) bool {
+	return currentRole(c) == models.RoleStaff
+}
+
+// currentCustomer returns the customer profile of a customer user, or nil
+// for staff. A customer user without a profile gets gorm.ErrRecordNotFound.
+func currentCustomer(c *fiber.Ctx) (*models.Customer, error) {
+	if currentRole(c) != models.RoleCustomer {
+		return nil, nil
+	}
+	userID := currentUserID(c)
+	if userID == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return services.CustomerByUser(database.DB, *userID)
+}
@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/DewiKresnawati/DewiWebService/utils"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func customerAddressResponse(address models.CustomerAddress) models.CustomerAddressResponse {
+	return models.CustomerAddressResponse{
+		ID:              address.ID,
+		CustomerID:      address.CustomerID,
+		Label:           address.Label,
+		Recipient:       address.Recipient,
+		Phone:           address.Phone,
+		Street:          address.Street,
+		City:            address.City,
+		Province:        address.Province,
+		PostalCode:      address.PostalCode,
+		Country:         address.Country,
+		DefaultShipping: address.IsDefaultShipping,
+		DefaultBilling:  address.IsDefaultBilling,
+	}
+}
+
+func customerResponse(customer models.Customer) models.CustomerResponse {
+	addresses := make([]models.CustomerAddressResponse, 0, len(customer.Addresses))
+	for _, address := range customer.Addresses {
+		addresses = append(addresses, customerAddressResponse(address))
+	}
+	response := models.CustomerResponse{
+		ID:        customer.ID,
+		UserID:    customer.UserID,
+		Name:      customer.Name,
+		Email:     customer.Email,
+		Phone:     customer.Phone,
+		Addresses: addresses,
+		CreatedAt: customer.CreatedAt,
+	}
+	if customer.User != nil {
+		response.Username = customer.User.Username
+	}
+	return response
+}
+
+// loadCustomer loads a customer with its user and addresses.
+func loadCustomer(db *gorm.DB, id uint) (models.Customer, error) {
+	var customer models.Customer
+	err := db.Preload("User").Preload("Addresses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&customer, id).Error
+	return customer, err
+}
+
+// RegisterCustomer handles signing up a customer.
+// @Summary Register customer
+// @Description Create a customer account with its profile and log it in. Customers can place orders and see their own orders only.
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param customer body models.CustomerRegisterRequest true "Customer account"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers/register [post]
+func RegisterCustomer(c *fiber.Ctx) error {
+	var req models.CustomerRegisterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Username == "" || req.Password == "" || req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username, password and name are required",
+		})
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	user := models.User{
+		Username: req.Username,
+		Password: string(hashedPassword),
+		Role:     models.RoleCustomer,
+	}
+	var customer models.Customer
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		customer = models.Customer{
+			UserID: user.ID,
+			Name:   req.Name,
+			Email:  strings.TrimSpace(req.Email),
+			Phone:  strings.TrimSpace(req.Phone),
+		}
+		return tx.Create(&customer).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	token, err := utils.GenerateToken(user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	customer.User = &user
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"customer": customerResponse(customer),
+		"token":    token,
+	})
+}
+
+// GetMyCustomerProfile handles retrieving the profile of the logged in customer.
+// @Summary Get own customer profile
+// @Description Retrieve the profile and addresses of the logged in customer
+// @Tags Customers
+// @Produce json
+// @Success 200 {object} models.CustomerResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers/me [get]
+// @Security BearerAuth
+func GetMyCustomerProfile(c *fiber.Ctx) error {
+	current, err := currentCustomer(c)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+	customer, err := loadCustomer(database.DB, current.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(customerResponse(customer))
+}
+
+// UpdateMyCustomerProfile handles updating the profile of the logged in customer.
+// @Summary Update own customer profile
+// @Description Update the name, email and phone of the logged in customer
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param customer body models.CustomerRequest true "Profile"
+// @Success 200 {object} models.CustomerResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers/me [put]
+// @Security BearerAuth
+func UpdateMyCustomerProfile(c *fiber.Ctx) error {
+	var req models.CustomerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Name is required",
+		})
+	}
+
+	current, err := currentCustomer(c)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+	db := database.DB
+	customer, err := loadCustomer(db, current.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	customer.Name = req.Name
+	customer.Email = strings.TrimSpace(req.Email)
+	customer.Phone = strings.TrimSpace(req.Phone)
+	if err := db.Omit("User", "Addresses").Save(&customer).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(customerResponse(customer))
+}
+
+// customerErrorStatus maps customer errors to HTTP status codes.
+func customerErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrInvalidAddress), errors.Is(err, services.ErrUnknownAddress):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// applyCustomerAddressRequest copies the request onto address.
+func applyCustomerAddressRequest(address *models.CustomerAddress, req models.CustomerAddressRequest) {
+	address.Label = strings.TrimSpace(req.Label)
+	address.SetAddress(models.Address{
+		Recipient:  req.Recipient,
+		Phone:      req.Phone,
+		Street:     req.Street,
+		City:       req.City,
+		Province:   req.Province,
+		PostalCode: req.PostalCode,
+		Country:    req.Country,
+	})
+	address.IsDefaultShipping = req.DefaultShipping
+	address.IsDefaultBilling = req.DefaultBilling
+}
+
+// GetMyCustomerAddresses handles retrieving the addresses of the logged in customer.
+// @Summary Get own addresses
+// @Description Retrieve the addresses saved by the logged in customer
+// @Tags Customers
+// @Produce json
+// @Success 200 {array} models.CustomerAddressResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers/me/addresses [get]
+// @Security BearerAuth
+func GetMyCustomerAddresses(c *fiber.Ctx) error {
+	customer, err := currentCustomer(c)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+
+	var addresses []models.CustomerAddress
+	if err := database.DB.Where("customer_id = ?", customer.ID).Order("id").Find(&addresses).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.CustomerAddressResponse, 0, len(addresses))
+	for _, address := range addresses {
+		response = append(response, customerAddressResponse(address))
+	}
+
+	return c.JSON(response)
+}
+
+// CreateMyCustomerAddress handles adding an address for the logged in customer.
+// @Summary Create own address
+// @Description Save an address in the address book of the logged in customer. Postal codes and provinces are checked against the format of the country. The first address becomes the default shipping and billing address; marking another as default takes the flag from the previous one.
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param address body models.CustomerAddressRequest true "Address data"
+// @Success 201 {object} models.CustomerAddressResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers/me/addresses [post]
+// @Security BearerAuth
+func CreateMyCustomerAddress(c *fiber.Ctx) error {
+	var req models.CustomerAddressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	customer, err := currentCustomer(c)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+
+	address := models.CustomerAddress{CustomerID: customer.ID}
+	applyCustomerAddressRequest(&address, req)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.SaveCustomerAddress(tx, &address)
+	})
+	if err != nil {
+		return c.Status(customerErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(customerAddressResponse(address))
+}
+
+// UpdateMyCustomerAddress handles updating an address of the logged in customer.
+// @Summary Update own address
+// @Description Update an address in the address book of the logged in customer. Orders already placed keep the address they were placed with.
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param addressId path string true "Address ID"
+// @Param address body models.CustomerAddressRequest true "Address data"
+// @Success 200 {object} models.CustomerAddressResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers/me/addresses/{addressId} [put]
+// @Security BearerAuth
+func UpdateMyCustomerAddress(c *fiber.Ctx) error {
+	var req models.CustomerAddressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	customer, err := currentCustomer(c)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+
+	db := database.DB
+	var address models.CustomerAddress
+	if err := db.Where("customer_id = ?", customer.ID).First(&address, c.Params("addressId")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Address not found",
+		})
+	}
+	applyCustomerAddressRequest(&address, req)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return services.SaveCustomerAddress(tx, &address)
+	})
+	if err != nil {
+		return c.Status(customerErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(customerAddressResponse(address))
+}
+
+// DeleteMyCustomerAddress handles deleting an address of the logged in customer.
+// @Summary Delete own address
+// @Description Delete an address from the address book of the logged in customer. Orders already placed keep the address they were placed with.
+// @Tags Customers
+// @Produce json
+// @Param addressId path string true "Address ID"
+// @Success 204 {object} nil
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers/me/addresses/{addressId} [delete]
+// @Security BearerAuth
+func DeleteMyCustomerAddress(c *fiber.Ctx) error {
+	customer, err := currentCustomer(c)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+	if err := database.DB.Where("customer_id = ?", customer.ID).Delete(&models.CustomerAddress{}, c.Params("addressId")).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetCustomers handles listing customers.
+// @Summary Get customers
+// @Description Retrieve customers with their addresses, optionally searching name, email and username
+// @Tags Customers
+// @Produce json
+// @Param q query string false "Search term"
+// @Success 200 {array} models.CustomerResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers [get]
+// @Security BearerAuth
+func GetCustomers(c *fiber.Ctx) error {
+	query := database.DB.Preload("User").Preload("Addresses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Order("customers.id")
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + q + "%"
+		query = query.Joins("JOIN users ON users.id = customers.user_id").
+			Where("customers.name LIKE ? OR customers.email LIKE ? OR users.username LIKE ?", like, like, like)
+	}
+
+	var customers []models.Customer
+	if err := query.Find(&customers).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.CustomerResponse, 0, len(customers))
+	for _, customer := range customers {
+		response = append(response, customerResponse(customer))
+	}
+	return c.JSON(response)
+}
+
+// GetCustomerByID handles retrieving a customer.
+// @Summary Get customer by ID
+// @Description Retrieve a customer with its addresses
+// @Tags Customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Success 200 {object} models.CustomerResponse
+// @Failure 404 {object} map[string]interface{}
+// @Router /customers/{id} [get]
+// @Security BearerAuth
+func GetCustomerByID(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Customer not found",
+		})
+	}
+	customer, err := loadCustomer(database.DB, uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Customer not found",
+		})
+	}
+
+	return c.JSON(customerResponse(customer))
+}
+
+// GetCustomerOrders handles listing the orders of a customer.
+// @Summary Get customer orders
+// @Description Retrieve the orders of a customer, optionally filtered by product, warehouse and period
+// @Tags Customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param product_id query int false "Product ID"
+// @Param warehouse_id query int false "Warehouse ID"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD), inclusive"
+// @Param currency query string false "Currency of the totals, converted at the rate of the order date"
+// @Success 200 {array} models.OrderResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers/{id}/orders [get]
+// @Security BearerAuth
+func GetCustomerOrders(c *fiber.Ctx) error {
+	db := database.DB
+	var customer models.Customer
+	if err := db.First(&customer, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Customer not found",
+		})
+	}
+
+	query, err := filterOrders(c, db.Where("customer_id = ?", customer.ID))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	var orders []models.Order
+	if err := query.Preload("Items").Preload("Taxes").Preload("Discounts").Order("created_at DESC").Find(&orders).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := ordersInCurrency(c, orders); err != nil {
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.OrderResponse, 0, len(orders))
+	for _, order := range orders {
+		response = append(response, orderResponse(order))
+	}
+	return c.JSON(response)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// streamExport streams an export in the format asked for by the format query
+// parameter (csv by default). The response is written while the records are
+// read, so errors after the first byte can only be logged.
+func streamExport(c *fiber.Ctx, name string, columns []string, export func(services.ExportWriter) error) error {
+	format := c.Query("format", services.ExportCSV)
+	contentType, ok := services.ExportContentTypes[format]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": services.ErrUnknownExportFormat.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-%s.%s"`, name, time.Now().Format("20060102-150405"), format))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		writer, err := services.NewExportWriter(format, w, columns)
+		if err == nil {
+			err = export(writer)
+			if closeErr := writer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			log.Println(name, "export failed:", err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+// ExportProducts handles exporting products.
+// @Summary Export products
+// @Description Stream the products matched by the same filters as the product list, attribute filters (attr.<code>) included, as CSV, XLSX or NDJSON
+// @Tags Products
+// @Produce text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (default), xlsx or ndjson"
+// @Param category_id query int false "Category ID"
+// @Param include_descendants query bool false "Also include products of sub-categories"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Router /products/export [get]
+// @Security BearerAuth
+func ExportProducts(c *fiber.Ctx) error {
+	query, err := filterProducts(c, database.DB)
+	if err != nil {
+		return c.Status(attributeErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return streamExport(c, "products", services.ProductExportColumns, func(w services.ExportWriter) error {
+		return services.ExportProducts(query, w)
+	})
+}
+
+// ExportOrders handles exporting orders.
+// @Summary Export orders
+// @Description Stream the orders matched by the same filters as the order list as CSV, XLSX or NDJSON, one row per order line
+// @Tags Orders
+// @Produce text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (default), xlsx or ndjson"
+// @Param product_id query int false "Product ID"
+// @Param warehouse_id query int false "Warehouse ID"
+// @Param customer_id query int false "Customer ID"
+// @Param status query string false "Status (pending, paid, partially_refunded, refunded)"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD), inclusive"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Router /orders/export [get]
+// @Security BearerAuth
+func ExportOrders(c *fiber.Ctx) error {
+	query, err := filterOrders(c, database.DB)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return streamExport(c, "orders", services.OrderExportColumns, func(w services.ExportWriter) error {
+		return services.ExportOrders(query, w)
+	})
+}
+
+// ExportSuppliers handles exporting suppliers.
+// @Summary Export suppliers
+// @Description Stream the suppliers matched by the same filters as the supplier list as CSV, XLSX or NDJSON
+// @Tags Supplier
+// @Produce text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (default), xlsx or ndjson"
+// @Param q query string false "Part of the name or email"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Router /suppliers/export [get]
+// @Security BearerAuth
+func ExportSuppliers(c *fiber.Ctx) error {
+	query := filterSuppliers(c, database.DB)
+	return streamExport(c, "suppliers", services.SupplierExportColumns, func(w services.ExportWriter) error {
+		return services.ExportSuppliers(query, w)
+	})
+}
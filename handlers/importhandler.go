@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/jobs"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func importJobResponse(job models.ImportJob) models.ImportJobResponse {
+	rowErrors := job.RowErrors
+	if rowErrors == nil {
+		rowErrors = []models.ImportRowError{}
+	}
+	return models.ImportJobResponse{
+		ID:         job.ID,
+		Type:       job.Type,
+		FileName:   job.FileName,
+		Status:     job.Status,
+		TotalRows:  job.TotalRows,
+		Created:    job.Created,
+		Updated:    job.Updated,
+		Failed:     job.Failed,
+		Errors:     rowErrors,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
+
+// ImportProducts handles a bulk product import.
+// @Summary Import products
+// @Description Import products from a CSV or XLSX file with the columns sku, name, description, price, category, supplier, reorder_point, reorder_quantity, meta_title and meta_description. Category and supplier take a name or an ID. Rows whose SKU exists update that product, other rows create one. With dry_run the file is only validated and the row errors are returned right away; otherwise the import runs in the background and its progress is available from the returned job.
+// @Tags Products
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "CSV or XLSX file"
+// @Param dry_run query bool false "Only validate the file"
+// @Success 200 {object} models.ImportJobResponse
+// @Success 202 {object} models.ImportJobResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/import [post]
+// @Security BearerAuth
+func ImportProducts(c *fiber.Ctx) error {
+	db := database.DB
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A CSV or XLSX file is required in the file field",
+		})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	rows, err := services.ReadImportFile(fileHeader.Filename, data)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, services.ErrImportFile) {
+			status = fiber.StatusBadRequest
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if c.QueryBool("dry_run") {
+		valid, rowErrors, err := services.ParseProductImport(db, rows)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		response := importJobResponse(models.ImportJob{
+			Type:      "products",
+			FileName:  fileHeader.Filename,
+			Status:    models.ImportStatusCompleted,
+			TotalRows: len(valid) + len(rowErrors),
+			Failed:    len(rowErrors),
+			RowErrors: rowErrors,
+		})
+		response.DryRun = true
+		for _, row := range valid {
+			if row.ProductID != 0 {
+				response.Updated++
+			} else {
+				response.Created++
+			}
+		}
+		return c.JSON(response)
+	}
+
+	job := models.ImportJob{
+		Type:     "products",
+		FileName: fileHeader.Filename,
+		Status:   models.ImportStatusQueued,
+		UserID:   currentUserID(c),
+	}
+	if err := db.Create(&job).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	jobs.StartProductImport(db, job, rows)
+
+	return c.Status(fiber.StatusAccepted).JSON(importJobResponse(job))
+}
+
+// GetImportJob handles retrieving the status of an import.
+// @Summary Get import job
+// @Description Retrieve the status, counts and row errors of a background import
+// @Tags Products
+// @Produce json
+// @Param id path int true "Import job ID"
+// @Success 200 {object} models.ImportJobResponse
+// @Failure 404 {object} map[string]interface{}
+// @Router /imports/{id} [get]
+// @Security BearerAuth
+func GetImportJob(c *fiber.Ctx) error {
+	var job models.ImportJob
+	if err := database.DB.First(&job, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Import job not found",
+		})
+	}
+
+	return c.JSON(importJobResponse(job))
+}
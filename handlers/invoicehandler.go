@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func invoiceResponse(invoice models.Invoice) models.InvoiceResponse {
+	taxes := make([]models.InvoiceTaxResponse, 0, len(invoice.Taxes))
+	for _, tax := range invoice.Taxes {
+		taxes = append(taxes, models.InvoiceTaxResponse{
+			Name:          tax.Name,
+			Region:        tax.Region,
+			Rate:          tax.Rate,
+			TaxableAmount: tax.TaxableAmount,
+			Amount:        tax.Amount,
+		})
+	}
+	items := make([]models.InvoiceItemResponse, 0, len(invoice.Items))
+	for _, item := range invoice.Items {
+		items = append(items, models.InvoiceItemResponse{
+			Description: item.Description,
+			SKU:         item.SKU,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Subtotal:    item.Subtotal,
+		})
+	}
+	response := models.InvoiceResponse{
+		ID:               invoice.ID,
+		OrderID:          invoice.OrderID,
+		Number:           invoice.Number,
+		FiscalYear:       invoice.FiscalYear,
+		IssuedAt:         invoice.IssuedAt,
+		CustomerName:     invoice.CustomerName,
+		Items:            items,
+		Subtotal:         invoice.Subtotal,
+		DiscountTotal:    invoice.DiscountTotal,
+		ShippingTotal:    invoice.ShippingTotal,
+		TaxTotal:         invoice.TaxTotal,
+		Total:            invoice.Total,
+		Currency:         invoice.Currency,
+		PricesIncludeTax: invoice.PricesIncludeTax,
+		Taxes:            taxes,
+	}
+	if !invoice.BillingAddress.IsZero() {
+		response.BillingAddress = &invoice.BillingAddress
+	}
+	return response
+}
+
+// findOrderInvoice loads the invoice of the order of the id parameter among
+// orders.
+func findOrderInvoice(c *fiber.Ctx, orders *gorm.DB) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := database.DB.Preload("Items").Preload("Taxes").
+		Where("order_id IN (?)", orders.Model(&models.Order{}).Select("id").Where("id = ?", c.Params("id"))).
+		First(&invoice).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// orderInvoice loads the invoice of the order of the id parameter, limited
+// to the orders of the logged in customer, answering the request when it
+// cannot.
+func orderInvoice(c *fiber.Ctx) (*models.Invoice, error) {
+	db, err := customerOrders(c, database.DB)
+	if err != nil {
+		return nil, customerProfileError(c, err)
+	}
+	invoice, err := findOrderInvoice(c, db)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Invoice not found",
+		})
+	}
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return invoice, nil
+}
+
+// IssueOrderInvoice handles invoicing an order.
+// @Summary Issue order invoice
+// @Description Issue the invoice of an order with the next number of the current fiscal year (INVOICE_PREFIX-year-sequence, without gaps; the fiscal year starts in FISCAL_YEAR_START_MONTH). Orders are invoiced automatically when they become paid. An invoice never changes once issued, and the order can then no longer be updated or deleted; issuing again returns the same invoice with 200.
+// @Tags Invoices
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 201 {object} models.InvoiceResponse
+// @Success 200 {object} models.InvoiceResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /orders/{id}/invoice [post]
+// @Security BearerAuth
+func IssueOrderInvoice(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Order not found",
+		})
+	}
+
+	var invoice *models.Invoice
+	var issued bool
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		invoice, issued, err = services.IssueInvoice(tx, uint(id))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Order not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if issued {
+		return c.Status(fiber.StatusCreated).JSON(invoiceResponse(*invoice))
+	}
+	return c.JSON(invoiceResponse(*invoice))
+}
+
+// GetOrderInvoice handles retrieving the invoice of an order.
+// @Summary Get order invoice
+// @Description Retrieve the invoice issued for an order. Customers may only see the invoices of their own orders.
+// @Tags Invoices
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} models.InvoiceResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /orders/{id}/invoice [get]
+// @Security BearerAuth
+func GetOrderInvoice(c *fiber.Ctx) error {
+	invoice, err := orderInvoice(c)
+	if invoice == nil {
+		return err
+	}
+
+	return c.JSON(invoiceResponse(*invoice))
+}
+
+// GetOrderInvoicePDF handles downloading the invoice of an order.
+// @Summary Download order invoice PDF
+// @Description Download the invoice issued for an order as a PDF with the company header (COMPANY_NAME, COMPANY_ADDRESS with lines separated by semicolons, COMPANY_TAX_ID, COMPANY_EMAIL), the items, the tax breakdown and the totals. Customers may only download the invoices of their own orders.
+// @Tags Invoices
+// @Produce application/pdf
+// @Param id path int true "Order ID"
+// @Success 200 {file} file
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /orders/{id}/invoice.pdf [get]
+// @Security BearerAuth
+func GetOrderInvoicePDF(c *fiber.Ctx) error {
+	invoice, err := orderInvoice(c)
+	if invoice == nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := services.RenderInvoicePDF(&buf, *invoice, services.InvoiceTemplateFromEnv()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.pdf"`, invoice.Number))
+	return c.Send(buf.Bytes())
+}
+
+// GetAllInvoices handles listing invoices.
+// @Summary List invoices
+// @Description Retrieve invoices in numbering order, optionally of one fiscal year
+// @Tags Invoices
+// @Produce json
+// @Param fiscal_year query int false "Fiscal year"
+// @Success 200 {array} models.InvoiceResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /invoices [get]
+// @Security BearerAuth
+func GetAllInvoices(c *fiber.Ctx) error {
+	query := database.DB.Preload("Items").Preload("Taxes").Order("fiscal_year, sequence")
+	if year := c.QueryInt("fiscal_year"); year != 0 {
+		query = query.Where("fiscal_year = ?", year)
+	}
+
+	var invoices []models.Invoice
+	if err := query.Find(&invoices).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.InvoiceResponse, 0, len(invoices))
+	for _, invoice := range invoices {
+		response = append(response, invoiceResponse(invoice))
+	}
+	return c.JSON(response)
+}
@@ -1,21 +1,133 @@
 package handlers
 
 import (
+	"errors"
+	"time"
+
 	"github.com/DewiKresnawati/DewiWebService/database"
 	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+func orderResponse(order models.Order) models.OrderResponse {
+	taxes := make([]models.OrderTaxResponse, 0, len(order.Taxes))
+	for _, tax := range order.Taxes {
+		taxes = append(taxes, models.OrderTaxResponse{
+			Name:          tax.Name,
+			Region:        tax.Region,
+			Rate:          tax.Rate,
+			TaxableAmount: tax.TaxableAmount,
+			Amount:        tax.Amount,
+		})
+	}
+	discounts := make([]models.OrderDiscountResponse, 0, len(order.Discounts))
+	for _, discount := range order.Discounts {
+		discounts = append(discounts, models.OrderDiscountResponse{
+			PromotionID: discount.PromotionID,
+			Code:        discount.Code,
+			Name:        discount.Name,
+			Type:        discount.Type,
+			Amount:      discount.Amount,
+		})
+	}
+	items := make([]models.OrderItemResponse, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, models.OrderItemResponse{
+			ID:            item.ID,
+			ProductID:     item.ProductID,
+			VariantID:     item.VariantID,
+			Quantity:      item.Quantity,
+			UnitPrice:     item.UnitPrice,
+			DiscountTotal: item.DiscountTotal,
+			Subtotal:      item.Subtotal,
+			TaxTotal:      item.TaxTotal,
+			WarehouseID:   item.WarehouseID,
+		})
+	}
+	response := models.OrderResponse{
+		ID:               order.ID,
+		Status:           order.Status,
+		Items:            items,
+		Subtotal:         order.Subtotal,
+		TaxTotal:         order.TaxTotal,
+		DiscountTotal:    order.DiscountTotal,
+		ShippingTotal:    order.ShippingTotal,
+		Total:            order.Total,
+		Currency:         order.Currency,
+		ExchangeRate:     order.ExchangeRate,
+		PricesIncludeTax: order.PricesIncludeTax,
+		TaxRegion:        order.TaxRegion,
+		Taxes:            taxes,
+		CouponCode:       order.CouponCode,
+		Discounts:        discounts,
+		UserID:           order.UserID,
+		CustomerID:       order.CustomerID,
+	}
+	if !order.ShippingAddress.IsZero() {
+		response.ShippingAddress = &order.ShippingAddress
+	}
+	if !order.BillingAddress.IsZero() {
+		response.BillingAddress = &order.BillingAddress
+	}
+	return response
+}
+
+// orderErrorStatus maps order errors to HTTP status codes.
+func orderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUnknownCoupon), errors.Is(err, services.ErrCouponNotValid),
+		errors.Is(err, services.ErrCouponNotApplicable), errors.Is(err, services.ErrCouponMinimumSpend),
+		errors.Is(err, services.ErrUnknownCustomer), errors.Is(err, services.ErrUnknownAddress),
+		errors.Is(err, services.ErrInvalidAddress), errors.Is(err, services.ErrInvalidQuantity),
+		errors.Is(err, services.ErrUnknownCurrency), errors.Is(err, services.ErrNoExchangeRate):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrCouponUsedUp), errors.Is(err, services.ErrOrderPaid),
+		errors.Is(err, services.ErrOrderInvoiced):
+		return fiber.StatusConflict
+	default:
+		return stockErrorStatus(err)
+	}
+}
+
+// customerOrders limits query to the orders of the logged in customer; staff
+// see every order.
+func customerOrders(c *fiber.Ctx, query *gorm.DB) (*gorm.DB, error) {
+	customer, err := currentCustomer(c)
+	if err != nil {
+		return nil, err
+	}
+	if customer != nil {
+		query = query.Where("customer_id = ?", customer.ID)
+	}
+	return query, nil
+}
+
+// customerProfileError answers a request of a customer user whose profile
+// could not be loaded.
+func customerProfileError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Customer profile not found",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
 // CreateOrder handles creating a new order.
 // @Summary Create a new order
-// @Description Create a new order
+// @Description Create a new order of one or more lines for the logged in customer or, for staff, for the customer given in the request. The shipping and billing addresses are copied from the customer's address book. Each line takes its stock from a warehouse chosen by the fulfilment strategy. The lines are priced at the current price of their product or variant, less the automatic promotions and coupon that apply, with the taxes of their tax class in the tax region; the shipping fee is charged once per order.
 // @Tags Orders
 // @Accept json
 // @Produce json
 // @Param   order body models.OrderRequest true "Order data"
 // @Success 201 {object} models.OrderResponse
 // @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Router /orders [post]
 // @Security BearerAuth
 func CreateOrder(c *fiber.Ctx) error {
@@ -27,77 +139,175 @@ func CreateOrder(c *fiber.Ctx) error {
 		})
 	}
 
-	order := models.Order{
-		ProductID: req.ProductID,
-		Quantity:  req.Quantity,
-		Total:     req.Total,
+	customer, err := currentCustomer(c)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+	if customer != nil {
+		req.CustomerID = &customer.ID
 	}
 
-	if err := db.Create(&order).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	var order models.Order
+	err = db.Transaction(func(tx *gorm.DB) error {
+		order, err = placeOrder(c, tx, req)
+		return err
+	})
+	if err != nil {
+		return c.Status(orderErrorStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	orderResponse := models.OrderResponse{
-		ID:        order.ID,
-		ProductID: order.ProductID,
-		Quantity:  order.Quantity,
-		Total:     order.Total,
-	}
+	return c.Status(fiber.StatusCreated).JSON(orderResponse(order))
+}
 
-	return c.Status(fiber.StatusCreated).JSON(orderResponse)
+// placeOrder creates an order for the request: it prices the order, copies
+// its addresses and takes its stock. The customer of the request must
+// already be checked against the logged in user.
+func placeOrder(c *fiber.Ctx, tx *gorm.DB, req models.OrderRequest) (models.Order, error) {
+	items, err := orderItems(tx, req)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if err := services.CheckCustomer(tx, req.CustomerID); err != nil {
+		return models.Order{}, err
+	}
+	order := models.Order{
+		Items:      items,
+		TaxRegion:  req.TaxRegion,
+		CouponCode: req.CouponCode,
+		Currency:   req.Currency,
+		UserID:     currentUserID(c),
+		CustomerID: req.CustomerID,
+	}
+	if err := services.SnapshotOrderAddresses(tx, &order, req.ShippingAddressID, req.BillingAddressID); err != nil {
+		return order, err
+	}
+	if err := services.PriceOrder(tx, &order); err != nil {
+		return order, err
+	}
+	if err := tx.Create(&order).Error; err != nil {
+		return order, err
+	}
+	if err := services.FulfilOrder(tx, &order, fulfilmentRequest(c, req)); err != nil {
+		return order, err
+	}
+	return order, tx.Omit(clause.Associations).Save(&order).Error
 }
 
 // GetAllOrders handles retrieving all orders.
 // @Summary Get all orders
-// @Description Retrieve all orders
+// @Description Retrieve all orders, optionally filtered by product, warehouse, customer and period. Customers only see their own orders.
 // @Tags Orders
 // @Accept json
 // @Produce json
+// @Param product_id query int false "Product ID"
+// @Param warehouse_id query int false "Warehouse ID"
+// @Param customer_id query int false "Customer ID"
+// @Param status query string false "Status (pending, paid, partially_refunded, refunded)"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD), inclusive"
+// @Param currency query string false "Currency of the totals, converted at the rate of the order date"
 // @Success 200 {array} models.OrderResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /orders [get]
 // @Security BearerAuth
 func GetAllOrders(c *fiber.Ctx) error {
 	db := database.DB
+	query, err := customerOrders(c, db)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+	if query, err = filterOrders(c, query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	var orders []models.Order
-	if err := db.Find(&orders).Error; err != nil {
+	if err := query.Preload("Items").Preload("Taxes").Preload("Discounts").Find(&orders).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
+	if err := ordersInCurrency(c, orders); err != nil {
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
 	orderResponses := make([]models.OrderResponse, 0, len(orders))
 	for _, order := range orders {
-		orderResponse := models.OrderResponse{
-			ID:        order.ID,
-			ProductID: order.ProductID,
-			Quantity:  order.Quantity,
-			Total:     order.Total,
-		}
-		orderResponses = append(orderResponses, orderResponse)
+		orderResponses = append(orderResponses, orderResponse(order))
 	}
 
 	return c.JSON(orderResponses)
 }
 
+// ordersInCurrency converts the totals of orders to the currency asked for
+// with the currency query parameter, the base currency by default.
+func ordersInCurrency(c *fiber.Ctx, orders []models.Order) error {
+	currency, err := services.NormaliseCurrency(c.Query("currency"))
+	if err != nil {
+		return err
+	}
+	return services.ConvertOrderTotals(database.DB, orders, currency)
+}
+
+// filterOrders applies the order list query parameters to query.
+func filterOrders(c *fiber.Ctx, query *gorm.DB) (*gorm.DB, error) {
+	if productID := c.QueryInt("product_id"); productID != 0 {
+		query = query.Where("id IN (?)", database.DB.Model(&models.OrderItem{}).Select("order_id").Where("product_id = ?", productID))
+	}
+	if warehouseID := c.QueryInt("warehouse_id"); warehouseID != 0 {
+		query = query.Where("id IN (?)", database.DB.Model(&models.OrderItem{}).Select("order_id").Where("warehouse_id = ?", warehouseID))
+	}
+	if customerID := c.QueryInt("customer_id"); customerID != 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if from := c.Query("from"); from != "" {
+		date, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return nil, errors.New("invalid from date, expected YYYY-MM-DD")
+		}
+		query = query.Where("created_at >= ?", date)
+	}
+	if to := c.Query("to"); to != "" {
+		date, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return nil, errors.New("invalid to date, expected YYYY-MM-DD")
+		}
+		query = query.Where("created_at < ?", date.AddDate(0, 0, 1))
+	}
+	return query, nil
+}
+
 // GetOrderByID handles retrieving an order by its ID.
 // @Summary Get order by ID
-// @Description Retrieve an order by its ID
+// @Description Retrieve an order by its ID. Customers can only retrieve their own orders.
 // @Tags Orders
 // @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
+// @Param currency query string false "Currency of the total, converted at the rate of the order date"
 // @Success 200 {object} models.OrderResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Router /orders/{id} [get]
 // @Security BearerAuth
 func GetOrderByID(c *fiber.Ctx) error {
-	db := database.DB
+	db, err := customerOrders(c, database.DB)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
 	id := c.Params("id")
 	var order models.Order
-	if err := db.First(&order, id).Error; err != nil {
+	if err := db.Preload("Items").Preload("Taxes").Preload("Discounts").First(&order, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": "Order not found",
@@ -107,20 +317,75 @@ func GetOrderByID(c *fiber.Ctx) error {
 			"error": err.Error(),
 		})
 	}
+	orders := []models.Order{order}
+	if err := ordersInCurrency(c, orders); err != nil {
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	order = orders[0]
+
+	return c.JSON(orderResponse(order))
+}
 
-	orderResponse := models.OrderResponse{
-		ID:        order.ID,
-		ProductID: order.ProductID,
-		Quantity:  order.Quantity,
-		Total:     order.Total,
+// orderItems turns the lines of an order request into order lines, checking
+// their variants and filling in the product of lines given by variant only.
+func orderItems(tx *gorm.DB, req models.OrderRequest) ([]models.OrderItem, error) {
+	lines := req.OrderLines()
+	if len(lines) == 0 {
+		return nil, services.ErrNoItems
 	}
+	items := make([]models.OrderItem, 0, len(lines))
+	for _, line := range lines {
+		if line.Quantity == 0 {
+			return nil, services.ErrInvalidQuantity
+		}
+		item := models.OrderItem{ProductID: line.ProductID, VariantID: line.VariantID, Quantity: line.Quantity}
+		if line.VariantID != nil {
+			variant, err := services.OrderVariant(tx, line.ProductID, *line.VariantID)
+			if err != nil {
+				return nil, err
+			}
+			item.ProductID = variant.ProductID
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func fulfilmentRequest(c *fiber.Ctx, req models.OrderRequest) services.FulfilmentRequest {
+	return services.FulfilmentRequest{
+		Strategy:  req.Strategy,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		UserID:    currentUserID(c),
+	}
+}
 
-	return c.JSON(orderResponse)
+func sameVariant(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sameOrderItems reports whether two sets of order lines order the same
+// quantities of the same products and variants in the same order.
+func sameOrderItems(a, b []models.OrderItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ProductID != b[i].ProductID || !sameVariant(a[i].VariantID, b[i].VariantID) || a[i].Quantity != b[i].Quantity {
+			return false
+		}
+	}
+	return true
 }
 
 // UpdateOrder handles updating an existing order.
 // @Summary Update order
-// @Description Update an existing order
+// @Description Update an existing order. Sending lines replaces the lines of the order; lines left out are kept. Changing the lines, tax region or coupon prices the order again at current prices and promotions; an empty tax region keeps the current one, while the coupon is replaced by the one sent. Sending a shipping or billing address ID copies both addresses from the address book again.
 // @Tags Orders
 // @Accept json
 // @Produce json
@@ -129,6 +394,7 @@ func GetOrderByID(c *fiber.Ctx) error {
 // @Success 200 {object} models.OrderResponse
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Router /orders/{id} [put]
 // @Security BearerAuth
 func UpdateOrder(c *fiber.Ctx) error {
@@ -142,7 +408,7 @@ func UpdateOrder(c *fiber.Ctx) error {
 	}
 
 	var order models.Order
-	if err := db.First(&order, id).Error; err != nil {
+	if err := db.Preload("Items").Preload("Taxes").Preload("Discounts").First(&order, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": "Order not found",
@@ -153,46 +419,135 @@ func UpdateOrder(c *fiber.Ctx) error {
 		})
 	}
 
-	order.ProductID = req.ProductID
-	order.Quantity = req.Quantity
-	order.Total = req.Total
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Dibaca ulang dengan kunci agar pembayaran atau faktur yang terbit bersamaan tidak terlewat
+		order = models.Order{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").Preload("Taxes").Preload("Discounts").First(&order, id).Error; err != nil {
+			return err
+		}
+		// Pesanan yang sudah dibayar tidak boleh dihitung ulang
+		if order.Status != models.OrderStatusPending {
+			return services.ErrOrderPaid
+		}
+		// Faktur yang sudah terbit tidak boleh berubah
+		invoiced, err := services.OrderInvoiced(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if invoiced {
+			return services.ErrOrderInvoiced
+		}
 
-	if err := db.Save(&order).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		itemsChanged := false
+		if len(req.OrderLines()) > 0 {
+			items, err := orderItems(tx, req)
+			if err != nil {
+				return err
+			}
+			itemsChanged = !sameOrderItems(order.Items, items)
+			// Barang dari baris lama dikembalikan ke gudangnya lalu baris baru dialokasikan ulang.
+			if itemsChanged {
+				if err := services.ReleaseOrderStock(tx, &order, models.MovementTypeSale, "order updated", currentUserID(c)); err != nil {
+					return err
+				}
+				if err := tx.Unscoped().Where("order_id = ?", order.ID).Delete(&models.OrderItem{}).Error; err != nil {
+					return err
+				}
+				order.Items = items
+			}
+		}
+		lineChanged := itemsChanged
+
+		// Harga, diskon dan pajak dihitung ulang hanya bila baris pesanan, wilayah pajak atau kuponnya berubah
+		if req.TaxRegion != "" && services.NormaliseTaxRegion(req.TaxRegion) != order.TaxRegion {
+			order.TaxRegion = req.TaxRegion
+			lineChanged = true
+		}
+		if services.NormaliseCouponCode(req.CouponCode) != order.CouponCode {
+			order.CouponCode = req.CouponCode
+			lineChanged = true
+		}
+		if req.ShippingAddressID != nil || req.BillingAddressID != nil {
+			if err := services.SnapshotOrderAddresses(tx, &order, req.ShippingAddressID, req.BillingAddressID); err != nil {
+				return err
+			}
+		}
+		if lineChanged {
+			if err := services.PriceOrder(tx, &order); err != nil {
+				return err
+			}
+		}
+		if err := tx.Save(&order).Error; err != nil {
+			return err
+		}
+		// Baris baru disimpan dulu sebelum stoknya diambil
+		if itemsChanged {
+			if err := services.FulfilOrder(tx, &order, fulfilmentRequest(c, req)); err != nil {
+				return err
+			}
+			return tx.Omit(clause.Associations).Save(&order).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(orderErrorStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	orderResponse := models.OrderResponse{
-		ID:        order.ID,
-		ProductID: order.ProductID,
-		Quantity:  order.Quantity,
-		Total:     order.Total,
-	}
-
-	return c.JSON(orderResponse)
+	return c.JSON(orderResponse(order))
 }
 
 // DeleteOrder handles deleting an order.
 // @Summary Delete order
-// @Description Delete an order by its ID
+// @Description Delete an unpaid order by its ID. The stock taken by its lines is booked back into their warehouses and its discounts no longer count towards the usage limits of their promotions. Paid and invoiced orders cannot be deleted.
 // @Tags Orders
 // @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
 // @Success 204 {object} nil
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /orders/{id} [delete]
 // @Security BearerAuth
 func DeleteOrder(c *fiber.Ctx) error {
-	db := database.DB
-	id := c.Params("id")
-	if err := db.Delete(&models.Order{}, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&order, c.Params("id")).Error; err != nil {
+			return err
+		}
+		// Pesanan yang sudah dibayar atau difakturkan tidak boleh dihapus
+		if order.Status != models.OrderStatusPending {
+			return services.ErrOrderPaid
+		}
+		invoiced, err := services.OrderInvoiced(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if invoiced {
+			return services.ErrOrderInvoiced
+		}
+		// Stok dikembalikan dan pemakaian kupon dilepas dalam transaksi yang sama
+		if err := services.ReleaseOrderStock(tx, &order, models.MovementTypeSale, "order deleted", currentUserID(c)); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("order_id = ?", order.ID).Delete(&models.OrderDiscount{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&order).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": "Order not found",
 			})
 		}
+		if errors.Is(err, services.ErrOrderPaid) || errors.Is(err, services.ErrOrderInvoiced) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
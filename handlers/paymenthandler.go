@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/payment"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func paymentResponse(p models.Payment) models.PaymentResponse {
+	response := models.PaymentResponse{
+		ID:             p.ID,
+		OrderID:        p.OrderID,
+		Provider:       p.Provider,
+		Reference:      p.Reference,
+		Status:         p.Status,
+		Amount:         p.Amount,
+		Currency:       p.Currency,
+		CapturedAmount: p.CapturedAmount,
+		RefundedAmount: p.RefundedAmount,
+		FailureReason:  p.FailureReason,
+	}
+	if p.Order != nil {
+		response.OrderStatus = p.Order.Status
+	}
+	return response
+}
+
+// paymentErrorStatus maps payment errors to HTTP status codes.
+func paymentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidPaymentAmount), errors.Is(err, payment.ErrInvalidAmount):
+		return fiber.StatusBadRequest
+	case errors.Is(err, payment.ErrInvalidSignature):
+		return fiber.StatusUnauthorized
+	case errors.Is(err, payment.ErrDeclined):
+		return fiber.StatusPaymentRequired
+	case errors.Is(err, services.ErrOrderPaid), errors.Is(err, services.ErrPaymentProvider):
+		return fiber.StatusConflict
+	case errors.Is(err, payment.ErrUnknownPayment):
+		return fiber.StatusBadGateway
+	default:
+		return stockErrorStatus(err)
+	}
+}
+
+// CreateOrderPayment handles paying an order.
+// @Summary Pay order
+// @Description Pay what is left to pay of an order through the payment provider, capturing it at once unless capture is false. The order becomes paid once its total is captured. Customers may only pay their own orders. A declined payment is recorded as failed and answered with 402; a payment the provider settles asynchronously stays pending until its webhook arrives.
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param payment body models.PaymentRequest true "Payment"
+// @Success 201 {object} models.PaymentResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 402 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 502 {object} map[string]interface{}
+// @Router /orders/{id}/payments [post]
+// @Security BearerAuth
+func CreateOrderPayment(c *fiber.Ctx) error {
+	var req models.PaymentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	db, err := customerOrders(c, database.DB)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+	var order models.Order
+	if err := db.First(&order, c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Order not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var p *models.Payment
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		p, err = services.CreatePayment(c.Context(), tx, payment.Default, order.ID, req)
+		return err
+	})
+	if err != nil {
+		return c.Status(paymentErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if p.Status == models.PaymentStatusFailed {
+		return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
+			"error":   payment.ErrDeclined.Error() + ": " + p.FailureReason,
+			"payment": paymentResponse(*p),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(paymentResponse(*p))
+}
+
+// GetOrderPayments handles listing the payments of an order.
+// @Summary List order payments
+// @Description Retrieve the payments of an order, including failed ones. Customers may only see the payments of their own orders.
+// @Tags Payments
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} models.PaymentResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /orders/{id}/payments [get]
+// @Security BearerAuth
+func GetOrderPayments(c *fiber.Ctx) error {
+	db, err := customerOrders(c, database.DB)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+	var order models.Order
+	if err := db.First(&order, c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Order not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var payments []models.Payment
+	if err := database.DB.Where("order_id = ?", order.ID).Order("id").Find(&payments).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	response := make([]models.PaymentResponse, 0, len(payments))
+	for _, p := range payments {
+		p.Order = &order
+		response = append(response, paymentResponse(p))
+	}
+	return c.JSON(response)
+}
+
+// CapturePayment handles charging an authorized payment.
+// @Summary Capture payment
+// @Description Charge an authorized payment, by default for the whole authorized amount
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Param id path int true "Payment ID"
+// @Param amount body models.PaymentAmountRequest false "Amount"
+// @Success 200 {object} models.PaymentResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 402 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 502 {object} map[string]interface{}
+// @Router /payments/{id}/capture [post]
+// @Security BearerAuth
+func CapturePayment(c *fiber.Ctx) error {
+	return changePayment(c, services.CapturePayment)
+}
+
+// RefundPayment handles paying back a captured payment.
+// @Summary Refund payment
+// @Description Pay back part of a captured payment, by default all that has not been refunded yet. The order becomes partially refunded, or refunded once everything captured has been paid back.
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Param id path int true "Payment ID"
+// @Param amount body models.PaymentAmountRequest false "Amount"
+// @Success 200 {object} models.PaymentResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 402 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 502 {object} map[string]interface{}
+// @Router /payments/{id}/refund [post]
+// @Security BearerAuth
+func RefundPayment(c *fiber.Ctx) error {
+	return changePayment(c, services.RefundPayment)
+}
+
+// changePayment captures or refunds the payment of the id parameter.
+func changePayment(c *fiber.Ctx, change func(ctx context.Context, tx *gorm.DB, provider payment.Provider, paymentID uint, amount *models.Money) (*models.Payment, error)) error {
+	var req models.PaymentAmountRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Payment not found",
+		})
+	}
+
+	var p *models.Payment
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		p, err = change(c.Context(), tx, payment.Default, uint(id), req.Amount)
+		return err
+	})
+	if err != nil {
+		return c.Status(paymentErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(paymentResponse(*p))
+}
+
+// PaymentWebhook handles callbacks of the payment provider.
+// @Summary Payment provider webhook
+// @Description Receive a callback of the payment provider about one of its payments, such as the outcome of a payment settled asynchronously. The callback is only accepted with a valid signature (for the mock provider the hex HMAC-SHA256 of the body keyed with PAYMENT_WEBHOOK_SECRET, in the X-Mock-Signature header). Callbacks delivered again are acknowledged without being applied twice.
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} models.PaymentResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /payments/webhooks/{provider} [post]
+func PaymentWebhook(c *fiber.Ctx) error {
+	provider := payment.Default
+	if c.Params("provider") != provider.Name() {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Unknown payment provider",
+		})
+	}
+	event, err := provider.VerifyWebhook(func(key string) string { return c.Get(key) }, c.Body())
+	if err != nil {
+		status := paymentErrorStatus(err)
+		if status == fiber.StatusInternalServerError {
+			status = fiber.StatusBadRequest
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var p *models.Payment
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		p, err = services.HandlePaymentEvent(tx, provider.Name(), event)
+		return err
+	})
+	if err != nil {
+		return c.Status(paymentErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(paymentResponse(*p))
+}
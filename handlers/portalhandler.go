@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// portalSupplierID returns the supplier of the logged in portal user; ok is
+// false when the user is not linked to a supplier.
+func portalSupplierID(c *fiber.Ctx) (uint, bool) {
+	supplierID := currentSupplierID(c)
+	if supplierID == nil {
+		return 0, false
+	}
+	return *supplierID, true
+}
+
+func portalForbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error": "User is not linked to a supplier",
+	})
+}
+
+// GetPortalProducts handles listing the products supplied by the portal user's supplier.
+// @Summary Get own products
+// @Description Retrieve the products linked to the logged in supplier
+// @Tags Supplier Portal
+// @Produce json
+// @Success 200 {array} models.ProductSupplierResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /portal/products [get]
+// @Security BearerAuth
+func GetPortalProducts(c *fiber.Ctx) error {
+	supplierID, ok := portalSupplierID(c)
+	if !ok {
+		return portalForbidden(c)
+	}
+
+	db := database.DB
+	var links []models.ProductSupplier
+	if err := db.Where("supplier_id = ?", supplierID).Order("product_id").Find(&links).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	productIDs := make([]uint, 0, len(links))
+	for _, link := range links {
+		productIDs = append(productIDs, link.ProductID)
+	}
+	var products []models.Product
+	if err := db.Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	names := make(map[uint]string, len(products))
+	for _, product := range products {
+		names[product.ID] = product.Name
+	}
+
+	response := make([]models.ProductSupplierResponse, 0, len(links))
+	for _, link := range links {
+		item := productSupplierResponse(link)
+		item.ProductName = names[link.ProductID]
+		response = append(response, item)
+	}
+
+	return c.JSON(response)
+}
+
+// UpdatePortalProduct handles a supplier updating its own data for a product.
+// @Summary Update own product data
+// @Description Update the supplier SKU of a product linked to the logged in supplier
+// @Tags Supplier Portal
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param product body models.PortalProductRequest true "Supplier product data"
+// @Success 200 {object} models.ProductSupplierResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /portal/products/{id} [put]
+// @Security BearerAuth
+func UpdatePortalProduct(c *fiber.Ctx) error {
+	supplierID, ok := portalSupplierID(c)
+	if !ok {
+		return portalForbidden(c)
+	}
+	var req models.PortalProductRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	db := database.DB
+	var link models.ProductSupplier
+	if err := db.Where("product_id = ? AND supplier_id = ?", c.Params("id"), supplierID).First(&link).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Product not found",
+		})
+	}
+
+	link.SupplierSKU = req.SupplierSKU
+	if err := db.Save(&link).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(productSupplierResponse(link))
+}
+
+// GetPortalPrices handles listing the price list of the portal user's supplier.
+// @Summary Get own prices
+// @Description Retrieve the price list of the logged in supplier
+// @Tags Supplier Portal
+// @Produce json
+// @Success 200 {array} models.SupplierPriceResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /portal/prices [get]
+// @Security BearerAuth
+func GetPortalPrices(c *fiber.Ctx) error {
+	supplierID, ok := portalSupplierID(c)
+	if !ok {
+		return portalForbidden(c)
+	}
+
+	var prices []models.SupplierPrice
+	if err := database.DB.Where("supplier_id = ?", supplierID).Order("product_id, valid_from").Find(&prices).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.SupplierPriceResponse, 0, len(prices))
+	for _, price := range prices {
+		response = append(response, supplierPriceResponse(price))
+	}
+
+	return c.JSON(response)
+}
+
+// UploadPortalPriceList handles a supplier uploading its price list.
+// @Summary Upload price list
+// @Description Upload a CSV price list with the columns product_id or supplier_sku, unit_cost, min_order_quantity, valid_from and valid_to. Either every row is imported or none.
+// @Tags Supplier Portal
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "CSV price list"
+// @Success 201 {object} models.PriceListImportResponse
+// @Failure 400 {object} models.PriceListImportResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /portal/price-lists [post]
+// @Security BearerAuth
+func UploadPortalPriceList(c *fiber.Ctx) error {
+	supplierID, ok := portalSupplierID(c)
+	if !ok {
+		return portalForbidden(c)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A CSV file is required in the file field",
+		})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	defer file.Close()
+
+	db := database.DB
+	var links []models.ProductSupplier
+	if err := db.Where("supplier_id = ?", supplierID).Find(&links).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	byProduct := make(map[uint]bool, len(links))
+	bySKU := make(map[string]uint, len(links))
+	for _, link := range links {
+		byProduct[link.ProductID] = true
+		if link.SupplierSKU != "" {
+			bySKU[link.SupplierSKU] = link.ProductID
+		}
+	}
+
+	prices, rowErrors := parsePriceList(file, supplierID, byProduct, bySKU)
+	if len(rowErrors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.PriceListImportResponse{
+			Errors: rowErrors,
+		})
+	}
+
+	if err := db.Create(&prices).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.PriceListImportResponse{
+		Imported: len(prices),
+	})
+}
+
+// parsePriceList reads a CSV price list, accepting only products linked to
+// the supplier.
+func parsePriceList(r io.Reader, supplierID uint, byProduct map[uint]bool, bySKU map[string]uint) ([]models.SupplierPrice, []string) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, []string{"cannot read header: " + err.Error()}
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	_, hasProduct := columns["product_id"]
+	_, hasSKU := columns["supplier_sku"]
+	if _, ok := columns["unit_cost"]; !ok || (!hasProduct && !hasSKU) {
+		return nil, []string{"header must contain unit_cost and product_id or supplier_sku"}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var prices []models.SupplierPrice
+	var rowErrors []string
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("row %d: %s", line, err.Error()))
+			continue
+		}
+
+		var productID uint
+		if sku := field(record, "supplier_sku"); sku != "" {
+			productID = bySKU[sku]
+		} else if id, err := strconv.ParseUint(field(record, "product_id"), 10, 64); err == nil {
+			productID = uint(id)
+		}
+		if productID == 0 || !byProduct[productID] {
+			rowErrors = append(rowErrors, fmt.Sprintf("row %d: unknown product", line))
+			continue
+		}
+
+		unitCost, err := models.ParseMoney(field(record, "unit_cost"))
+		if err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("row %d: invalid unit_cost", line))
+			continue
+		}
+		var moq uint64
+		if s := field(record, "min_order_quantity"); s != "" {
+			if moq, err = strconv.ParseUint(s, 10, 64); err != nil {
+				rowErrors = append(rowErrors, fmt.Sprintf("row %d: invalid min_order_quantity", line))
+				continue
+			}
+		}
+
+		price := models.SupplierPrice{SupplierID: supplierID}
+		msg := applySupplierPriceRequest(&price, models.SupplierPriceRequest{
+			ProductID:        productID,
+			UnitCost:         unitCost,
+			MinOrderQuantity: uint(moq),
+			ValidFrom:        field(record, "valid_from"),
+			ValidTo:          field(record, "valid_to"),
+		})
+		if msg != "" {
+			rowErrors = append(rowErrors, fmt.Sprintf("row %d: %s", line, msg))
+			continue
+		}
+		prices = append(prices, price)
+	}
+	if len(prices) == 0 && len(rowErrors) == 0 {
+		rowErrors = append(rowErrors, "price list contains no rows")
+	}
+	return prices, rowErrors
+}
+
+// GetPortalPurchaseOrders handles listing the purchase orders sent to the portal user's supplier.
+// @Summary Get own purchase orders
+// @Description Retrieve the purchase orders sent to the logged in supplier
+// @Tags Supplier Portal
+// @Produce json
+// @Param status query string false "Status"
+// @Success 200 {array} models.PurchaseOrderResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /portal/purchase-orders [get]
+// @Security BearerAuth
+func GetPortalPurchaseOrders(c *fiber.Ctx) error {
+	supplierID, ok := portalSupplierID(c)
+	if !ok {
+		return portalForbidden(c)
+	}
+
+	// Draft belum dikirim sehingga tidak terlihat oleh supplier
+	query := database.DB.Preload("Items").
+		Where("supplier_id = ? AND status <> ?", supplierID, models.PurchaseOrderStatusDraft).
+		Order("id DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var pos []models.PurchaseOrder
+	if err := query.Find(&pos).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.PurchaseOrderResponse, 0, len(pos))
+	for _, po := range pos {
+		response = append(response, purchaseOrderResponse(po))
+	}
+
+	return c.JSON(response)
+}
+
+// GetPortalPurchaseOrderByID handles retrieving one of the portal user's purchase orders.
+// @Summary Get own purchase order
+// @Description Retrieve a purchase order sent to the logged in supplier
+// @Tags Supplier Portal
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} models.PurchaseOrderResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /portal/purchase-orders/{id} [get]
+// @Security BearerAuth
+func GetPortalPurchaseOrderByID(c *fiber.Ctx) error {
+	supplierID, ok := portalSupplierID(c)
+	if !ok {
+		return portalForbidden(c)
+	}
+
+	var po models.PurchaseOrder
+	err := database.DB.Preload("Items").
+		Where("supplier_id = ? AND status <> ?", supplierID, models.PurchaseOrderStatusDraft).
+		First(&po, c.Params("id")).Error
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Purchase order not found",
+		})
+	}
+
+	return c.JSON(purchaseOrderResponse(po))
+}
+
+// AcknowledgePortalPurchaseOrder handles a supplier confirming a purchase order.
+// @Summary Acknowledge purchase order
+// @Description Confirm receipt of a purchase order sent to the logged in supplier
+// @Tags Supplier Portal
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} models.PurchaseOrderResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /portal/purchase-orders/{id}/acknowledge [post]
+// @Security BearerAuth
+func AcknowledgePortalPurchaseOrder(c *fiber.Ctx) error {
+	supplierID, ok := portalSupplierID(c)
+	if !ok {
+		return portalForbidden(c)
+	}
+
+	var po models.PurchaseOrder
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").
+			Where("supplier_id = ? AND status <> ?", supplierID, models.PurchaseOrderStatusDraft).
+			First(&po, c.Params("id")).Error
+		if err != nil {
+			return err
+		}
+		return services.AcknowledgePurchaseOrder(tx, &po, currentUserID(c))
+	})
+	if err != nil {
+		return c.Status(stockErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(purchaseOrderResponse(po))
+}
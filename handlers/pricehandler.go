@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func priceChangeResponse(change models.PriceChange) models.PriceChangeResponse {
+	return models.PriceChangeResponse{
+		ID:          change.ID,
+		ProductID:   change.ProductID,
+		OldPrice:    change.OldPrice,
+		NewPrice:    change.NewPrice,
+		Status:      change.Status,
+		Note:        change.Note,
+		EffectiveAt: change.EffectiveAt,
+		AppliedAt:   change.AppliedAt,
+		UserID:      change.UserID,
+		CreatedAt:   change.CreatedAt,
+	}
+}
+
+// priceChangeErrorStatus maps price change errors to HTTP status codes.
+func priceChangeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrInvalidPrice):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrPriceChangeNotScheduled):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// GetProductPriceHistory handles retrieving the price changes of a product.
+// @Summary Get product price history
+// @Description Retrieve the price changes of a product, newest first, with the old and new price, the author and when they took effect. Scheduled, cancelled and failed changes are included unless a status is given.
+// @Tags Products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param status query string false "scheduled, applied, cancelled or failed"
+// @Success 200 {array} models.PriceChangeResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/price-history [get]
+// @Security BearerAuth
+func GetProductPriceHistory(c *fiber.Ctx) error {
+	db := database.DB
+	var product models.Product
+	if err := db.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+
+	query := db.Where("product_id = ?", product.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var changes []models.PriceChange
+	if err := query.Order("effective_at DESC, id DESC").Find(&changes).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.PriceChangeResponse, 0, len(changes))
+	for _, change := range changes {
+		response = append(response, priceChangeResponse(change))
+	}
+	return c.JSON(response)
+}
+
+// ScheduleProductPrice handles changing the price of a product now or later.
+// @Summary Schedule a price change
+// @Description Change the price of a product. Without effective_at, or with a time in the past, the price is changed right away; otherwise the scheduler applies it once the time has come.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param change body models.PriceChangeRequest true "Price change"
+// @Success 201 {object} models.PriceChangeResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/price-changes [post]
+// @Security BearerAuth
+func ScheduleProductPrice(c *fiber.Ctx) error {
+	var req models.PriceChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	db := database.DB
+	var product models.Product
+	if err := db.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+
+	var change *models.PriceChange
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		change, err = services.SchedulePriceChange(tx, product.ID, req, currentUserID(c))
+		return err
+	})
+	if err != nil {
+		return c.Status(priceChangeErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(priceChangeResponse(*change))
+}
+
+// CancelProductPriceChange handles cancelling a scheduled price change.
+// @Summary Cancel a scheduled price change
+// @Description Cancel a price change that has not taken effect yet; it stays in the history as cancelled
+// @Tags Products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param changeId path int true "Price change ID"
+// @Success 200 {object} models.PriceChangeResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/price-changes/{changeId} [delete]
+// @Security BearerAuth
+func CancelProductPriceChange(c *fiber.Ctx) error {
+	var change models.PriceChange
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ?", c.Params("id")).
+			First(&change, c.Params("changeId")).Error
+		if err != nil {
+			return err
+		}
+		return services.CancelPriceChange(tx, &change)
+	})
+	if err != nil {
+		return c.Status(priceChangeErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(priceChangeResponse(change))
+}
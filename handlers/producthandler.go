@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/DewiKresnawati/DewiWebService/database"
 	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -18,6 +21,7 @@ import (
 // @Success 201 {object} models.ProductResponse
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /products [post]
 // @Security BearerAuth
@@ -32,35 +36,195 @@ func CreateProduct(c *fiber.Ctx) error {
 		})
 	}
 
+	// SKUs are optional but unique
+	req.SKU = services.NormaliseSKU(req.SKU)
+	if skuTaken(req.SKU, 0) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": "SKU is already in use",
+		})
+	}
+
 	// Create a new Product instance
 	product := models.Product{
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		CategoryID:  req.CategoryID,
-		SupplierID:  req.SupplierID,
+		Name:            req.Name,
+		SKU:             req.SKU,
+		Description:     req.Description,
+		Price:           req.Price.Round(),
+		CategoryID:      req.CategoryID,
+		SupplierID:      req.SupplierID,
+		TaxClassID:      req.TaxClassID,
+		ReorderPoint:    req.ReorderPoint,
+		ReorderQuantity: req.ReorderQuantity,
+		MetaTitle:       req.MetaTitle,
+		MetaDescription: req.MetaDescription,
 	}
 
 	// Get the database connection
 	db := database.DB
 
-	// Create the product in the database
-	if err := db.Create(&product).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	// Create the product in the database, linking its supplier as preferred
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := services.CheckTaxClass(tx, product.TaxClassID); err != nil {
+			return err
+		}
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		if _, err := services.LinkSupplier(tx, product.ID, models.ProductSupplierRequest{
+			SupplierID: product.SupplierID,
+			Preferred:  true,
+		}); err != nil {
+			return err
+		}
+		if err := services.SetProductAttributes(tx, &product, req.Attributes); err != nil {
+			return err
+		}
+		if err := services.RecordPriceChange(tx, &product, nil, currentUserID(c), ""); err != nil {
+			return err
+		}
+		return assignProductSlug(tx, &product, req)
+	})
+	if err != nil {
+		return c.Status(productErrorStatus(err)).JSON(fiber.Map{
 			"message": "Failed to create product",
 			"error":   err.Error(),
 		})
 	}
 
 	// Return the created product as response
+	product.Currency = services.BaseCurrency()
 	return c.Status(fiber.StatusCreated).JSON(product)
 }
 
+// productErrorStatus maps errors of saving a product to HTTP status codes.
+func productErrorStatus(err error) int {
+	if errors.Is(err, services.ErrUnknownTaxClass) {
+		return fiber.StatusBadRequest
+	}
+	return attributeErrorStatus(err)
+}
+
+// skuTaken reports whether another product already uses the SKU.
+func skuTaken(sku *string, productID uint) bool {
+	if sku == nil {
+		return false
+	}
+	var count int64
+	database.DB.Unscoped().Model(&models.Product{}).Where("sku = ? AND id <> ?", *sku, productID).Count(&count)
+	return count > 0
+}
+
+// catalogProduct leaves out of a product what only staff may see.
+func catalogProduct(product models.Product) models.CatalogProductResponse {
+	return models.CatalogProductResponse{
+		ID:              product.ID,
+		Name:            product.Name,
+		SKU:             product.SKU,
+		Description:     product.Description,
+		Price:           product.Price,
+		Currency:        product.Currency,
+		CategoryID:      product.CategoryID,
+		Slug:            product.Slug,
+		MetaTitle:       product.MetaTitle,
+		MetaDescription: product.MetaDescription,
+	}
+}
+
+// productsJSON answers with the products in full for staff and as catalog
+// entries for customers and anonymous shoppers.
+func productsJSON(c *fiber.Ctx, products []models.Product) error {
+	if isStaff(c) {
+		return c.JSON(products)
+	}
+	response := make([]models.CatalogProductResponse, 0, len(products))
+	for _, product := range products {
+		response = append(response, catalogProduct(product))
+	}
+	return c.JSON(response)
+}
+
+// productJSON answers with one product like productsJSON.
+func productJSON(c *fiber.Ctx, product models.Product) error {
+	if isStaff(c) {
+		return c.JSON(product)
+	}
+	return c.JSON(catalogProduct(product))
+}
+
+// redirectToSlug answers a request for an old slug with a permanent redirect
+// to the current one, keeping the query string such as ?currency=.
+func redirectToSlug(c *fiber.Ctx, location string) error {
+	if query := c.Context().QueryArgs().QueryString(); len(query) > 0 {
+		location += "?" + string(query)
+	}
+	return c.Redirect(location, fiber.StatusMovedPermanently)
+}
+
+// productsInCurrency converts the prices of products to the currency asked
+// for with the currency query parameter, the base currency by default.
+func productsInCurrency(c *fiber.Ctx, products []models.Product) error {
+	currency, err := services.NormaliseCurrency(c.Query("currency"))
+	if err != nil {
+		return err
+	}
+	return services.ConvertProductPrices(database.DB, products, currency)
+}
+
+// assignProductSlug gives the product the requested slug, or one derived
+// from its name.
+func assignProductSlug(tx *gorm.DB, product *models.Product, req models.ProductRequest) error {
+	desired := req.Slug
+	if desired == "" {
+		desired = product.Name
+	}
+	slug, err := services.AssignSlug(tx, models.SlugTypeProduct, product.ID, desired)
+	if err != nil {
+		return err
+	}
+	product.Slug = slug
+	return tx.Model(product).UpdateColumn("slug", slug).Error
+}
+
+// filterProducts applies the product list query parameters to query.
+func filterProducts(c *fiber.Ctx, query *gorm.DB) (*gorm.DB, error) {
+	if categoryID := c.QueryInt("category_id"); categoryID != 0 {
+		if c.QueryBool("include_descendants") {
+			ids, err := services.CategoryWithDescendants(database.DB, uint(categoryID))
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where("category_id IN ?", ids)
+		} else {
+			query = query.Where("category_id = ?", categoryID)
+		}
+	}
+	// attr.<code>=a,b matches any of the values; attr.<code>.min and .max give a range
+	for key, value := range c.Queries() {
+		code, ok := strings.CutPrefix(key, "attr.")
+		if !ok || value == "" {
+			continue
+		}
+		operator := ""
+		if i := strings.LastIndex(code, "."); i >= 0 {
+			code, operator = code[:i], code[i+1:]
+			if operator != "min" && operator != "max" {
+				return nil, fmt.Errorf("%w: unknown filter %s", services.ErrInvalidAttributeValue, key)
+			}
+		}
+		query = services.FilterByAttribute(query, code, operator, value)
+	}
+	return query, nil
+}
+
 // @Summary Get all products
-// @Description Get all products
+// @Description Get all products, optionally only those of a category and its sub-categories. Custom attributes filter with attr.<code>=value (comma separated for any of several values) and attr.<code>.min / attr.<code>.max for number and date ranges. Customers and anonymous shoppers get catalog entries without supplier, costs and reorder settings.
 // @Tags Products
 // @Produce json
+// @Param category_id query int false "Category ID"
+// @Param include_descendants query bool false "Also include products of sub-categories"
+// @Param currency query string false "Currency of the prices, the base currency by default"
 // @Success 200 {array} models.ProductResponse
+// @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /products [get]
@@ -69,25 +233,42 @@ func GetAllProducts(c *fiber.Ctx) error {
 	// Get the database connection
 	db := database.DB
 
+	// Apply the filters from the query string
+	query, err := filterProducts(c, db)
+	if err != nil {
+		return c.Status(attributeErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Query all products from the database
 	var products []models.Product
-	if err := db.Find(&products).Error; err != nil {
+	if err := query.Find(&products).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   err.Error(),
+			"error": err.Error(),
+		})
+	}
+
+	// Convert the prices to the requested currency
+	if err := productsInCurrency(c, products); err != nil {
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
 
 	// Return the products as response
-	return c.JSON(products)
+	return productsJSON(c, products)
 }
 
 // @Summary Get product by ID
-// @Description Get product by ID
+// @Description Get product by ID. Customers and anonymous shoppers get a catalog entry without supplier, costs and reorder settings.
 // @Tags Products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param currency query string false "Currency of the price, the base currency by default"
 // @Success 200 {object} models.ProductResponse
+// @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
@@ -114,12 +295,20 @@ func GetProductByID(c *fiber.Ctx) error {
 		})
 	}
 
+	// Convert the price to the requested currency
+	products := []models.Product{product}
+	if err := productsInCurrency(c, products); err != nil {
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Return the product as response
-	return c.JSON(product)
+	return productJSON(c, products[0])
 }
 
 // @Summary Update product by ID
-// @Description Update product by ID
+// @Description Update product by ID. A new price is recorded in the price history; use the price changes endpoint to plan one ahead.
 // @Tags Products
 // @Accept json
 // @Produce json
@@ -129,6 +318,7 @@ func GetProductByID(c *fiber.Ctx) error {
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /products/{id} [put]
 // @Security BearerAuth
@@ -162,22 +352,66 @@ func UpdateProduct(c *fiber.Ctx) error {
 		})
 	}
 
+	// SKUs are optional but unique
+	req.SKU = services.NormaliseSKU(req.SKU)
+	if skuTaken(req.SKU, product.ID) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": "SKU is already in use",
+		})
+	}
+
 	// Update the product fields
+	supplierChanged := product.SupplierID != req.SupplierID
+	oldPrice := product.Price
 	product.Name = req.Name
+	product.SKU = req.SKU
 	product.Description = req.Description
-	product.Price = req.Price
+	product.Price = req.Price.Round()
 	product.CategoryID = req.CategoryID
 	product.SupplierID = req.SupplierID
+	product.TaxClassID = req.TaxClassID
+	product.ReorderPoint = req.ReorderPoint
+	product.ReorderQuantity = req.ReorderQuantity
+	product.MetaTitle = req.MetaTitle
+	product.MetaDescription = req.MetaDescription
 
-	// Save the updated product to the database
-	if err := db.Save(&product).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	// Save the updated product to the database; a new supplier becomes the preferred one
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := services.CheckTaxClass(tx, product.TaxClassID); err != nil {
+			return err
+		}
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+		if err := services.RecordPriceChange(tx, &product, &oldPrice, currentUserID(c), ""); err != nil {
+			return err
+		}
+		if req.Slug != "" && services.Slugify(req.Slug) != product.Slug {
+			if err := assignProductSlug(tx, &product, req); err != nil {
+				return err
+			}
+		}
+		if err := services.SetProductAttributes(tx, &product, req.Attributes); err != nil {
+			return err
+		}
+		if !supplierChanged {
+			return nil
+		}
+		_, err := services.LinkSupplier(tx, product.ID, models.ProductSupplierRequest{
+			SupplierID: product.SupplierID,
+			Preferred:  true,
+		})
+		return err
+	})
+	if err != nil {
+		return c.Status(productErrorStatus(err)).JSON(fiber.Map{
 			"message": "Failed to update product",
 			"error":   err.Error(),
 		})
 	}
 
 	// Return the updated product as response
+	product.Currency = services.BaseCurrency()
 	return c.JSON(product)
 }
 
@@ -213,3 +447,65 @@ func DeleteProduct(c *fiber.Ctx) error {
 		"message": "Product deleted successfully",
 	})
 }
+
+// @Summary Get product by slug
+// @Description Get a product by its URL slug. Old slugs answer with a 301 redirect to the current one. Customers and anonymous shoppers get a catalog entry without supplier, costs and reorder settings.
+// @Tags Products
+// @Produce json
+// @Param slug path string true "Product slug"
+// @Param currency query string false "Currency of the price, the base currency by default"
+// @Success 200 {object} models.ProductResponse
+// @Success 301 {object} nil
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/by-slug/{slug} [get]
+// @Security BearerAuth
+func GetProductBySlug(c *fiber.Ctx) error {
+	// Get the database connection
+	db := database.DB
+
+	// Resolve the slug, redirecting old slugs to the current one
+	slug := c.Params("slug")
+	id, current, err := services.ResolveSlug(db, models.SlugTypeProduct, slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Product not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch product",
+			"error":   err.Error(),
+		})
+	}
+	if current != slug {
+		return redirectToSlug(c, "/api/v1/products/by-slug/"+current)
+	}
+
+	// Query the product from the database by ID
+	var product models.Product
+	if err := db.First(&product, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Product not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch product",
+			"error":   err.Error(),
+		})
+	}
+
+	// Convert the price to the requested currency
+	products := []models.Product{product}
+	if err := productsInCurrency(c, products); err != nil {
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Return the product as response
+	return productJSON(c, products[0])
+}
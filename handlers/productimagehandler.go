@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/DewiKresnawati/DewiWebService/storage"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func productImageResponse(img models.ProductImage) models.ProductImageResponse {
+	thumbnails := make(map[string]string, len(services.ThumbnailSizes))
+	for _, thumbnail := range services.ThumbnailSizes {
+		thumbnails[thumbnail.Name] = storage.Default.URL(services.ThumbnailKey(img, thumbnail.Name))
+	}
+	return models.ProductImageResponse{
+		ID:          img.ID,
+		ProductID:   img.ProductID,
+		URL:         storage.Default.URL(img.Key),
+		FileName:    img.FileName,
+		ContentType: img.ContentType,
+		Size:        img.Size,
+		Width:       img.Width,
+		Height:      img.Height,
+		AltText:     img.AltText,
+		Position:    img.Position,
+		Primary:     img.IsPrimary,
+		Thumbnails:  thumbnails,
+	}
+}
+
+func productImageResponses(images []models.ProductImage) []models.ProductImageResponse {
+	response := make([]models.ProductImageResponse, 0, len(images))
+	for _, img := range images {
+		response = append(response, productImageResponse(img))
+	}
+	return response
+}
+
+// productImageErrorStatus maps image errors to HTTP status codes.
+func productImageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrImageTooLarge):
+		return fiber.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrUnsupportedImage):
+		return fiber.StatusUnsupportedMediaType
+	case errors.Is(err, services.ErrImageOrder), errors.Is(err, services.ErrImageDimensions):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// findProductImage loads an image of the product named in the URL.
+func findProductImage(c *fiber.Ctx) (*models.ProductImage, error) {
+	var img models.ProductImage
+	err := database.DB.Where("product_id = ?", c.Params("id")).First(&img, c.Params("imageId")).Error
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+// GetProductImages handles retrieving the images of a product.
+// @Summary Get product images
+// @Description Retrieve the images of a product in display order, with thumbnail URLs
+// @Tags Products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.ProductImageResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/images [get]
+// @Security BearerAuth
+func GetProductImages(c *fiber.Ctx) error {
+	db := database.DB
+	var product models.Product
+	if err := db.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+
+	var images []models.ProductImage
+	if err := db.Where("product_id = ?", product.ID).Order("position, id").Find(&images).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(productImageResponses(images))
+}
+
+// UploadProductImage handles uploading an image of a product.
+// @Summary Upload a product image
+// @Description Upload a JPEG, PNG, GIF or WebP image (at most MAX_IMAGE_SIZE bytes, 5 MiB by default, and MAX_IMAGE_PIXELS pixels, 40 megapixels by default). Small, medium and large thumbnails are generated. The first image of a product becomes its primary image.
+// @Tags Products
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param image formData file true "Image file"
+// @Param alt_text formData string false "Alternative text"
+// @Success 201 {object} models.ProductImageResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Failure 415 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/images [post]
+// @Security BearerAuth
+func UploadProductImage(c *fiber.Ctx) error {
+	db := database.DB
+	var product models.Product
+	if err := db.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+
+	header, err := c.FormFile("image")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "An image file is required",
+		})
+	}
+	if header.Size > services.MaxImageSize() {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": services.ErrImageTooLarge.Error(),
+		})
+	}
+	file, err := header.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, services.MaxImageSize()+1))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	img, err := services.UploadProductImage(c.Context(), storage.Default, product.ID, header.Filename, data)
+	if err != nil {
+		return c.Status(productImageErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	img.AltText = c.FormValue("alt_text")
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return services.AddProductImage(tx, img)
+	})
+	if err != nil {
+		services.RemoveProductImageFiles(c.Context(), storage.Default, *img)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(productImageResponse(*img))
+}
+
+// UpdateProductImage handles updating an image of a product.
+// @Summary Update a product image
+// @Description Change the alternative text of an image or make it the primary image
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param imageId path int true "Image ID"
+// @Param image body models.ProductImageRequest true "Image data"
+// @Success 200 {object} models.ProductImageResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/images/{imageId} [put]
+// @Security BearerAuth
+func UpdateProductImage(c *fiber.Ctx) error {
+	var req models.ProductImageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	img, err := findProductImage(c)
+	if err != nil {
+		return c.Status(productImageErrorStatus(err)).JSON(fiber.Map{
+			"error": "Image not found",
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		img.AltText = req.AltText
+		if err := tx.Model(img).Update("alt_text", img.AltText).Error; err != nil {
+			return err
+		}
+		if req.Primary && !img.IsPrimary {
+			return services.SetPrimaryImage(tx, img)
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(productImageErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(productImageResponse(*img))
+}
+
+// ReorderProductImages handles changing the order of the images of a product.
+// @Summary Reorder product images
+// @Description Put the images of a product in a new display order
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param order body models.ProductImageOrderRequest true "Image IDs in the new order"
+// @Success 200 {array} models.ProductImageResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/images/order [put]
+// @Security BearerAuth
+func ReorderProductImages(c *fiber.Ctx) error {
+	db := database.DB
+	var req models.ProductImageOrderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	var product models.Product
+	if err := db.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+
+	var images []models.ProductImage
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		images, err = services.ReorderProductImages(tx, product.ID, req.ImageIDs)
+		return err
+	})
+	if err != nil {
+		return c.Status(productImageErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(productImageResponses(images))
+}
+
+// DeleteProductImage handles deleting an image of a product.
+// @Summary Delete a product image
+// @Description Delete an image and its thumbnails. When it was the primary image the next image takes its place.
+// @Tags Products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param imageId path int true "Image ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/images/{imageId} [delete]
+// @Security BearerAuth
+func DeleteProductImage(c *fiber.Ctx) error {
+	img, err := findProductImage(c)
+	if err != nil {
+		return c.Status(productImageErrorStatus(err)).JSON(fiber.Map{
+			"error": "Image not found",
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.DeleteProductImage(tx, img)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	services.RemoveProductImageFiles(c.Context(), storage.Default, *img)
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
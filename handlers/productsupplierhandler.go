@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func productSupplierResponse(link models.ProductSupplier) models.ProductSupplierResponse {
+	return models.ProductSupplierResponse{
+		ProductID:    link.ProductID,
+		SupplierID:   link.SupplierID,
+		SupplierName: link.Supplier.Name,
+		SupplierSKU:  link.SupplierSKU,
+		Preferred:    link.Preferred,
+	}
+}
+
+// productSupplierErrorStatus maps product supplier errors to HTTP status codes.
+func productSupplierErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrPreferredSupplier):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// GetProductSuppliers handles retrieving the suppliers of a product.
+// @Summary Get product suppliers
+// @Description Retrieve every supplier a product can be bought from, preferred first
+// @Tags Products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.ProductSupplierResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/suppliers [get]
+// @Security BearerAuth
+func GetProductSuppliers(c *fiber.Ctx) error {
+	db := database.DB
+	var product models.Product
+	if err := db.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+
+	var links []models.ProductSupplier
+	if err := db.Preload("Supplier").Where("product_id = ?", product.ID).Order("preferred DESC, supplier_id").Find(&links).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.ProductSupplierResponse, 0, len(links))
+	for _, link := range links {
+		response = append(response, productSupplierResponse(link))
+	}
+
+	return c.JSON(response)
+}
+
+// AddProductSupplier handles linking a supplier to a product.
+// @Summary Add product supplier
+// @Description Link a supplier to a product, optionally as the preferred supplier
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param supplier body models.ProductSupplierRequest true "Product supplier data"
+// @Success 201 {object} models.ProductSupplierResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/suppliers [post]
+// @Security BearerAuth
+func AddProductSupplier(c *fiber.Ctx) error {
+	var req models.ProductSupplierRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return saveProductSupplier(c, req, fiber.StatusCreated)
+}
+
+// UpdateProductSupplier handles updating the link between a product and a supplier.
+// @Summary Update product supplier
+// @Description Update the supplier SKU or make the supplier preferred
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param supplierId path int true "Supplier ID"
+// @Param supplier body models.ProductSupplierRequest true "Product supplier data"
+// @Success 200 {object} models.ProductSupplierResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/suppliers/{supplierId} [put]
+// @Security BearerAuth
+func UpdateProductSupplier(c *fiber.Ctx) error {
+	var req models.ProductSupplierRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	supplierID, err := strconv.ParseUint(c.Params("supplierId"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid supplier ID",
+		})
+	}
+	req.SupplierID = uint(supplierID)
+
+	var count int64
+	database.DB.Model(&models.ProductSupplier{}).Where("product_id = ? AND supplier_id = ?", c.Params("id"), req.SupplierID).Count(&count)
+	if count == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier is not linked to this product",
+		})
+	}
+	return saveProductSupplier(c, req, fiber.StatusOK)
+}
+
+func saveProductSupplier(c *fiber.Ctx, req models.ProductSupplierRequest, status int) error {
+	db := database.DB
+	var product models.Product
+	if err := db.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+
+	var link *models.ProductSupplier
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		link, err = services.LinkSupplier(tx, product.ID, req)
+		return err
+	})
+	if err != nil {
+		return c.Status(productSupplierErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(status).JSON(productSupplierResponse(*link))
+}
+
+// RemoveProductSupplier handles unlinking a supplier from a product.
+// @Summary Remove product supplier
+// @Description Unlink a non-preferred supplier from a product
+// @Tags Products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param supplierId path int true "Supplier ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/suppliers/{supplierId} [delete]
+// @Security BearerAuth
+func RemoveProductSupplier(c *fiber.Ctx) error {
+	db := database.DB
+	productID, _ := strconv.ParseUint(c.Params("id"), 10, 64)
+	supplierID, _ := strconv.ParseUint(c.Params("supplierId"), 10, 64)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.UnlinkSupplier(tx, uint(productID), uint(supplierID))
+	})
+	if err != nil {
+		return c.Status(productSupplierErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func promotionResponse(promotion models.Promotion, timesUsed int64) models.PromotionResponse {
+	productIDs := make([]uint, 0, len(promotion.Products))
+	for _, product := range promotion.Products {
+		productIDs = append(productIDs, product.ID)
+	}
+	categoryIDs := make([]uint, 0, len(promotion.Categories))
+	for _, category := range promotion.Categories {
+		categoryIDs = append(categoryIDs, category.ID)
+	}
+	return models.PromotionResponse{
+		ID:          promotion.ID,
+		Name:        promotion.Name,
+		Code:        promotion.Code,
+		Type:        promotion.Type,
+		Value:       promotion.Value,
+		BuyQuantity: promotion.BuyQuantity,
+		GetQuantity: promotion.GetQuantity,
+		MinSpend:    promotion.MinSpend,
+		UsageLimit:  promotion.UsageLimit,
+		TimesUsed:   timesUsed,
+		StartsAt:    promotion.StartsAt,
+		EndsAt:      promotion.EndsAt,
+		Active:      promotion.Active,
+		ProductIDs:  productIDs,
+		CategoryIDs: categoryIDs,
+	}
+}
+
+// promotionErrorStatus maps promotion errors to HTTP status codes.
+func promotionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrInvalidPromotion):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrDuplicateCouponCode):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// GetPromotions handles listing promotions and coupons.
+// @Summary Get promotions
+// @Description Retrieve promotions with how many orders use them. Promotions with a code are coupons; the others apply automatically.
+// @Tags Promotions
+// @Produce json
+// @Param kind query string false "coupon for promotions with a code, automatic for the others"
+// @Param type query string false "Promotion type (percentage, fixed_amount, free_shipping, buy_x_get_y)"
+// @Param active query bool false "Only active or inactive promotions"
+// @Success 200 {array} models.PromotionResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /promotions [get]
+// @Security BearerAuth
+func GetPromotions(c *fiber.Ctx) error {
+	db := database.DB
+	query := db.Preload("Products").Preload("Categories").Order("id")
+	switch c.Query("kind") {
+	case "coupon":
+		query = query.Where("code IS NOT NULL")
+	case "automatic":
+		query = query.Where("code IS NULL")
+	}
+	if promotionType := c.Query("type"); promotionType != "" {
+		query = query.Where("type = ?", promotionType)
+	}
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", c.QueryBool("active"))
+	}
+
+	var promotions []models.Promotion
+	if err := query.Find(&promotions).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	ids := make([]uint, len(promotions))
+	for i, promotion := range promotions {
+		ids[i] = promotion.ID
+	}
+	usage, err := services.PromotionUsage(db, ids)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.PromotionResponse, 0, len(promotions))
+	for _, promotion := range promotions {
+		response = append(response, promotionResponse(promotion, usage[promotion.ID]))
+	}
+	return c.JSON(response)
+}
+
+// GetPromotion handles getting a promotion by ID.
+// @Summary Get promotion by ID
+// @Description Retrieve a promotion or coupon with how many orders use it
+// @Tags Promotions
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} models.PromotionResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /promotions/{id} [get]
+// @Security BearerAuth
+func GetPromotion(c *fiber.Ctx) error {
+	db := database.DB
+	var promotion models.Promotion
+	if err := db.Preload("Products").Preload("Categories").First(&promotion, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Promotion not found",
+		})
+	}
+	usage, err := services.PromotionUsage(db, []uint{promotion.ID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(promotionResponse(promotion, usage[promotion.ID]))
+}
+
+// CreatePromotion handles creating a promotion or coupon.
+// @Summary Create promotion
+// @Description Create a promotion. With a code it is a coupon that applies to orders carrying the code; without one it applies automatically to every order it matches. Restricting it to products or categories limits it to those products and the products of those categories and their children.
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Param promotion body models.PromotionRequest true "Promotion"
+// @Success 201 {object} models.PromotionResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /promotions [post]
+// @Security BearerAuth
+func CreatePromotion(c *fiber.Ctx) error {
+	var req models.PromotionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var promotion models.Promotion
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.SavePromotion(tx, &promotion, req)
+	})
+	if err != nil {
+		return c.Status(promotionErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(promotionResponse(promotion, 0))
+}
+
+// UpdatePromotion handles updating a promotion or coupon.
+// @Summary Update promotion
+// @Description Update a promotion and replace its restrictions. Orders already placed keep the discounts they were priced with.
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Param promotion body models.PromotionRequest true "Promotion"
+// @Success 200 {object} models.PromotionResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /promotions/{id} [put]
+// @Security BearerAuth
+func UpdatePromotion(c *fiber.Ctx) error {
+	var req models.PromotionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	db := database.DB
+	var promotion models.Promotion
+	if err := db.First(&promotion, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Promotion not found",
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.SavePromotion(tx, &promotion, req)
+	})
+	if err != nil {
+		return c.Status(promotionErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	usage, err := services.PromotionUsage(db, []uint{promotion.ID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(promotionResponse(promotion, usage[promotion.ID]))
+}
+
+// DeletePromotion handles deleting a promotion or coupon.
+// @Summary Delete promotion
+// @Description Delete a promotion; its code can then be used again. Orders already placed keep the discounts it gave them. Set active to false instead to pause a promotion.
+// @Tags Promotions
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /promotions/{id} [delete]
+// @Security BearerAuth
+func DeletePromotion(c *fiber.Ctx) error {
+	db := database.DB
+	var promotion models.Promotion
+	if err := db.First(&promotion, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Promotion not found",
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.DeletePromotion(tx, &promotion)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
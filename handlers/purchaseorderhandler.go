@@ -0,0 +1,460 @@
+package handlers
+
+import (
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func purchaseOrderResponse(po models.PurchaseOrder) models.PurchaseOrderResponse {
+	items := make([]models.PurchaseOrderItemResponse, 0, len(po.Items))
+	for _, item := range po.Items {
+		items = append(items, models.PurchaseOrderItemResponse{
+			ID:               item.ID,
+			ProductID:        item.ProductID,
+			VariantID:        item.VariantID,
+			Quantity:         item.Quantity,
+			ReceivedQuantity: item.ReceivedQuantity,
+			UnitCost:         item.UnitCost,
+		})
+	}
+	return models.PurchaseOrderResponse{
+		ID:             po.ID,
+		SupplierID:     po.SupplierID,
+		WarehouseID:    po.WarehouseID,
+		Status:         po.Status,
+		Note:           po.Note,
+		SentAt:         po.SentAt,
+		AcknowledgedAt: po.AcknowledgedAt,
+		ReceivedAt:     po.ReceivedAt,
+		ClosedAt:       po.ClosedAt,
+		Items:          items,
+	}
+}
+
+func goodsReceiptResponse(receipt models.GoodsReceipt) models.GoodsReceiptResponse {
+	items := make([]models.GoodsReceiptItemResponse, 0, len(receipt.Items))
+	for _, item := range receipt.Items {
+		items = append(items, models.GoodsReceiptItemResponse{
+			PurchaseOrderItemID: item.PurchaseOrderItemID,
+			ProductID:           item.ProductID,
+			VariantID:           item.VariantID,
+			Quantity:            item.Quantity,
+			UnitCost:            item.UnitCost,
+		})
+	}
+	return models.GoodsReceiptResponse{
+		ID:              receipt.ID,
+		PurchaseOrderID: receipt.PurchaseOrderID,
+		WarehouseID:     receipt.WarehouseID,
+		Note:            receipt.Note,
+		UserID:          receipt.UserID,
+		ReceivedAt:      receipt.ReceivedAt,
+		Items:           items,
+	}
+}
+
+// purchaseOrderItems validates the item lines of a purchase order request.
+func purchaseOrderItems(req models.PurchaseOrderRequest) ([]models.PurchaseOrderItem, string) {
+	if len(req.Items) == 0 {
+		return nil, "Purchase order must contain at least one item"
+	}
+	items := make([]models.PurchaseOrderItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		if item.Quantity == 0 {
+			return nil, "Item quantity must be greater than zero"
+		}
+		if item.UnitCost.IsNegative() {
+			return nil, "Item unit cost must not be negative"
+		}
+		items = append(items, models.PurchaseOrderItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+			UnitCost:  item.UnitCost,
+		})
+	}
+	return items, ""
+}
+
+// checkPurchaseOrderVariants checks that the variants of the item lines
+// belong to their products.
+func checkPurchaseOrderVariants(tx *gorm.DB, items []models.PurchaseOrderItem) error {
+	for _, item := range items {
+		if err := services.CheckVariant(tx, item.ProductID, item.VariantID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreatePurchaseOrder handles creating a new purchase order.
+// @Summary Create a new purchase order
+// @Description Create a draft purchase order to a supplier
+// @Tags Purchase Orders
+// @Accept json
+// @Produce json
+// @Param purchase_order body models.PurchaseOrderRequest true "Purchase order data"
+// @Success 201 {object} models.PurchaseOrderResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /purchase-orders [post]
+// @Security BearerAuth
+func CreatePurchaseOrder(c *fiber.Ctx) error {
+	db := database.DB
+	var req models.PurchaseOrderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	items, msg := purchaseOrderItems(req)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	po := models.PurchaseOrder{
+		SupplierID:  req.SupplierID,
+		WarehouseID: req.WarehouseID,
+		Status:      models.PurchaseOrderStatusDraft,
+		Note:        req.Note,
+		Items:       items,
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkPurchaseOrderVariants(tx, items); err != nil {
+			return err
+		}
+		return tx.Create(&po).Error
+	})
+	if err != nil {
+		return c.Status(stockErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(purchaseOrderResponse(po))
+}
+
+// GetAllPurchaseOrders handles retrieving all purchase orders.
+// @Summary Get all purchase orders
+// @Description Retrieve purchase orders, optionally filtered by status and supplier
+// @Tags Purchase Orders
+// @Produce json
+// @Param status query string false "Status"
+// @Param supplier_id query int false "Supplier ID"
+// @Success 200 {array} models.PurchaseOrderResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /purchase-orders [get]
+// @Security BearerAuth
+func GetAllPurchaseOrders(c *fiber.Ctx) error {
+	db := database.DB
+	query := db.Preload("Items").Order("id DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if supplierID := c.QueryInt("supplier_id"); supplierID != 0 {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+
+	var pos []models.PurchaseOrder
+	if err := query.Find(&pos).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.PurchaseOrderResponse, 0, len(pos))
+	for _, po := range pos {
+		response = append(response, purchaseOrderResponse(po))
+	}
+
+	return c.JSON(response)
+}
+
+// GetPurchaseOrderByID handles retrieving a purchase order by its ID.
+// @Summary Get purchase order by ID
+// @Description Retrieve a purchase order by its ID
+// @Tags Purchase Orders
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} models.PurchaseOrderResponse
+// @Failure 404 {object} map[string]interface{}
+// @Router /purchase-orders/{id} [get]
+// @Security BearerAuth
+func GetPurchaseOrderByID(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var po models.PurchaseOrder
+	if err := db.Preload("Items").First(&po, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Purchase order not found",
+		})
+	}
+
+	return c.JSON(purchaseOrderResponse(po))
+}
+
+// UpdatePurchaseOrder handles updating a draft purchase order.
+// @Summary Update purchase order
+// @Description Replace the supplier, warehouse and items of a draft purchase order
+// @Tags Purchase Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Param purchase_order body models.PurchaseOrderRequest true "Purchase order data"
+// @Success 200 {object} models.PurchaseOrderResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /purchase-orders/{id} [put]
+// @Security BearerAuth
+func UpdatePurchaseOrder(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var req models.PurchaseOrderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	items, msg := purchaseOrderItems(req)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var po models.PurchaseOrder
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&po, id).Error; err != nil {
+			return err
+		}
+		if po.Status != models.PurchaseOrderStatusDraft {
+			return services.ErrInvalidTransition
+		}
+		if err := checkPurchaseOrderVariants(tx, items); err != nil {
+			return err
+		}
+		if err := tx.Where("purchase_order_id = ?", po.ID).Delete(&models.PurchaseOrderItem{}).Error; err != nil {
+			return err
+		}
+		po.SupplierID = req.SupplierID
+		po.WarehouseID = req.WarehouseID
+		po.Note = req.Note
+		po.Items = items
+		return tx.Save(&po).Error
+	})
+	if err != nil {
+		return c.Status(stockErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(purchaseOrderResponse(po))
+}
+
+// changePurchaseOrder loads a purchase order under lock and applies a workflow step to it.
+func changePurchaseOrder(c *fiber.Ctx, step func(tx *gorm.DB, po *models.PurchaseOrder, userID *uint) error) error {
+	db := database.DB
+	id := c.Params("id")
+	var po models.PurchaseOrder
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&po, id).Error; err != nil {
+			return err
+		}
+		return step(tx, &po, currentUserID(c))
+	})
+	if err != nil {
+		return c.Status(stockErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(purchaseOrderResponse(po))
+}
+
+// SendPurchaseOrder handles sending a purchase order to the supplier.
+// @Summary Send purchase order
+// @Description Mark a draft purchase order as sent to the supplier
+// @Tags Purchase Orders
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} models.PurchaseOrderResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /purchase-orders/{id}/send [post]
+// @Security BearerAuth
+func SendPurchaseOrder(c *fiber.Ctx) error {
+	return changePurchaseOrder(c, services.SendPurchaseOrder)
+}
+
+// ClosePurchaseOrder handles closing a purchase order.
+// @Summary Close purchase order
+// @Description Close a received or partially received purchase order
+// @Tags Purchase Orders
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} models.PurchaseOrderResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /purchase-orders/{id}/close [post]
+// @Security BearerAuth
+func ClosePurchaseOrder(c *fiber.Ctx) error {
+	return changePurchaseOrder(c, services.ClosePurchaseOrder)
+}
+
+// CancelPurchaseOrder handles cancelling a purchase order.
+// @Summary Cancel purchase order
+// @Description Cancel a draft or sent purchase order
+// @Tags Purchase Orders
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} models.PurchaseOrderResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /purchase-orders/{id}/cancel [post]
+// @Security BearerAuth
+func CancelPurchaseOrder(c *fiber.Ctx) error {
+	return changePurchaseOrder(c, services.CancelPurchaseOrder)
+}
+
+// CreateGoodsReceipt handles receiving goods against a purchase order.
+// @Summary Receive goods
+// @Description Book received quantities and actual costs into stock and advance the purchase order status
+// @Tags Purchase Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Param receipt body models.GoodsReceiptRequest true "Goods receipt data"
+// @Success 201 {object} models.GoodsReceiptResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /purchase-orders/{id}/receipts [post]
+// @Security BearerAuth
+func CreateGoodsReceipt(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var req models.GoodsReceiptRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var receipt *models.GoodsReceipt
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var po models.PurchaseOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&po, id).Error; err != nil {
+			return err
+		}
+		var err error
+		receipt, err = services.ReceiveGoods(tx, &po, req, currentUserID(c))
+		return err
+	})
+	if err != nil {
+		return c.Status(stockErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(goodsReceiptResponse(*receipt))
+}
+
+// GetGoodsReceipts handles retrieving the goods receipts of a purchase order.
+// @Summary Get goods receipts
+// @Description Retrieve the deliveries received against a purchase order
+// @Tags Purchase Orders
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {array} models.GoodsReceiptResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /purchase-orders/{id}/receipts [get]
+// @Security BearerAuth
+func GetGoodsReceipts(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var po models.PurchaseOrder
+	if err := db.First(&po, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Purchase order not found",
+		})
+	}
+
+	var receipts []models.GoodsReceipt
+	if err := db.Preload("Items").Where("purchase_order_id = ?", po.ID).Order("received_at").Find(&receipts).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.GoodsReceiptResponse, 0, len(receipts))
+	for _, receipt := range receipts {
+		response = append(response, goodsReceiptResponse(receipt))
+	}
+
+	return c.JSON(response)
+}
+
+// CreateReorderPurchaseOrder handles turning reorder suggestions into a draft purchase order.
+// @Summary Create purchase order from reorder suggestions
+// @Description Create a draft purchase order for the supplier's low-stock products
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param request body models.ReorderPurchaseOrderRequest true "Destination warehouse"
+// @Success 201 {object} models.PurchaseOrderResponse
+// @Success 204 {object} nil
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/reorder-suggestions/purchase-order [post]
+// @Security BearerAuth
+func CreateReorderPurchaseOrder(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var req models.ReorderPurchaseOrderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var supplier models.Supplier
+	if err := db.First(&supplier, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+	var warehouse models.Warehouse
+	if err := db.First(&warehouse, req.WarehouseID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Warehouse not found",
+		})
+	}
+
+	var po *models.PurchaseOrder
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		po, err = services.DraftPurchaseOrder(tx, supplier.ID, warehouse.ID)
+		return err
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if po == nil {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(purchaseOrderResponse(*po))
+}
@@ -9,17 +9,35 @@ import (
 )
 
 // @Summary Register
-// @Description Register a new user
+// @Description Register a new staff user. Only staff may register staff users, except for the first one, which anyone may register while there is no staff user yet. Customers sign up through /customers/register.
 // @ID register
 // @Accept  json
 // @Produce  json
 // @Param   register  body     models.RegisterRequest  true  "Register Request"
 // @Success 201    {object} map[string]interface{}
 // @Failure 400    {object} map[string]interface{}
+// @Failure 403    {object} map[string]interface{}
 // @Failure 500    {object} map[string]interface{}
 // @Router /register [post]
+// @Security BearerAuth
 func Register(c *fiber.Ctx) error {
 	db := database.DB
+	// Tanpa token staff, pendaftaran hanya terbuka untuk user staff pertama
+	if currentRole(c) != models.RoleStaff {
+		var staff int64
+		if err := db.Model(&models.User{}).Where("role = ?", models.RoleStaff).Count(&staff).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "failed to register user",
+				"error":   err.Error(),
+			})
+		}
+		if staff > 0 {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "only staff may register staff users",
+			})
+		}
+	}
+
 	user := new(models.User)
 	err := c.BodyParser(user)
 	if err != nil {
@@ -38,6 +56,9 @@ func Register(c *fiber.Ctx) error {
 	}
 
 	user.Password = string(hashedPassword)
+	// Pendaftaran ini selalu menghasilkan user staff; user supplier dibuat lewat /suppliers/:id/users
+	user.Role = models.RoleStaff
+	user.SupplierID = nil
 
 	result := db.Create(&user)
 	if result.Error != nil {
@@ -47,7 +68,7 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
-	token, err := utils.GenerateToken(user.ID)
+	token, err := utils.GenerateToken(*user)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "failed to generate token",
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetMarginReport handles the sales margin report.
+// @Summary Get margin report
+// @Description Revenue, cost of goods sold and margin of order lines grouped by product, category or supplier. Revenue is net of discounts and excludes tax and shipping; returned goods are netted out and refunded orders are left out.
+// @Tags Reports
+// @Produce json
+// @Param group_by query string false "Grouping (product, category, supplier)" default(product)
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD), inclusive"
+// @Success 200 {array} models.MarginReportRow
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /reports/margins [get]
+// @Security BearerAuth
+func GetMarginReport(c *fiber.Ctx) error {
+	db := database.DB
+	var from, to time.Time
+	if s := c.Query("from"); s != "" {
+		date, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid from date, expected YYYY-MM-DD",
+			})
+		}
+		from = date
+	}
+	if s := c.Query("to"); s != "" {
+		date, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid to date, expected YYYY-MM-DD",
+			})
+		}
+		to = date.AddDate(0, 0, 1)
+	}
+
+	report, err := services.MarginReport(db, c.Query("group_by"), from, to)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, services.ErrUnknownGrouping) {
+			status = fiber.StatusBadRequest
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(report)
+}
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/payment"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func creditNoteResponse(note models.CreditNote) models.CreditNoteResponse {
+	return models.CreditNoteResponse{
+		ID:             note.ID,
+		Number:         note.Number,
+		OrderID:        note.OrderID,
+		ReturnID:       note.OrderReturnID,
+		Subtotal:       note.Subtotal,
+		TaxTotal:       note.TaxTotal,
+		Total:          note.Total,
+		Currency:       note.Currency,
+		RefundedAmount: note.RefundedAmount,
+		Reason:         note.Reason,
+		IssuedAt:       note.IssuedAt,
+	}
+}
+
+func returnResponse(ret models.OrderReturn) models.OrderReturnResponse {
+	response := models.OrderReturnResponse{
+		ID:           ret.ID,
+		OrderID:      ret.OrderID,
+		OrderItemID:  ret.OrderItemID,
+		CustomerID:   ret.CustomerID,
+		Quantity:     ret.Quantity,
+		Reason:       ret.Reason,
+		Note:         ret.Note,
+		Status:       ret.Status,
+		DecisionNote: ret.DecisionNote,
+		WarehouseID:  ret.WarehouseID,
+		Restocked:    ret.Restocked,
+		CreatedAt:    ret.CreatedAt,
+		ApprovedAt:   ret.ApprovedAt,
+		ReceivedAt:   ret.ReceivedAt,
+		RefundedAt:   ret.RefundedAt,
+	}
+	if ret.CreditNote != nil {
+		note := creditNoteResponse(*ret.CreditNote)
+		response.CreditNote = &note
+	}
+	return response
+}
+
+// returnErrorStatus maps return errors to HTTP status codes.
+func returnErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrReturnQuantity), errors.Is(err, services.ErrReturnWarehouse),
+		errors.Is(err, services.ErrReturnItem), errors.Is(err, services.ErrRefundAboveValue):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrReturnNotPaid), errors.Is(err, services.ErrReturnNotShipped):
+		return fiber.StatusConflict
+	default:
+		return paymentErrorStatus(err)
+	}
+}
+
+// CreateOrderReturn handles asking to return goods of an order.
+// @Summary Request order return
+// @Description Ask to send back some or all of the goods of an order with a reason code (damaged, defective, wrong_item, not_as_described, no_longer_needed, other). The order must be paid and the goods shipped. Goods of returns that have not been rejected cannot be asked for again. Customers may only return goods of their own orders.
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param return body models.OrderReturnRequest true "Return"
+// @Success 201 {object} models.OrderReturnResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /orders/{id}/returns [post]
+// @Security BearerAuth
+func CreateOrderReturn(c *fiber.Ctx) error {
+	var req models.OrderReturnRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	db, err := customerOrders(c, database.DB)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+	var order models.Order
+	if err := db.First(&order, c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Order not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var ret *models.OrderReturn
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ret, err = services.RequestReturn(tx, order.ID, req, currentUserID(c))
+		return err
+	})
+	if err != nil {
+		return c.Status(returnErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(returnResponse(*ret))
+}
+
+// GetOrderReturns handles listing the returns of an order.
+// @Summary List order returns
+// @Description Retrieve the returns of an order with their credit notes. Customers may only see the returns of their own orders.
+// @Tags Returns
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} models.OrderReturnResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /orders/{id}/returns [get]
+// @Security BearerAuth
+func GetOrderReturns(c *fiber.Ctx) error {
+	db, err := customerOrders(c, database.DB)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+	var returns []models.OrderReturn
+	if err := db.Preload("CreditNote").Where("order_id = ?", c.Params("id")).Order("id").Find(&returns).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.OrderReturnResponse, 0, len(returns))
+	for _, ret := range returns {
+		response = append(response, returnResponse(ret))
+	}
+	return c.JSON(response)
+}
+
+// GetOrderCreditNotes handles listing the credit notes of an order.
+// @Summary List order credit notes
+// @Description Retrieve the credit notes issued against an order. Customers may only see the credit notes of their own orders.
+// @Tags Returns
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} models.CreditNoteResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /orders/{id}/credit-notes [get]
+// @Security BearerAuth
+func GetOrderCreditNotes(c *fiber.Ctx) error {
+	db, err := customerOrders(c, database.DB)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+	var order models.Order
+	if err := db.First(&order, c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Order not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var notes []models.CreditNote
+	if err := database.DB.Where("order_id = ?", order.ID).Order("id").Find(&notes).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	response := make([]models.CreditNoteResponse, 0, len(notes))
+	for _, note := range notes {
+		response = append(response, creditNoteResponse(note))
+	}
+	return c.JSON(response)
+}
+
+// GetAllReturns handles listing returns.
+// @Summary List returns
+// @Description Retrieve returns, newest first, optionally filtered by status or order
+// @Tags Returns
+// @Produce json
+// @Param status query string false "Return status (requested, approved, rejected, received, refunded)"
+// @Param order_id query int false "Order ID"
+// @Success 200 {array} models.OrderReturnResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /returns [get]
+// @Security BearerAuth
+func GetAllReturns(c *fiber.Ctx) error {
+	query := database.DB.Preload("CreditNote").Order("id DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if orderID := c.QueryInt("order_id"); orderID != 0 {
+		query = query.Where("order_id = ?", orderID)
+	}
+
+	var returns []models.OrderReturn
+	if err := query.Find(&returns).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.OrderReturnResponse, 0, len(returns))
+	for _, ret := range returns {
+		response = append(response, returnResponse(ret))
+	}
+	return c.JSON(response)
+}
+
+// GetReturnByID handles retrieving a return by its ID.
+// @Summary Get return by ID
+// @Description Retrieve a return with its credit note. Customers may only see their own returns.
+// @Tags Returns
+// @Produce json
+// @Param id path int true "Return ID"
+// @Success 200 {object} models.OrderReturnResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /returns/{id} [get]
+// @Security BearerAuth
+func GetReturnByID(c *fiber.Ctx) error {
+	db, err := customerOrders(c, database.DB)
+	if err != nil {
+		return customerProfileError(c, err)
+	}
+	var ret models.OrderReturn
+	if err := db.Preload("CreditNote").First(&ret, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Return not found",
+		})
+	}
+
+	return c.JSON(returnResponse(ret))
+}
+
+// changeReturn loads a return under lock and applies a workflow step to it.
+func changeReturn(c *fiber.Ctx, step func(tx *gorm.DB, ret *models.OrderReturn) error) error {
+	id := c.Params("id")
+	var ret models.OrderReturn
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("CreditNote").First(&ret, id).Error; err != nil {
+			return err
+		}
+		return step(tx, &ret)
+	})
+	if err != nil {
+		return c.Status(returnErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(returnResponse(ret))
+}
+
+// parseOptionalBody parses the request body into out when one was sent.
+func parseOptionalBody(c *fiber.Ctx, out interface{}) error {
+	if len(c.Body()) == 0 {
+		return nil
+	}
+	return c.BodyParser(out)
+}
+
+// ApproveReturn handles accepting a return.
+// @Summary Approve return
+// @Description Accept a requested return so that the goods may be sent back
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param decision body models.ReturnDecisionRequest false "Decision"
+// @Success 200 {object} models.OrderReturnResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /returns/{id}/approve [post]
+// @Security BearerAuth
+func ApproveReturn(c *fiber.Ctx) error {
+	var req models.ReturnDecisionRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return changeReturn(c, func(tx *gorm.DB, ret *models.OrderReturn) error {
+		return services.ApproveReturn(tx, ret, req.Note)
+	})
+}
+
+// RejectReturn handles turning down a return.
+// @Summary Reject return
+// @Description Turn down a requested return; its goods may be asked for again in another return
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param decision body models.ReturnDecisionRequest false "Decision"
+// @Success 200 {object} models.OrderReturnResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /returns/{id}/reject [post]
+// @Security BearerAuth
+func RejectReturn(c *fiber.Ctx) error {
+	var req models.ReturnDecisionRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return changeReturn(c, func(tx *gorm.DB, ret *models.OrderReturn) error {
+		return services.RejectReturn(tx, ret, req.Note)
+	})
+}
+
+// ReceiveReturn handles the goods of a return arriving back.
+// @Summary Receive return
+// @Description Record the goods of an approved return as arrived and book them back into stock of the given warehouse, by default the one that fulfilled the order, unless restock is false
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param receipt body models.ReturnReceiptRequest false "Receipt"
+// @Success 200 {object} models.OrderReturnResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /returns/{id}/receive [post]
+// @Security BearerAuth
+func ReceiveReturn(c *fiber.Ctx) error {
+	var req models.ReturnReceiptRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return changeReturn(c, func(tx *gorm.DB, ret *models.OrderReturn) error {
+		return services.ReceiveReturn(tx, ret, req, currentUserID(c))
+	})
+}
+
+// RefundReturn handles refunding a return.
+// @Summary Refund return
+// @Description Issue a credit note against the order for a received return, by default for the value of the returned goods with taxes; a smaller amount may be given but not a larger one. It is paid back through the captured payments of the order. The refund cannot exceed what was captured less earlier refunds, and credit notes never add up to more than the order total.
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param refund body models.ReturnRefundRequest false "Refund"
+// @Success 200 {object} models.OrderReturnResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 402 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 502 {object} map[string]interface{}
+// @Router /returns/{id}/refund [post]
+// @Security BearerAuth
+func RefundReturn(c *fiber.Ctx) error {
+	var req models.ReturnRefundRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return changeReturn(c, func(tx *gorm.DB, ret *models.OrderReturn) error {
+		return services.RefundReturn(c.Context(), tx, payment.Default, ret, req.Amount)
+	})
+}
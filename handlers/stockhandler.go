@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func stockMovementResponse(movement models.StockMovement) models.StockMovementResponse {
+	return models.StockMovementResponse{
+		ID:              movement.ID,
+		WarehouseID:     movement.WarehouseID,
+		ProductID:       movement.ProductID,
+		VariantID:       movement.VariantID,
+		Type:            movement.Type,
+		Quantity:        movement.Quantity,
+		BalanceAfter:    movement.BalanceAfter,
+		ReasonCode:      movement.ReasonCode,
+		Note:            movement.Note,
+		OrderID:         movement.OrderID,
+		PurchaseOrderID: movement.PurchaseOrderID,
+		StockTransferID: movement.StockTransferID,
+		UserID:          movement.UserID,
+		OccurredAt:      movement.OccurredAt,
+	}
+}
+
+// stockErrorStatus maps stock and purchasing service errors to HTTP status codes.
+func stockErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrInsufficientStock), errors.Is(err, services.ErrInvalidTransition):
+		return fiber.StatusConflict
+	case errors.Is(err, services.ErrUnknownStrategy), errors.Is(err, services.ErrInvalidReason),
+		errors.Is(err, services.ErrUnknownItem), errors.Is(err, services.ErrOverReceipt), errors.Is(err, services.ErrNoItems),
+		errors.Is(err, services.ErrVariantMismatch), errors.Is(err, services.ErrDuplicateOption),
+		errors.Is(err, services.ErrUnknownWarehouse), errors.Is(err, services.ErrZeroQuantity),
+		errors.Is(err, services.ErrUnknownProduct), errors.Is(err, services.ErrUnknownVariant):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// CreateStockAdjustment handles booking a manual stock adjustment.
+// @Summary Create a stock adjustment
+// @Description Add or remove stock of a product in a warehouse with a reason code (stock_count, damaged, lost, found, expired, other). The quantity must not be zero; an unknown warehouse, product or variant is rejected with 400.
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param adjustment body models.StockAdjustmentRequest true "Adjustment data"
+// @Success 201 {object} models.StockMovementResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /stock/adjustments [post]
+// @Security BearerAuth
+func CreateStockAdjustment(c *fiber.Ctx) error {
+	db := database.DB
+	var req models.StockAdjustmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var movement *models.StockMovement
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		movement, err = services.Adjust(tx, req, currentUserID(c))
+		return err
+	})
+	if err != nil {
+		return c.Status(stockErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(stockMovementResponse(*movement))
+}
+
+// GetStockMovements handles retrieving the stock movement ledger.
+// @Summary Get stock movements
+// @Description Retrieve ledger entries, optionally filtered by product, warehouse, type and period
+// @Tags Stock
+// @Produce json
+// @Param product_id query int false "Product ID"
+// @Param variant_id query int false "Variant ID"
+// @Param warehouse_id query int false "Warehouse ID"
+// @Param type query string false "Movement type (receipt, sale, return, adjustment, transfer)"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD), inclusive"
+// @Success 200 {array} models.StockMovementResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /stock/movements [get]
+// @Security BearerAuth
+func GetStockMovements(c *fiber.Ctx) error {
+	db := database.DB
+	query := db.Order("occurred_at, id")
+	if productID := c.QueryInt("product_id"); productID != 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+	if variantID := c.QueryInt("variant_id"); variantID != 0 {
+		query = query.Where("variant_id = ?", variantID)
+	}
+	if warehouseID := c.QueryInt("warehouse_id"); warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	if movementType := c.Query("type"); movementType != "" {
+		query = query.Where("type = ?", movementType)
+	}
+	if from := c.Query("from"); from != "" {
+		date, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid from date, expected YYYY-MM-DD",
+			})
+		}
+		query = query.Where("occurred_at >= ?", date)
+	}
+	if to := c.Query("to"); to != "" {
+		date, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid to date, expected YYYY-MM-DD",
+			})
+		}
+		query = query.Where("occurred_at < ?", date.AddDate(0, 0, 1))
+	}
+
+	var movements []models.StockMovement
+	if err := query.Find(&movements).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.StockMovementResponse, 0, len(movements))
+	for _, movement := range movements {
+		response = append(response, stockMovementResponse(movement))
+	}
+
+	return c.JSON(response)
+}
+
+// GetStockOnDate handles reconstructing stock levels at a past date.
+// @Summary Get stock on date
+// @Description Reconstruct the stock of each product at the end of the given date from the movement ledger
+// @Tags Stock
+// @Produce json
+// @Param date query string true "Date (YYYY-MM-DD)"
+// @Param product_id query int false "Product ID"
+// @Param warehouse_id query int false "Warehouse ID"
+// @Param by_warehouse query bool false "Split the result per warehouse"
+// @Success 200 {array} models.StockOnDateResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /stock/reports/on-date [get]
+// @Security BearerAuth
+func GetStockOnDate(c *fiber.Ctx) error {
+	db := database.DB
+	date, err := time.ParseInLocation("2006-01-02", c.Query("date"), time.Local)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date, expected YYYY-MM-DD",
+		})
+	}
+	// Akhir hari: semua pergerakan sebelum tengah malam berikutnya.
+	at := date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+
+	report, err := services.StockOnDate(db, at, uint(c.QueryInt("product_id")), uint(c.QueryInt("warehouse_id")), c.QueryBool("by_warehouse"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// GetLowStockAlerts handles retrieving low stock alerts.
+// @Summary Get low stock alerts
+// @Description Retrieve alerts raised by the periodic low stock check
+// @Tags Stock
+// @Produce json
+// @Param status query string false "Alert status (open, resolved)"
+// @Param supplier_id query int false "Supplier ID"
+// @Success 200 {array} models.LowStockAlertResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /stock/alerts [get]
+// @Security BearerAuth
+func GetLowStockAlerts(c *fiber.Ctx) error {
+	db := database.DB
+	query := db.Order("detected_at DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if supplierID := c.QueryInt("supplier_id"); supplierID != 0 {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+
+	var alerts []models.LowStockAlert
+	if err := query.Find(&alerts).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.LowStockAlertResponse, 0, len(alerts))
+	for _, alert := range alerts {
+		response = append(response, models.LowStockAlertResponse{
+			ID:           alert.ID,
+			ProductID:    alert.ProductID,
+			SupplierID:   alert.SupplierID,
+			OnHand:       alert.OnHand,
+			ReorderPoint: alert.ReorderPoint,
+			Status:       alert.Status,
+			DetectedAt:   alert.DetectedAt,
+			ResolvedAt:   alert.ResolvedAt,
+		})
+	}
+
+	return c.JSON(response)
+}
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func supplierContactResponse(contact models.SupplierContact) models.SupplierContactResponse {
+	return models.SupplierContactResponse{
+		ID:         contact.ID,
+		SupplierID: contact.SupplierID,
+		Name:       contact.Name,
+		Position:   contact.Position,
+		Email:      contact.Email,
+		Phone:      contact.Phone,
+		Primary:    contact.IsPrimary,
+	}
+}
+
+func supplierAddressResponse(address models.SupplierAddress) models.SupplierAddressResponse {
+	return models.SupplierAddressResponse{
+		ID:         address.ID,
+		SupplierID: address.SupplierID,
+		Type:       address.Type,
+		Street:     address.Street,
+		City:       address.City,
+		Province:   address.Province,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+}
+
+// saveSupplierContact stores a contact; a primary contact demotes the others.
+func saveSupplierContact(contact *models.SupplierContact) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if contact.IsPrimary {
+			err := tx.Model(&models.SupplierContact{}).
+				Where("supplier_id = ? AND id <> ?", contact.SupplierID, contact.ID).
+				Update("is_primary", false).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Save(contact).Error
+	})
+}
+
+// GetSupplierContacts handles retrieving the contacts of a supplier.
+// @Summary Get supplier contacts
+// @Description Retrieve the contact persons of a supplier
+// @Tags Supplier
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Success 200 {array} models.SupplierContactResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/contacts [get]
+// @Security BearerAuth
+func GetSupplierContacts(c *fiber.Ctx) error {
+	db := database.DB
+	var supplier models.Supplier
+	if err := db.First(&supplier, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+
+	var contacts []models.SupplierContact
+	if err := db.Where("supplier_id = ?", supplier.ID).Order("is_primary DESC, name").Find(&contacts).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.SupplierContactResponse, 0, len(contacts))
+	for _, contact := range contacts {
+		response = append(response, supplierContactResponse(contact))
+	}
+
+	return c.JSON(response)
+}
+
+// CreateSupplierContact handles adding a contact to a supplier.
+// @Summary Create supplier contact
+// @Description Add a contact person to a supplier
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param contact body models.SupplierContactRequest true "Contact data"
+// @Success 201 {object} models.SupplierContactResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/contacts [post]
+// @Security BearerAuth
+func CreateSupplierContact(c *fiber.Ctx) error {
+	db := database.DB
+	var req models.SupplierContactRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Name is required",
+		})
+	}
+
+	var supplier models.Supplier
+	if err := db.First(&supplier, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+
+	contact := models.SupplierContact{
+		SupplierID: supplier.ID,
+		Name:       req.Name,
+		Position:   req.Position,
+		Email:      req.Email,
+		Phone:      req.Phone,
+		IsPrimary:  req.Primary,
+	}
+	if err := saveSupplierContact(&contact); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(supplierContactResponse(contact))
+}
+
+// UpdateSupplierContact handles updating a supplier contact.
+// @Summary Update supplier contact
+// @Description Update a contact person of a supplier
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param contactId path string true "Contact ID"
+// @Param contact body models.SupplierContactRequest true "Contact data"
+// @Success 200 {object} models.SupplierContactResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/contacts/{contactId} [put]
+// @Security BearerAuth
+func UpdateSupplierContact(c *fiber.Ctx) error {
+	db := database.DB
+	var req models.SupplierContactRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Name is required",
+		})
+	}
+
+	var contact models.SupplierContact
+	if err := db.Where("supplier_id = ?", c.Params("id")).First(&contact, c.Params("contactId")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Contact not found",
+		})
+	}
+
+	contact.Name = req.Name
+	contact.Position = req.Position
+	contact.Email = req.Email
+	contact.Phone = req.Phone
+	contact.IsPrimary = req.Primary
+	if err := saveSupplierContact(&contact); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(supplierContactResponse(contact))
+}
+
+// DeleteSupplierContact handles deleting a supplier contact.
+// @Summary Delete supplier contact
+// @Description Delete a contact person of a supplier
+// @Tags Supplier
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param contactId path string true "Contact ID"
+// @Success 204 {object} nil
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/contacts/{contactId} [delete]
+// @Security BearerAuth
+func DeleteSupplierContact(c *fiber.Ctx) error {
+	db := database.DB
+	if err := db.Where("supplier_id = ?", c.Params("id")).Delete(&models.SupplierContact{}, c.Params("contactId")).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// applySupplierAddressRequest validates the request and copies it onto address.
+func applySupplierAddressRequest(address *models.SupplierAddress, req models.SupplierAddressRequest) string {
+	if req.Street == "" || req.City == "" {
+		return "Street and city are required"
+	}
+	switch req.Type {
+	case "":
+		req.Type = "office"
+	case "office", "billing", "pickup":
+	default:
+		return "Type must be office, billing or pickup"
+	}
+	if req.Country == "" {
+		req.Country = "ID"
+	}
+	address.Type = req.Type
+	address.Street = req.Street
+	address.City = req.City
+	address.Province = req.Province
+	address.PostalCode = req.PostalCode
+	address.Country = req.Country
+	return ""
+}
+
+// GetSupplierAddresses handles retrieving the addresses of a supplier.
+// @Summary Get supplier addresses
+// @Description Retrieve the addresses of a supplier
+// @Tags Supplier
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Success 200 {array} models.SupplierAddressResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/addresses [get]
+// @Security BearerAuth
+func GetSupplierAddresses(c *fiber.Ctx) error {
+	db := database.DB
+	var supplier models.Supplier
+	if err := db.First(&supplier, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+
+	var addresses []models.SupplierAddress
+	if err := db.Where("supplier_id = ?", supplier.ID).Order("type, id").Find(&addresses).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.SupplierAddressResponse, 0, len(addresses))
+	for _, address := range addresses {
+		response = append(response, supplierAddressResponse(address))
+	}
+
+	return c.JSON(response)
+}
+
+// CreateSupplierAddress handles adding an address to a supplier.
+// @Summary Create supplier address
+// @Description Add an office, billing or pickup address to a supplier
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param address body models.SupplierAddressRequest true "Address data"
+// @Success 201 {object} models.SupplierAddressResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/addresses [post]
+// @Security BearerAuth
+func CreateSupplierAddress(c *fiber.Ctx) error {
+	db := database.DB
+	var req models.SupplierAddressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var supplier models.Supplier
+	if err := db.First(&supplier, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+
+	address := models.SupplierAddress{SupplierID: supplier.ID}
+	if msg := applySupplierAddressRequest(&address, req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+	if err := db.Create(&address).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(supplierAddressResponse(address))
+}
+
+// UpdateSupplierAddress handles updating a supplier address.
+// @Summary Update supplier address
+// @Description Update an address of a supplier
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param addressId path string true "Address ID"
+// @Param address body models.SupplierAddressRequest true "Address data"
+// @Success 200 {object} models.SupplierAddressResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/addresses/{addressId} [put]
+// @Security BearerAuth
+func UpdateSupplierAddress(c *fiber.Ctx) error {
+	db := database.DB
+	var req models.SupplierAddressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var address models.SupplierAddress
+	if err := db.Where("supplier_id = ?", c.Params("id")).First(&address, c.Params("addressId")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Address not found",
+		})
+	}
+	if msg := applySupplierAddressRequest(&address, req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+	if err := db.Save(&address).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(supplierAddressResponse(address))
+}
+
+// DeleteSupplierAddress handles deleting a supplier address.
+// @Summary Delete supplier address
+// @Description Delete an address of a supplier
+// @Tags Supplier
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param addressId path string true "Address ID"
+// @Success 204 {object} nil
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/addresses/{addressId} [delete]
+// @Security BearerAuth
+func DeleteSupplierAddress(c *fiber.Ctx) error {
+	db := database.DB
+	if err := db.Where("supplier_id = ?", c.Params("id")).Delete(&models.SupplierAddress{}, c.Params("addressId")).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
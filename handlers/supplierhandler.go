@@ -3,7 +3,10 @@ package handlers
 import (
 	"github.com/DewiKresnawati/DewiWebService/database"
 	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
 	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // CreateSupplier handles creating a new supplier.
@@ -27,8 +30,11 @@ func CreateSupplier(c *fiber.Ctx) error {
 	}
 
 	supplier := models.Supplier{
-		Name:  req.Name,
-		Email: req.Email,
+		Name:            req.Name,
+		Email:           req.Email,
+		Phone:           req.Phone,
+		TaxID:           req.TaxID,
+		PaymentTermDays: req.PaymentTermDays,
 	}
 
 	if err := db.Create(&supplier).Error; err != nil {
@@ -42,10 +48,11 @@ func CreateSupplier(c *fiber.Ctx) error {
 
 // GetAllSuppliers handles retrieving all suppliers.
 // @Summary Get all suppliers
-// @Description Retrieve all suppliers
+// @Description Retrieve all suppliers, optionally searching by name or email
 // @Tags Supplier
 // @Accept json
 // @Produce json
+// @Param q query string false "Part of the name or email"
 // @Success 200 {array} models.SupplierResponse
 // @Failure 500 {object} map[string]interface{}
 // @Router /suppliers [get]
@@ -53,7 +60,7 @@ func CreateSupplier(c *fiber.Ctx) error {
 func GetAllSuppliers(c *fiber.Ctx) error {
 	db := database.DB
 	var suppliers []models.Supplier
-	if err := db.Find(&suppliers).Error; err != nil {
+	if err := filterSuppliers(c, db).Find(&suppliers).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -62,6 +69,14 @@ func GetAllSuppliers(c *fiber.Ctx) error {
 	return c.JSON(suppliers)
 }
 
+// filterSuppliers applies the supplier list query parameters to query.
+func filterSuppliers(c *fiber.Ctx, query *gorm.DB) *gorm.DB {
+	if q := c.Query("q"); q != "" {
+		query = query.Where("name LIKE ? OR email LIKE ?", "%"+q+"%", "%"+q+"%")
+	}
+	return query
+}
+
 // GetSupplierByID handles retrieving a supplier by its ID.
 // @Summary Get supplier by ID
 // @Description Retrieve a supplier by its ID
@@ -77,7 +92,7 @@ func GetSupplierByID(c *fiber.Ctx) error {
 	db := database.DB
 	id := c.Params("id")
 	var supplier models.Supplier
-	if err := db.First(&supplier, id).Error; err != nil {
+	if err := db.Preload("Contacts").Preload("Addresses").First(&supplier, id).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Supplier not found",
 		})
@@ -118,6 +133,9 @@ func UpdateSupplier(c *fiber.Ctx) error {
 
 	supplier.Name = req.Name
 	supplier.Email = req.Email
+	supplier.Phone = req.Phone
+	supplier.TaxID = req.TaxID
+	supplier.PaymentTermDays = req.PaymentTermDays
 
 	if err := db.Save(&supplier).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -150,3 +168,146 @@ func DeleteSupplier(c *fiber.Ctx) error {
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// GetReorderSuggestions handles building a draft purchase order for a supplier.
+// @Summary Get reorder suggestions
+// @Description Group the supplier's products that are at or below their reorder point, net of open purchase orders, into a draft purchase order
+// @Tags Supplier
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Success 200 {object} models.ReorderSuggestionResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/reorder-suggestions [get]
+// @Security BearerAuth
+func GetReorderSuggestions(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var supplier models.Supplier
+	if err := db.First(&supplier, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+
+	products, err := services.LowStockProducts(db, supplier.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := models.ReorderSuggestionResponse{
+		SupplierID: supplier.ID,
+		Items:      make([]models.ReorderSuggestionItem, 0, len(products)),
+	}
+	for _, product := range products {
+		if product.SuggestedQuantity() == 0 {
+			continue
+		}
+		response.Items = append(response.Items, models.ReorderSuggestionItem{
+			ProductID:         product.ProductID,
+			ProductName:       product.Name,
+			OnHand:            product.OnHand,
+			OnOrder:           product.OnOrder,
+			ReorderPoint:      product.ReorderPoint,
+			ReorderQuantity:   product.ReorderQuantity,
+			SuggestedQuantity: product.SuggestedQuantity(),
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// CreateSupplierUser handles creating a supplier portal login.
+// @Summary Create supplier portal user
+// @Description Create a user that can log in to the supplier portal on behalf of the supplier
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param user body models.SupplierUserRequest true "User data"
+// @Success 201 {object} models.SupplierUserResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/users [post]
+// @Security BearerAuth
+func CreateSupplierUser(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var req models.SupplierUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if req.Username == "" || req.Password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username and password are required",
+		})
+	}
+
+	var supplier models.Supplier
+	if err := db.First(&supplier, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	user := models.User{
+		Username:   req.Username,
+		Password:   string(hashedPassword),
+		Role:       models.RoleSupplier,
+		SupplierID: &supplier.ID,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.SupplierUserResponse{
+		ID:         user.ID,
+		Username:   user.Username,
+		SupplierID: supplier.ID,
+	})
+}
+
+// GetSupplierUsers handles retrieving the portal users of a supplier.
+// @Summary Get supplier portal users
+// @Description Retrieve the users that can log in to the supplier portal for the supplier
+// @Tags Supplier
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Success 200 {array} models.SupplierUserResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/users [get]
+// @Security BearerAuth
+func GetSupplierUsers(c *fiber.Ctx) error {
+	db := database.DB
+	var users []models.User
+	if err := db.Where("role = ? AND supplier_id = ?", models.RoleSupplier, c.Params("id")).Find(&users).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.SupplierUserResponse, 0, len(users))
+	for _, user := range users {
+		response = append(response, models.SupplierUserResponse{
+			ID:         user.ID,
+			Username:   user.Username,
+			SupplierID: *user.SupplierID,
+		})
+	}
+
+	return c.JSON(response)
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func supplierPriceResponse(price models.SupplierPrice) models.SupplierPriceResponse {
+	return models.SupplierPriceResponse{
+		ID:               price.ID,
+		SupplierID:       price.SupplierID,
+		ProductID:        price.ProductID,
+		UnitCost:         price.UnitCost,
+		MinOrderQuantity: price.MinOrderQuantity,
+		ValidFrom:        price.ValidFrom,
+		ValidTo:          price.ValidTo,
+	}
+}
+
+// applySupplierPriceRequest validates the request and copies it onto price.
+func applySupplierPriceRequest(price *models.SupplierPrice, req models.SupplierPriceRequest) string {
+	if req.UnitCost.IsNegative() {
+		return "Unit cost must not be negative"
+	}
+	validFrom := time.Now().Truncate(24 * time.Hour)
+	if req.ValidFrom != "" {
+		date, err := time.ParseInLocation("2006-01-02", req.ValidFrom, time.Local)
+		if err != nil {
+			return "Invalid valid_from date, expected YYYY-MM-DD"
+		}
+		validFrom = date
+	}
+	var validTo *time.Time
+	if req.ValidTo != "" {
+		date, err := time.ParseInLocation("2006-01-02", req.ValidTo, time.Local)
+		if err != nil {
+			return "Invalid valid_to date, expected YYYY-MM-DD"
+		}
+		// Inklusif sampai akhir hari.
+		end := date.AddDate(0, 0, 1).Add(-time.Second)
+		if end.Before(validFrom) {
+			return "valid_to must not be before valid_from"
+		}
+		validTo = &end
+	}
+
+	price.ProductID = req.ProductID
+	price.UnitCost = req.UnitCost
+	price.MinOrderQuantity = req.MinOrderQuantity
+	if price.MinOrderQuantity == 0 {
+		price.MinOrderQuantity = 1
+	}
+	price.ValidFrom = validFrom
+	price.ValidTo = validTo
+	return ""
+}
+
+// CreateSupplierPrice handles adding a price list entry for a supplier.
+// @Summary Create supplier price
+// @Description Add a cost price with minimum order quantity and validity period to a supplier's price list
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param price body models.SupplierPriceRequest true "Price data"
+// @Success 201 {object} models.SupplierPriceResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/prices [post]
+// @Security BearerAuth
+func CreateSupplierPrice(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var req models.SupplierPriceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var supplier models.Supplier
+	if err := db.First(&supplier, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+
+	price := models.SupplierPrice{SupplierID: supplier.ID}
+	if msg := applySupplierPriceRequest(&price, req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if err := db.Create(&price).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(supplierPriceResponse(price))
+}
+
+// GetSupplierPrices handles retrieving a supplier's price list.
+// @Summary Get supplier prices
+// @Description Retrieve a supplier's price list, optionally only the entries valid on a date
+// @Tags Supplier
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param product_id query int false "Product ID"
+// @Param valid_on query string false "Date (YYYY-MM-DD)"
+// @Success 200 {array} models.SupplierPriceResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/prices [get]
+// @Security BearerAuth
+func GetSupplierPrices(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var supplier models.Supplier
+	if err := db.First(&supplier, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+
+	query := db.Where("supplier_id = ?", supplier.ID).Order("product_id, valid_from")
+	if productID := c.QueryInt("product_id"); productID != 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+	if validOn := c.Query("valid_on"); validOn != "" {
+		date, err := time.ParseInLocation("2006-01-02", validOn, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid valid_on date, expected YYYY-MM-DD",
+			})
+		}
+		query = query.Where("valid_from <= ? AND (valid_to IS NULL OR valid_to >= ?)", date.AddDate(0, 0, 1).Add(-time.Second), date)
+	}
+
+	var prices []models.SupplierPrice
+	if err := query.Find(&prices).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.SupplierPriceResponse, 0, len(prices))
+	for _, price := range prices {
+		response = append(response, supplierPriceResponse(price))
+	}
+
+	return c.JSON(response)
+}
+
+// UpdateSupplierPrice handles updating a supplier price list entry.
+// @Summary Update supplier price
+// @Description Update an entry of a supplier's price list
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param priceId path string true "Price ID"
+// @Param price body models.SupplierPriceRequest true "Price data"
+// @Success 200 {object} models.SupplierPriceResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/prices/{priceId} [put]
+// @Security BearerAuth
+func UpdateSupplierPrice(c *fiber.Ctx) error {
+	db := database.DB
+	var req models.SupplierPriceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var price models.SupplierPrice
+	if err := db.Where("supplier_id = ?", c.Params("id")).First(&price, c.Params("priceId")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier price not found",
+		})
+	}
+	if msg := applySupplierPriceRequest(&price, req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if err := db.Save(&price).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(supplierPriceResponse(price))
+}
+
+// DeleteSupplierPrice handles deleting a supplier price list entry.
+// @Summary Delete supplier price
+// @Description Delete an entry of a supplier's price list
+// @Tags Supplier
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param priceId path string true "Price ID"
+// @Success 204 {object} nil
+// @Failure 500 {object} map[string]interface{}
+// @Router /suppliers/{id}/prices/{priceId} [delete]
+// @Security BearerAuth
+func DeleteSupplierPrice(c *fiber.Ctx) error {
+	db := database.DB
+	if err := db.Where("supplier_id = ?", c.Params("id")).Delete(&models.SupplierPrice{}, c.Params("priceId")).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
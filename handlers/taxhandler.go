@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func taxRateResponse(rate models.TaxRate) models.TaxRateResponse {
+	return models.TaxRateResponse{
+		ID:         rate.ID,
+		TaxClassID: rate.TaxClassID,
+		Region:     rate.Region,
+		Name:       rate.Name,
+		Rate:       rate.Rate,
+	}
+}
+
+func taxClassResponse(class models.TaxClass) models.TaxClassResponse {
+	rates := make([]models.TaxRateResponse, 0, len(class.Rates))
+	for _, rate := range class.Rates {
+		rates = append(rates, taxRateResponse(rate))
+	}
+	return models.TaxClassResponse{
+		ID:          class.ID,
+		Name:        class.Name,
+		Description: class.Description,
+		Default:     class.IsDefault,
+		Rates:       rates,
+	}
+}
+
+// taxErrorStatus maps tax errors to HTTP status codes.
+func taxErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrInvalidTaxRate), errors.Is(err, services.ErrUnknownTaxClass):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrTaxClassInUse):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// GetTaxClasses handles listing tax classes.
+// @Summary Get tax classes
+// @Description Retrieve all tax classes with their rates
+// @Tags Taxes
+// @Produce json
+// @Success 200 {array} models.TaxClassResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /tax-classes [get]
+// @Security BearerAuth
+func GetTaxClasses(c *fiber.Ctx) error {
+	var classes []models.TaxClass
+	err := database.DB.Preload("Rates", func(db *gorm.DB) *gorm.DB {
+		return db.Order("region, id")
+	}).Order("name").Find(&classes).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.TaxClassResponse, 0, len(classes))
+	for _, class := range classes {
+		response = append(response, taxClassResponse(class))
+	}
+	return c.JSON(response)
+}
+
+// GetTaxClass handles getting a tax class by ID.
+// @Summary Get tax class by ID
+// @Description Retrieve a tax class with its rates
+// @Tags Taxes
+// @Produce json
+// @Param id path int true "Tax class ID"
+// @Success 200 {object} models.TaxClassResponse
+// @Failure 404 {object} map[string]interface{}
+// @Router /tax-classes/{id} [get]
+// @Security BearerAuth
+func GetTaxClass(c *fiber.Ctx) error {
+	var class models.TaxClass
+	err := database.DB.Preload("Rates", func(db *gorm.DB) *gorm.DB {
+		return db.Order("region, id")
+	}).First(&class, c.Params("id")).Error
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tax class not found",
+		})
+	}
+
+	return c.JSON(taxClassResponse(class))
+}
+
+// CreateTaxClass handles creating a tax class.
+// @Summary Create tax class
+// @Description Create a tax class. The default class applies to products whose product and categories have no class of their own.
+// @Tags Taxes
+// @Accept json
+// @Produce json
+// @Param class body models.TaxClassRequest true "Tax class"
+// @Success 201 {object} models.TaxClassResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tax-classes [post]
+// @Security BearerAuth
+func CreateTaxClass(c *fiber.Ctx) error {
+	var req models.TaxClassRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Name is required",
+		})
+	}
+
+	class := models.TaxClass{Name: req.Name, Description: req.Description}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&class).Error; err != nil {
+			return err
+		}
+		if req.Default {
+			return services.SetDefaultTaxClass(tx, &class)
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(taxErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(taxClassResponse(class))
+}
+
+// UpdateTaxClass handles updating a tax class.
+// @Summary Update tax class
+// @Description Update the name and description of a tax class, or make it the default class
+// @Tags Taxes
+// @Accept json
+// @Produce json
+// @Param id path int true "Tax class ID"
+// @Param class body models.TaxClassRequest true "Tax class"
+// @Success 200 {object} models.TaxClassResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tax-classes/{id} [put]
+// @Security BearerAuth
+func UpdateTaxClass(c *fiber.Ctx) error {
+	var req models.TaxClassRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Name is required",
+		})
+	}
+
+	db := database.DB
+	var class models.TaxClass
+	if err := db.Preload("Rates").First(&class, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tax class not found",
+		})
+	}
+
+	class.Name = req.Name
+	class.Description = req.Description
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Rates").Save(&class).Error; err != nil {
+			return err
+		}
+		if req.Default && !class.IsDefault {
+			return services.SetDefaultTaxClass(tx, &class)
+		}
+		if !req.Default && class.IsDefault {
+			class.IsDefault = false
+			return tx.Model(&class).UpdateColumn("is_default", false).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(taxErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(taxClassResponse(class))
+}
+
+// DeleteTaxClass handles deleting a tax class.
+// @Summary Delete tax class
+// @Description Delete a tax class and its rates. Classes still assigned to products or categories cannot be deleted.
+// @Tags Taxes
+// @Produce json
+// @Param id path int true "Tax class ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tax-classes/{id} [delete]
+// @Security BearerAuth
+func DeleteTaxClass(c *fiber.Ctx) error {
+	db := database.DB
+	var class models.TaxClass
+	if err := db.First(&class, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tax class not found",
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.DeleteTaxClass(tx, &class)
+	})
+	if err != nil {
+		return c.Status(taxErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// CreateTaxRate handles adding a rate to a tax class.
+// @Summary Create tax rate
+// @Description Add a rate to a tax class for a region: an ISO 3166 country code such as ID, a subdivision such as ID-JK, or empty for every region. Orders use the rates of the most specific matching region.
+// @Tags Taxes
+// @Accept json
+// @Produce json
+// @Param id path int true "Tax class ID"
+// @Param rate body models.TaxRateRequest true "Tax rate"
+// @Success 201 {object} models.TaxRateResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tax-classes/{id}/rates [post]
+// @Security BearerAuth
+func CreateTaxRate(c *fiber.Ctx) error {
+	var req models.TaxRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	db := database.DB
+	var class models.TaxClass
+	if err := db.First(&class, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tax class not found",
+		})
+	}
+
+	rate := models.TaxRate{TaxClassID: class.ID, Region: req.Region, Name: req.Name, Rate: req.Rate}
+	if err := services.ValidateTaxRate(&rate); err != nil {
+		return c.Status(taxErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := db.Create(&rate).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(taxRateResponse(rate))
+}
+
+// UpdateTaxRate handles updating a tax rate.
+// @Summary Update tax rate
+// @Description Update the region, name and percentage of a tax rate. Orders already placed keep the taxes they were priced with.
+// @Tags Taxes
+// @Accept json
+// @Produce json
+// @Param id path int true "Tax rate ID"
+// @Param rate body models.TaxRateRequest true "Tax rate"
+// @Success 200 {object} models.TaxRateResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tax-rates/{id} [put]
+// @Security BearerAuth
+func UpdateTaxRate(c *fiber.Ctx) error {
+	var req models.TaxRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	db := database.DB
+	var rate models.TaxRate
+	if err := db.First(&rate, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tax rate not found",
+		})
+	}
+
+	rate.Region = req.Region
+	rate.Name = req.Name
+	rate.Rate = req.Rate
+	if err := services.ValidateTaxRate(&rate); err != nil {
+		return c.Status(taxErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := db.Save(&rate).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(taxRateResponse(rate))
+}
+
+// DeleteTaxRate handles deleting a tax rate.
+// @Summary Delete tax rate
+// @Description Delete a tax rate. Orders already placed keep the taxes they were priced with.
+// @Tags Taxes
+// @Produce json
+// @Param id path int true "Tax rate ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tax-rates/{id} [delete]
+// @Security BearerAuth
+func DeleteTaxRate(c *fiber.Ctx) error {
+	db := database.DB
+	var rate models.TaxRate
+	if err := db.First(&rate, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tax rate not found",
+		})
+	}
+	if err := db.Delete(&rate).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func transferResponse(transfer models.StockTransfer) models.StockTransferResponse {
+	items := make([]models.StockTransferItemResponse, 0, len(transfer.Items))
+	for _, item := range transfer.Items {
+		items = append(items, models.StockTransferItemResponse{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+	return models.StockTransferResponse{
+		ID:              transfer.ID,
+		FromWarehouseID: transfer.FromWarehouseID,
+		ToWarehouseID:   transfer.ToWarehouseID,
+		Status:          transfer.Status,
+		Note:            transfer.Note,
+		ShippedAt:       transfer.ShippedAt,
+		ReceivedAt:      transfer.ReceivedAt,
+		Items:           items,
+	}
+}
+
+// CreateTransfer handles creating a new stock transfer.
+// @Summary Create a new stock transfer
+// @Description Create a draft transfer of goods between two warehouses
+// @Tags Transfers
+// @Accept json
+// @Produce json
+// @Param transfer body models.StockTransferRequest true "Transfer data"
+// @Success 201 {object} models.StockTransferResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /transfers [post]
+// @Security BearerAuth
+func CreateTransfer(c *fiber.Ctx) error {
+	db := database.DB
+	var req models.StockTransferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if req.FromWarehouseID == req.ToWarehouseID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Source and destination warehouse must differ",
+		})
+	}
+	if len(req.Items) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Transfer must contain at least one item",
+		})
+	}
+
+	transfer := models.StockTransfer{
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		Status:          models.TransferStatusDraft,
+		Note:            req.Note,
+	}
+	for _, item := range req.Items {
+		if item.Quantity == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Item quantity must be greater than zero",
+			})
+		}
+		transfer.Items = append(transfer.Items, models.StockTransferItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range transfer.Items {
+			if err := services.CheckVariant(tx, item.ProductID, item.VariantID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&transfer).Error
+	})
+	if err != nil {
+		return c.Status(stockErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(transferResponse(transfer))
+}
+
+// GetAllTransfers handles retrieving all stock transfers.
+// @Summary Get all stock transfers
+// @Description Retrieve all stock transfers, optionally filtered by status
+// @Tags Transfers
+// @Produce json
+// @Param status query string false "Transfer status"
+// @Success 200 {array} models.StockTransferResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /transfers [get]
+// @Security BearerAuth
+func GetAllTransfers(c *fiber.Ctx) error {
+	db := database.DB
+	query := db.Preload("Items").Order("id DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var transfers []models.StockTransfer
+	if err := query.Find(&transfers).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.StockTransferResponse, 0, len(transfers))
+	for _, transfer := range transfers {
+		response = append(response, transferResponse(transfer))
+	}
+
+	return c.JSON(response)
+}
+
+// GetTransferByID handles retrieving a stock transfer by its ID.
+// @Summary Get stock transfer by ID
+// @Description Retrieve a stock transfer by its ID
+// @Tags Transfers
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} models.StockTransferResponse
+// @Failure 404 {object} map[string]interface{}
+// @Router /transfers/{id} [get]
+// @Security BearerAuth
+func GetTransferByID(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var transfer models.StockTransfer
+	if err := db.Preload("Items").First(&transfer, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Transfer not found",
+		})
+	}
+
+	return c.JSON(transferResponse(transfer))
+}
+
+// changeTransfer loads a transfer under lock and applies a workflow step to it.
+func changeTransfer(c *fiber.Ctx, step func(tx *gorm.DB, transfer *models.StockTransfer, userID *uint) error) error {
+	db := database.DB
+	id := c.Params("id")
+	var transfer models.StockTransfer
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&transfer, id).Error; err != nil {
+			return err
+		}
+		return step(tx, &transfer, currentUserID(c))
+	})
+	if err != nil {
+		return c.Status(stockErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(transferResponse(transfer))
+}
+
+// ShipTransfer handles dispatching a stock transfer.
+// @Summary Ship stock transfer
+// @Description Take the goods out of the source warehouse and mark the transfer in transit
+// @Tags Transfers
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} models.StockTransferResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /transfers/{id}/ship [post]
+// @Security BearerAuth
+func ShipTransfer(c *fiber.Ctx) error {
+	return changeTransfer(c, services.ShipTransfer)
+}
+
+// ReceiveTransfer handles receiving a stock transfer.
+// @Summary Receive stock transfer
+// @Description Book the goods in transit into the destination warehouse
+// @Tags Transfers
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} models.StockTransferResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /transfers/{id}/receive [post]
+// @Security BearerAuth
+func ReceiveTransfer(c *fiber.Ctx) error {
+	return changeTransfer(c, services.ReceiveTransfer)
+}
+
+// CancelTransfer handles cancelling a stock transfer.
+// @Summary Cancel stock transfer
+// @Description Cancel a transfer, returning goods in transit to the source warehouse
+// @Tags Transfers
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} models.StockTransferResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /transfers/{id}/cancel [post]
+// @Security BearerAuth
+func CancelTransfer(c *fiber.Ctx) error {
+	return changeTransfer(c, services.CancelTransfer)
+}
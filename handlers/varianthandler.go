@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func optionTypeResponse(optionType models.OptionType) models.OptionTypeResponse {
+	values := make([]models.OptionValueResponse, 0, len(optionType.Values))
+	for _, value := range optionType.Values {
+		values = append(values, models.OptionValueResponse{
+			ID:           value.ID,
+			OptionTypeID: value.OptionTypeID,
+			Value:        value.Value,
+		})
+	}
+	return models.OptionTypeResponse{
+		ID:     optionType.ID,
+		Name:   optionType.Name,
+		Values: values,
+	}
+}
+
+// variantResponses maps variants to responses, filling in their total stock
+// when withStock is set. Variants must have their Product and Options loaded.
+func variantResponses(db *gorm.DB, variants []models.ProductVariant, optionTypes map[uint]string, withStock bool) ([]models.ProductVariantResponse, error) {
+	var stock map[uint]int
+	if withStock {
+		ids := make([]uint, 0, len(variants))
+		for _, variant := range variants {
+			ids = append(ids, variant.ID)
+		}
+		var err error
+		if stock, err = services.VariantStock(db, ids); err != nil {
+			return nil, err
+		}
+	}
+
+	response := make([]models.ProductVariantResponse, 0, len(variants))
+	for _, variant := range variants {
+		options := make([]models.OptionValueResponse, 0, len(variant.Options))
+		for _, option := range variant.Options {
+			options = append(options, models.OptionValueResponse{
+				ID:           option.ID,
+				OptionTypeID: option.OptionTypeID,
+				OptionType:   optionTypes[option.OptionTypeID],
+				Value:        option.Value,
+			})
+		}
+		item := models.ProductVariantResponse{
+			ID:             variant.ID,
+			ProductID:      variant.ProductID,
+			SKU:            variant.SKU,
+			Barcode:        variant.Barcode,
+			Price:          variant.Price,
+			EffectivePrice: variant.EffectivePrice(variant.Product),
+			Options:        options,
+		}
+		if withStock {
+			quantity := stock[variant.ID]
+			item.Stock = &quantity
+		}
+		response = append(response, item)
+	}
+	return response, nil
+}
+
+// optionTypeNames returns the names of all option types by ID.
+func optionTypeNames(db *gorm.DB) (map[uint]string, error) {
+	var optionTypes []models.OptionType
+	if err := db.Find(&optionTypes).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(optionTypes))
+	for _, optionType := range optionTypes {
+		names[optionType.ID] = optionType.Name
+	}
+	return names, nil
+}
+
+// findVariants loads variants with everything variantResponses needs and
+// maps them to responses, with their stock when withStock is set.
+func findVariants(db *gorm.DB, query *gorm.DB, withStock bool) ([]models.ProductVariantResponse, error) {
+	var variants []models.ProductVariant
+	if err := query.Preload("Product").Preload("Options").Order("product_id, sku").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	names, err := optionTypeNames(db)
+	if err != nil {
+		return nil, err
+	}
+	return variantResponses(db, variants, names, withStock)
+}
+
+// variantErrorStatus maps variant errors to HTTP status codes.
+func variantErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrDuplicateOption):
+		return fiber.StatusBadRequest
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// GetOptionTypes handles retrieving all option types with their values.
+// @Summary Get option types
+// @Description Retrieve the option types products vary along, such as size and colour, with their values
+// @Tags Variants
+// @Produce json
+// @Success 200 {array} models.OptionTypeResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /option-types [get]
+// @Security BearerAuth
+func GetOptionTypes(c *fiber.Ctx) error {
+	db := database.DB
+	var optionTypes []models.OptionType
+	err := db.Preload("Values", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Order("name").Find(&optionTypes).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.OptionTypeResponse, 0, len(optionTypes))
+	for _, optionType := range optionTypes {
+		response = append(response, optionTypeResponse(optionType))
+	}
+
+	return c.JSON(response)
+}
+
+// CreateOptionType handles creating an option type.
+// @Summary Create an option type
+// @Description Create an option type, such as size or colour, with its values
+// @Tags Variants
+// @Accept json
+// @Produce json
+// @Param optionType body models.OptionTypeRequest true "Option type data"
+// @Success 201 {object} models.OptionTypeResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /option-types [post]
+// @Security BearerAuth
+func CreateOptionType(c *fiber.Ctx) error {
+	var req models.OptionTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return saveOptionType(c, &models.OptionType{}, req, fiber.StatusCreated)
+}
+
+// UpdateOptionType handles updating an option type.
+// @Summary Update an option type
+// @Description Rename an option type and replace its values. Removed values are detached from their variants.
+// @Tags Variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Option type ID"
+// @Param optionType body models.OptionTypeRequest true "Option type data"
+// @Success 200 {object} models.OptionTypeResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /option-types/{id} [put]
+// @Security BearerAuth
+func UpdateOptionType(c *fiber.Ctx) error {
+	var req models.OptionTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	var optionType models.OptionType
+	if err := database.DB.First(&optionType, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Option type not found",
+		})
+	}
+	return saveOptionType(c, &optionType, req, fiber.StatusOK)
+}
+
+func saveOptionType(c *fiber.Ctx, optionType *models.OptionType, req models.OptionTypeRequest, status int) error {
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Name is required",
+		})
+	}
+	optionType.Name = req.Name
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.SaveOptionType(tx, optionType, req.Values)
+	})
+	if err != nil {
+		return c.Status(variantErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(status).JSON(optionTypeResponse(*optionType))
+}
+
+// DeleteOptionType handles deleting an option type.
+// @Summary Delete an option type
+// @Description Delete an option type and its values, detaching them from variants
+// @Tags Variants
+// @Produce json
+// @Param id path int true "Option type ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /option-types/{id} [delete]
+// @Security BearerAuth
+func DeleteOptionType(c *fiber.Ctx) error {
+	db := database.DB
+	var optionType models.OptionType
+	if err := db.First(&optionType, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Option type not found",
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := services.SaveOptionType(tx, &optionType, nil); err != nil {
+			return err
+		}
+		// Dihapus permanen agar namanya bisa dipakai lagi
+		return tx.Unscoped().Delete(&optionType).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetProductVariants handles retrieving the variants of a product.
+// @Summary Get product variants
+// @Description Retrieve the variants of a product with their options, price and, for staff, stock
+// @Tags Variants
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.ProductVariantResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/variants [get]
+// @Security BearerAuth
+func GetProductVariants(c *fiber.Ctx) error {
+	db := database.DB
+	var product models.Product
+	if err := db.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+
+	response, err := findVariants(db, db.Where("product_id = ?", product.ID), isStaff(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// GetVariants handles listing and searching variants across products.
+// @Summary Get variants
+// @Description List variants of all products, optionally searching by SKU (prefix match) or exact barcode
+// @Tags Variants
+// @Produce json
+// @Param sku query string false "SKU or SKU prefix"
+// @Param barcode query string false "Barcode"
+// @Success 200 {array} models.ProductVariantResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /variants [get]
+// @Security BearerAuth
+func GetVariants(c *fiber.Ctx) error {
+	db := database.DB
+	query := db.Model(&models.ProductVariant{})
+	if sku := c.Query("sku"); sku != "" {
+		query = query.Where("sku LIKE ?", sku+"%")
+	}
+	if barcode := c.Query("barcode"); barcode != "" {
+		query = query.Where("barcode = ?", barcode)
+	}
+
+	response, err := findVariants(db, query, isStaff(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// GetVariantByID handles retrieving a variant by its ID.
+// @Summary Get variant by ID
+// @Description Retrieve a variant with its options, price and, for staff, stock
+// @Tags Variants
+// @Produce json
+// @Param id path int true "Variant ID"
+// @Success 200 {object} models.ProductVariantResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /variants/{id} [get]
+// @Security BearerAuth
+func GetVariantByID(c *fiber.Ctx) error {
+	db := database.DB
+	response, err := findVariants(db, db.Where("id = ?", c.Params("id")), isStaff(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if len(response) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Variant not found",
+		})
+	}
+
+	return c.JSON(response[0])
+}
+
+// CreateProductVariant handles adding a variant to a product.
+// @Summary Create a product variant
+// @Description Add a variant with its own SKU, barcode, optional price override and option values
+// @Tags Variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variant body models.ProductVariantRequest true "Variant data"
+// @Success 201 {object} models.ProductVariantResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/variants [post]
+// @Security BearerAuth
+func CreateProductVariant(c *fiber.Ctx) error {
+	var req models.ProductVariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	var product models.Product
+	if err := database.DB.First(&product, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Product not found",
+		})
+	}
+	return saveVariant(c, &models.ProductVariant{ProductID: product.ID}, req, fiber.StatusCreated)
+}
+
+// UpdateVariant handles updating a variant.
+// @Summary Update a variant
+// @Description Update the SKU, barcode, price override and option values of a variant
+// @Tags Variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Variant ID"
+// @Param variant body models.ProductVariantRequest true "Variant data"
+// @Success 200 {object} models.ProductVariantResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /variants/{id} [put]
+// @Security BearerAuth
+func UpdateVariant(c *fiber.Ctx) error {
+	var req models.ProductVariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	var variant models.ProductVariant
+	if err := database.DB.First(&variant, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Variant not found",
+		})
+	}
+	return saveVariant(c, &variant, req, fiber.StatusOK)
+}
+
+func saveVariant(c *fiber.Ctx, variant *models.ProductVariant, req models.ProductVariantRequest, status int) error {
+	db := database.DB
+	req.SKU = strings.TrimSpace(req.SKU)
+	if req.SKU == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "SKU is required",
+		})
+	}
+	if req.Price != nil && req.Price.IsNegative() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Price must not be negative",
+		})
+	}
+	// Deleted variants keep their SKU reserved so their stock history stays unambiguous
+	var taken int64
+	if err := db.Unscoped().Model(&models.ProductVariant{}).Where("sku = ? AND id <> ?", req.SKU, variant.ID).Count(&taken).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if taken > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "SKU is already in use",
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.SaveVariant(tx, variant, req)
+	})
+	if err != nil {
+		return c.Status(variantErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response, err := findVariants(db, db.Where("id = ?", variant.ID), true)
+	if err != nil || len(response) == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load variant",
+		})
+	}
+
+	return c.Status(status).JSON(response[0])
+}
+
+// DeleteVariant handles deleting a variant.
+// @Summary Delete a variant
+// @Description Delete a variant. Its stock history is kept.
+// @Tags Variants
+// @Produce json
+// @Param id path int true "Variant ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /variants/{id} [delete]
+// @Security BearerAuth
+func DeleteVariant(c *fiber.Ctx) error {
+	db := database.DB
+	var variant models.ProductVariant
+	if err := db.First(&variant, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Variant not found",
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.DeleteVariant(tx, &variant)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/database"
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func warehouseResponse(warehouse models.Warehouse) models.WarehouseResponse {
+	return models.WarehouseResponse{
+		ID:        warehouse.ID,
+		Code:      warehouse.Code,
+		Name:      warehouse.Name,
+		Address:   warehouse.Address,
+		Latitude:  warehouse.Latitude,
+		Longitude: warehouse.Longitude,
+		Priority:  warehouse.Priority,
+		Active:    warehouse.Active,
+	}
+}
+
+// CreateWarehouse handles creating a new warehouse.
+// @Summary Create a new warehouse
+// @Description Create a new warehouse
+// @Tags Warehouses
+// @Accept json
+// @Produce json
+// @Param warehouse body models.WarehouseRequest true "Warehouse data"
+// @Success 201 {object} models.WarehouseResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /warehouses [post]
+// @Security BearerAuth
+func CreateWarehouse(c *fiber.Ctx) error {
+	db := database.DB
+	var req models.WarehouseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	warehouse := models.Warehouse{
+		Code:      req.Code,
+		Name:      req.Name,
+		Address:   req.Address,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		Priority:  req.Priority,
+		Active:    req.Active == nil || *req.Active,
+	}
+
+	if err := db.Create(&warehouse).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(warehouseResponse(warehouse))
+}
+
+// GetAllWarehouses handles retrieving all warehouses.
+// @Summary Get all warehouses
+// @Description Retrieve all warehouses
+// @Tags Warehouses
+// @Produce json
+// @Success 200 {array} models.WarehouseResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /warehouses [get]
+// @Security BearerAuth
+func GetAllWarehouses(c *fiber.Ctx) error {
+	db := database.DB
+	var warehouses []models.Warehouse
+	if err := db.Order("priority, id").Find(&warehouses).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.WarehouseResponse, 0, len(warehouses))
+	for _, warehouse := range warehouses {
+		response = append(response, warehouseResponse(warehouse))
+	}
+
+	return c.JSON(response)
+}
+
+// GetWarehouseByID handles retrieving a warehouse by its ID.
+// @Summary Get warehouse by ID
+// @Description Retrieve a warehouse by its ID
+// @Tags Warehouses
+// @Produce json
+// @Param id path int true "Warehouse ID"
+// @Success 200 {object} models.WarehouseResponse
+// @Failure 404 {object} map[string]interface{}
+// @Router /warehouses/{id} [get]
+// @Security BearerAuth
+func GetWarehouseByID(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var warehouse models.Warehouse
+	if err := db.First(&warehouse, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Warehouse not found",
+		})
+	}
+
+	return c.JSON(warehouseResponse(warehouse))
+}
+
+// UpdateWarehouse handles updating an existing warehouse.
+// @Summary Update warehouse
+// @Description Update an existing warehouse
+// @Tags Warehouses
+// @Accept json
+// @Produce json
+// @Param id path int true "Warehouse ID"
+// @Param warehouse body models.WarehouseRequest true "Warehouse data"
+// @Success 200 {object} models.WarehouseResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /warehouses/{id} [put]
+// @Security BearerAuth
+func UpdateWarehouse(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var req models.WarehouseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var warehouse models.Warehouse
+	if err := db.First(&warehouse, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Warehouse not found",
+		})
+	}
+
+	warehouse.Code = req.Code
+	warehouse.Name = req.Name
+	warehouse.Address = req.Address
+	warehouse.Latitude = req.Latitude
+	warehouse.Longitude = req.Longitude
+	warehouse.Priority = req.Priority
+	if req.Active != nil {
+		warehouse.Active = *req.Active
+	}
+
+	if err := db.Save(&warehouse).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(warehouseResponse(warehouse))
+}
+
+// DeleteWarehouse handles deleting a warehouse.
+// @Summary Delete warehouse
+// @Description Delete a warehouse by its ID; its code may then be given to a new warehouse
+// @Tags Warehouses
+// @Produce json
+// @Param id path int true "Warehouse ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /warehouses/{id} [delete]
+// @Security BearerAuth
+func DeleteWarehouse(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.DeleteWarehouse(tx, id)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Warehouse not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetWarehouseStock handles retrieving the stock held in a warehouse.
+// @Summary Get warehouse stock
+// @Description Retrieve the quantity of every product held in a warehouse
+// @Tags Warehouses
+// @Produce json
+// @Param id path int true "Warehouse ID"
+// @Success 200 {array} models.WarehouseStockResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /warehouses/{id}/stock [get]
+// @Security BearerAuth
+func GetWarehouseStock(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var warehouse models.Warehouse
+	if err := db.First(&warehouse, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Warehouse not found",
+		})
+	}
+
+	var stocks []models.WarehouseStock
+	if err := db.Where("warehouse_id = ?", warehouse.ID).Order("product_id, variant_id").Find(&stocks).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.WarehouseStockResponse, 0, len(stocks))
+	for _, stock := range stocks {
+		response = append(response, models.WarehouseStockResponse{
+			WarehouseID: stock.WarehouseID,
+			ProductID:   stock.ProductID,
+			VariantID:   stock.VariantID,
+			Quantity:    stock.Quantity,
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// SetWarehouseStock handles setting the quantity of a product in a warehouse.
+// @Summary Set warehouse stock
+// @Description Set the on-hand quantity of a product, or of one of its variants, in a warehouse, booking the difference as a stock count adjustment
+// @Tags Warehouses
+// @Accept json
+// @Produce json
+// @Param id path int true "Warehouse ID"
+// @Param stock body models.WarehouseStockRequest true "Stock data"
+// @Success 200 {object} models.WarehouseStockResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /warehouses/{id}/stock [put]
+// @Security BearerAuth
+func SetWarehouseStock(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var req models.WarehouseStockRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if req.Quantity < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Quantity must not be negative",
+		})
+	}
+
+	var warehouse models.Warehouse
+	if err := db.First(&warehouse, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Warehouse not found",
+		})
+	}
+	var product models.Product
+	if err := db.First(&product, req.ProductID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Product not found",
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if req.VariantID != 0 {
+			if _, err := services.OrderVariant(tx, product.ID, req.VariantID); err != nil {
+				return err
+			}
+		}
+		return services.SetStock(tx, warehouse.ID, product.ID, req.VariantID, req.Quantity, currentUserID(c))
+	})
+	if err != nil {
+		return c.Status(stockErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(models.WarehouseStockResponse{
+		WarehouseID: warehouse.ID,
+		ProductID:   product.ID,
+		VariantID:   req.VariantID,
+		Quantity:    req.Quantity,
+	})
+}
+
+// GetProductStock handles retrieving the stock of a product per warehouse.
+// @Summary Get product stock
+// @Description Retrieve the quantity of a product held in each warehouse, split per variant
+// @Tags Products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.WarehouseStockResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /products/{id}/stock [get]
+// @Security BearerAuth
+func GetProductStock(c *fiber.Ctx) error {
+	db := database.DB
+	id := c.Params("id")
+	var product models.Product
+	if err := db.First(&product, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Product not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch product",
+			"error":   err.Error(),
+		})
+	}
+
+	var stocks []models.WarehouseStock
+	if err := db.Where("product_id = ?", product.ID).Order("warehouse_id, variant_id").Find(&stocks).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := make([]models.WarehouseStockResponse, 0, len(stocks))
+	for _, stock := range stocks {
+		response = append(response, models.WarehouseStockResponse{
+			WarehouseID: stock.WarehouseID,
+			ProductID:   stock.ProductID,
+			VariantID:   stock.VariantID,
+			Quantity:    stock.Quantity,
+		})
+	}
+
+	return c.JSON(response)
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"gorm.io/gorm"
+)
+
+// StartProductImport processes a queued product import job in the
+// background, keeping its status up to date.
+func StartProductImport(db *gorm.DB, job models.ImportJob, rows [][]string) {
+	go func() {
+		started := time.Now()
+		job.Status = models.ImportStatusRunning
+		job.StartedAt = &started
+		if err := db.Save(&job).Error; err != nil {
+			log.Println("product import", job.ID, "failed to start:", err)
+			return
+		}
+
+		job.Status = models.ImportStatusCompleted
+		if err := services.RunProductImport(db, &job, rows); err != nil {
+			job.Status = models.ImportStatusFailed
+			job.Error = err.Error()
+		}
+		finished := time.Now()
+		job.FinishedAt = &finished
+		if err := db.Save(&job).Error; err != nil {
+			log.Println("product import", job.ID, "failed to finish:", err)
+		}
+	}()
+}
@@ -0,0 +1,16 @@
+// Package jobs runs the periodic background tasks of the web service.
+package jobs
+
+import (
+	"os"
+	"time"
+)
+
+// IntervalFromEnv reads a duration such as "15m" from the environment,
+// falling back to def when it is missing or invalid.
+func IntervalFromEnv(key string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"gorm.io/gorm"
+)
+
+// StartLowStockMonitor checks for low stock right away and then once every
+// interval in the background.
+func StartLowStockMonitor(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := services.DetectLowStock(db); err != nil {
+				log.Println("low stock check failed:", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
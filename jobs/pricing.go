@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"gorm.io/gorm"
+)
+
+// StartPriceScheduler applies scheduled price changes that are due right
+// away and then once every interval in the background.
+func StartPriceScheduler(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := services.ApplyDuePriceChanges(db); err != nil {
+				log.Println("scheduled price changes failed:", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
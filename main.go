@@ -2,11 +2,17 @@ package main
 
 import (
 	"log"
+	"time"
 
+	"github.com/DewiKresnawati/DewiWebService/database"
 	"github.com/DewiKresnawati/DewiWebService/database/migration"
 	_ "github.com/DewiKresnawati/DewiWebService/docs"
+	"github.com/DewiKresnawati/DewiWebService/jobs"
 	"github.com/DewiKresnawati/DewiWebService/middlewares"
+	"github.com/DewiKresnawati/DewiWebService/payment"
 	"github.com/DewiKresnawati/DewiWebService/routes"
+	"github.com/DewiKresnawati/DewiWebService/services"
+	"github.com/DewiKresnawati/DewiWebService/storage"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/swagger" // swagger handler
@@ -34,9 +40,34 @@ import (
 func main() {
 	migration.RunMigration()
 
-	app := fiber.New()
+	// Background jobs
+	jobs.StartLowStockMonitor(database.DB, jobs.IntervalFromEnv("LOW_STOCK_CHECK_INTERVAL", time.Hour))
+	jobs.StartPriceScheduler(database.DB, jobs.IntervalFromEnv("PRICE_SCHEDULER_INTERVAL", time.Minute))
+
+	// Storage for uploaded media (local filesystem or S3-compatible)
+	if err := storage.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Payment provider (a local mock unless configured otherwise)
+	if err := payment.Init(); err != nil {
+		log.Fatal(err)
+	}
+	if mock, ok := payment.Default.(*payment.Mock); ok {
+		mock.RestoreFrom(services.MockPaymentRecords(database.DB, mock.Name()))
+	}
+
+	app := fiber.New(fiber.Config{
+		// Ruang untuk unggahan gambar beserta field form lainnya
+		BodyLimit: int(services.MaxImageSize()) + 1<<20,
+	})
 	app.Use(cors.New())
 
+	// Serve locally stored media
+	if local, ok := storage.Default.(*storage.Local); ok {
+		app.Static(local.BaseURL, local.Dir)
+	}
+
 	// Route to Swagger docs
 	app.Get("/swagger/*", swagger.HandlerDefault) // use more specific route for Swagger
 
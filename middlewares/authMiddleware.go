@@ -3,13 +3,35 @@ package middlewares
 import (
     "strings"
 
+    "github.com/DewiKresnawati/DewiWebService/models"
     "github.com/DewiKresnawati/DewiWebService/utils"
     "github.com/gofiber/fiber/v2"
 )
 
-func AuthMiddleware() fiber.Handler {
+// AuthMiddleware verifies the bearer token and lets through only users with
+// one of the given roles. Without roles only staff users are allowed, so
+// supplier accounts can reach nothing but the routes opened to them.
+func AuthMiddleware(roles ...string) fiber.Handler {
+    return authMiddleware(false, roles)
+}
+
+// OptionalAuthMiddleware works like AuthMiddleware but also lets through
+// requests without an Authorization header, for routes open to anonymous
+// users that behave differently for logged in ones.
+func OptionalAuthMiddleware(roles ...string) fiber.Handler {
+    return authMiddleware(true, roles)
+}
+
+func authMiddleware(optional bool, roles []string) fiber.Handler {
+    if len(roles) == 0 {
+        roles = []string{models.RoleStaff}
+    }
+
     return func(c *fiber.Ctx) error {
         authHeader := c.Get("Authorization")
+        if authHeader == "" && optional {
+            return c.Next()
+        }
         if authHeader == "" {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "message": "Unauthorized: No token provided",
@@ -31,7 +53,28 @@ func AuthMiddleware() fiber.Handler {
             })
         }
 
+        // Token lama tanpa klaim role ditolak; user perlu login ulang untuk token baru
+        role, _ := claims["role"].(string)
+        if role == "" {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "message": "Unauthorized: token has no role, log in again",
+            })
+        }
+        allowed := false
+        for _, r := range roles {
+            if r == role {
+                allowed = true
+                break
+            }
+        }
+        if !allowed {
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "message": "Forbidden: role " + role + " may not access this resource",
+            })
+        }
+
         c.Locals("user", claims)
+        c.Locals("role", role)
 
         return c.Next()
     }
@@ -0,0 +1,85 @@
+package models
+
+import "gorm.io/gorm"
+
+// Attribute types.
+const (
+	AttributeTypeText    = "text"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeDate    = "date"
+	AttributeTypeSelect  = "select"
+)
+
+// AttributeTypes lists the accepted attribute types.
+var AttributeTypes = []string{
+	AttributeTypeText,
+	AttributeTypeNumber,
+	AttributeTypeBoolean,
+	AttributeTypeDate,
+	AttributeTypeSelect,
+}
+
+// Attribute defines a custom product field for a category. Products of the
+// category and of its sub-categories can hold a value for it.
+type Attribute struct {
+	gorm.Model
+	CategoryID uint     `gorm:"not null;uniqueIndex:idx_category_attribute_code"`
+	Category   Category // Relasi belongs to
+	Code       string   `gorm:"size:64;not null;uniqueIndex:idx_category_attribute_code"`
+	Name       string   `gorm:"not null"`
+	Type       string   `gorm:"size:16;not null"`
+	Required   bool     `gorm:"not null;default:false"`
+	Unit       string
+	Options    []string `gorm:"serializer:json"` // pilihan untuk tipe select
+	Min        *float64 // batas untuk tipe number
+	Max        *float64
+	Position   int `gorm:"not null;default:0"`
+}
+
+// ProductAttributeValue holds the value of an attribute for a product. Value
+// is normalised (numbers without trailing zeros, dates as YYYY-MM-DD,
+// booleans as true/false); NumberValue is set for numbers to allow ranges.
+type ProductAttributeValue struct {
+	gorm.Model
+	ProductID   uint      `gorm:"not null;uniqueIndex:idx_product_attribute"`
+	AttributeID uint      `gorm:"not null;uniqueIndex:idx_product_attribute;index:idx_attribute_value"`
+	Attribute   Attribute // Relasi belongs to
+	Value       string    `gorm:"size:191;not null;index:idx_attribute_value"`
+	NumberValue *float64
+}
+
+type AttributeRequest struct {
+	Code     string   `json:"code"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Unit     string   `json:"unit"`
+	Options  []string `json:"options"`
+	Min      *float64 `json:"min"`
+	Max      *float64 `json:"max"`
+	Position int      `json:"position"`
+}
+
+type AttributeResponse struct {
+	ID         uint     `json:"id"`
+	CategoryID uint     `json:"category_id"`
+	Code       string   `json:"code"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Required   bool     `json:"required"`
+	Unit       string   `json:"unit"`
+	Options    []string `json:"options"`
+	Min        *float64 `json:"min"`
+	Max        *float64 `json:"max"`
+	Position   int      `json:"position"`
+}
+
+type ProductAttributeResponse struct {
+	AttributeID uint   `json:"attribute_id"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Unit        string `json:"unit"`
+	Value       string `json:"value"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import "gorm.io/gorm"
+
+// Cart holds the items a shopper means to order. Anonymous carts are found
+// by their token; a logged in user has one cart, into which the anonymous
+// cart they filled before logging in is merged.
+type Cart struct {
+	gorm.Model
+	Token  string     `gorm:"size:64;uniqueIndex;not null"`
+	UserID *uint      `gorm:"uniqueIndex"` // kosong untuk keranjang anonim
+	Items  []CartItem // Relasi has many
+}
+
+// CartItem is a product or variant in a cart. Its price is not fixed: the
+// cart is priced at current prices whenever it is read and checked out.
+type CartItem struct {
+	gorm.Model
+	CartID    uint            `gorm:"not null;index"`
+	ProductID uint            `gorm:"not null"`
+	Product   Product         // Relasi belongs to
+	VariantID *uint           // Relasi belongs to
+	Variant   *ProductVariant // Relasi belongs to
+	Quantity  uint            `gorm:"not null"`
+	// Harga saat barang dimasukkan, untuk menandai perubahan harga
+	PriceWhenAdded Money `gorm:"not null;default:0"`
+}
+
+type CartItemRequest struct {
+	ProductID uint `json:"product_id"`
+	// VariantID selects a variant of the product; product_id may be left out when it is set.
+	VariantID *uint `json:"variant_id"`
+	Quantity  uint  `json:"quantity"`
+}
+
+type CartItemQuantityRequest struct {
+	// Quantity replaces the quantity of the item; 0 removes it.
+	Quantity uint `json:"quantity"`
+}
+
+type CheckoutRequest struct {
+	// TaxRegion, CouponCode, Currency, ShippingAddressID and BillingAddressID work as in OrderRequest.
+	TaxRegion         string `json:"tax_region"`
+	CouponCode        string `json:"coupon_code"`
+	Currency          string `json:"currency"`
+	ShippingAddressID *uint  `json:"shipping_address_id"`
+	BillingAddressID  *uint  `json:"billing_address_id"`
+	// CustomerID is the customer staff check out for; customers always check out for themselves.
+	CustomerID *uint    `json:"customer_id"`
+	Strategy   string   `json:"strategy"`
+	Latitude   *float64 `json:"latitude"`
+	Longitude  *float64 `json:"longitude"`
+}
+
+type CartItemResponse struct {
+	ID        uint   `json:"id"`
+	ProductID uint   `json:"product_id"`
+	VariantID *uint  `json:"variant_id"`
+	Name      string `json:"name"`
+	SKU       string `json:"sku"`
+	Quantity  uint   `json:"quantity"`
+	// UnitPrice is the current price, before promotions and taxes.
+	UnitPrice      Money `json:"unit_price"`
+	LineTotal      Money `json:"line_total"`
+	PriceWhenAdded Money `json:"price_when_added"`
+	PriceChanged   bool  `json:"price_changed"`
+}
+
+type CartResponse struct {
+	ID uint `json:"id"`
+	// Token identifies an anonymous cart; send it in the X-Cart-Token header.
+	Token    string             `json:"token"`
+	Items    []CartItemResponse `json:"items"`
+	Quantity uint               `json:"quantity"`
+	Subtotal Money              `json:"subtotal"`
+	Currency string             `json:"currency"`
+}
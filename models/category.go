@@ -5,14 +5,54 @@ import "gorm.io/gorm"
 // Category represents a category entity.
 type Category struct {
 	gorm.Model
-	Name string `gorm:"unique;not null"`
+	Name     string    `gorm:"unique;not null"`
+	ParentID *uint     `gorm:"index"` // nil untuk kategori utama
+	Parent   *Category // Relasi belongs to
+	Position int       `gorm:"not null;default:0"` // urutan di antara saudara
+	// Kelas pajak produk di kategori ini; kosong berarti mengikuti induknya
+	TaxClassID *uint `gorm:"index"`
+	// SEO
+	Slug            string `gorm:"size:191;index"`
+	MetaTitle       string
+	MetaDescription string
 }
 
 type CategoryRequest struct {
 	Name string `json:"name"`
+	// ParentID is only used on creation; use the move endpoint to re-parent.
+	ParentID *uint `json:"parent_id"`
+	// TaxClassID is the tax class of the products in the category; null inherits it from the parent.
+	TaxClassID *uint `json:"tax_class_id"`
+	// Slug defaults to one generated from the name on creation; send it to change the URL.
+	Slug            string `json:"slug"`
+	MetaTitle       string `json:"meta_title"`
+	MetaDescription string `json:"meta_description"`
 }
 
 type CategoryResponse struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID              uint   `json:"id"`
+	Name            string `json:"name"`
+	ParentID        *uint  `json:"parent_id"`
+	Position        int    `json:"position"`
+	TaxClassID      *uint  `json:"tax_class_id"`
+	Slug            string `json:"slug"`
+	MetaTitle       string `json:"meta_title"`
+	MetaDescription string `json:"meta_description"`
+}
+
+type CategoryMoveRequest struct {
+	// ParentID is the new parent; null moves the category to the top level.
+	ParentID *uint `json:"parent_id"`
+	// Position is the zero-based position among the new siblings.
+	Position int `json:"position"`
+}
+
+// CategoryTreeNode is a category with its nested sub-categories.
+type CategoryTreeNode struct {
+	ID       uint               `json:"id"`
+	Name     string             `json:"name"`
+	Slug     string             `json:"slug"`
+	ParentID *uint              `json:"parent_id"`
+	Position int                `json:"position"`
+	Children []CategoryTreeNode `json:"children"`
 }
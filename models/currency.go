@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExchangeRate is the number of units of a currency that one unit of the
+// base currency buys from EffectiveFrom until the next rate of the currency.
+type ExchangeRate struct {
+	gorm.Model
+	Currency      string    `gorm:"size:3;not null;uniqueIndex:idx_currency_effective_from"`
+	Rate          Money     `gorm:"type:decimal(24,10);not null"`
+	EffectiveFrom time.Time `gorm:"not null;uniqueIndex:idx_currency_effective_from"`
+	Source        string    `gorm:"size:16;not null;default:manual"` // manual atau import
+	UserID        *uint
+}
+
+// ProductPrice overrides the converted price of a product in one currency.
+type ProductPrice struct {
+	gorm.Model
+	ProductID uint   `gorm:"not null;uniqueIndex:idx_product_currency"`
+	Currency  string `gorm:"size:3;not null;uniqueIndex:idx_product_currency"`
+	Price     Money  `gorm:"not null"`
+}
+
+type ExchangeRateRequest struct {
+	Currency string `json:"currency"`
+	Rate     Money  `json:"rate"`
+	// EffectiveFrom is a date (YYYY-MM-DD) and defaults to today.
+	EffectiveFrom string `json:"effective_from"`
+}
+
+type ExchangeRateResponse struct {
+	ID            uint      `json:"id"`
+	BaseCurrency  string    `json:"base_currency"`
+	Currency      string    `json:"currency"`
+	Rate          Money     `json:"rate"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	Source        string    `json:"source"`
+	UserID        *uint     `json:"user_id"`
+}
+
+type ExchangeRateImportResponse struct {
+	Imported int              `json:"imported"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+type ProductPriceRequest struct {
+	Price Money `json:"price"`
+}
+
+type ProductPriceResponse struct {
+	ProductID uint   `json:"product_id"`
+	Currency  string `json:"currency"`
+	Price     Money  `json:"price"`
+}
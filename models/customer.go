@@ -0,0 +1,133 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Customer is the profile of a user with the customer role. Orders placed
+// by a customer, or by staff on their behalf, reference it through
+// Order.CustomerID.
+type Customer struct {
+	gorm.Model
+	UserID    uint   `gorm:"not null;uniqueIndex"`
+	User      *User  // Relasi belongs to
+	Name      string `gorm:"not null"`
+	Email     string `gorm:"size:191;index"`
+	Phone     string
+	Addresses []CustomerAddress // Relasi has many
+}
+
+// CustomerAddress is an address in a customer's address book. Orders copy
+// their shipping and billing address from it, by default from the
+// addresses marked as default.
+type CustomerAddress struct {
+	gorm.Model
+	CustomerID        uint   `gorm:"not null;index"`
+	Label             string // misalnya Rumah atau Kantor
+	Recipient         string `gorm:"not null"`
+	Phone             string
+	Street            string `gorm:"not null"`
+	City              string `gorm:"not null"`
+	Province          string
+	PostalCode        string
+	Country           string `gorm:"not null;default:ID"`
+	IsDefaultShipping bool   `gorm:"not null;default:false"`
+	IsDefaultBilling  bool   `gorm:"not null;default:false"`
+}
+
+// Address returns the address without its book-keeping fields.
+func (a CustomerAddress) Address() Address {
+	return Address{
+		Recipient:  a.Recipient,
+		Phone:      a.Phone,
+		Street:     a.Street,
+		City:       a.City,
+		Province:   a.Province,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+	}
+}
+
+// SetAddress copies the fields of an address onto the saved address.
+func (a *CustomerAddress) SetAddress(address Address) {
+	a.Recipient = address.Recipient
+	a.Phone = address.Phone
+	a.Street = address.Street
+	a.City = address.City
+	a.Province = address.Province
+	a.PostalCode = address.PostalCode
+	a.Country = address.Country
+}
+
+// Address is a postal address as copied onto an order.
+type Address struct {
+	Recipient  string `json:"recipient"`
+	Phone      string `json:"phone"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	Province   string `json:"province"`
+	PostalCode string `json:"postal_code"`
+	Country    string `gorm:"size:2" json:"country"`
+}
+
+// IsZero reports whether no address was set.
+func (a Address) IsZero() bool {
+	return a == Address{}
+}
+
+type CustomerRegisterRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+}
+
+type CustomerRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+type CustomerAddressRequest struct {
+	Label      string `json:"label"`
+	Recipient  string `json:"recipient"`
+	Phone      string `json:"phone"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	Province   string `json:"province"`
+	PostalCode string `json:"postal_code"`
+	// Country is a two letter ISO 3166 code and defaults to ID. Postal codes
+	// and provinces are checked for the countries with a known format.
+	Country         string `json:"country"`
+	DefaultShipping bool   `json:"default_shipping"`
+	DefaultBilling  bool   `json:"default_billing"`
+}
+
+type CustomerAddressResponse struct {
+	ID              uint   `json:"id"`
+	CustomerID      uint   `json:"customer_id"`
+	Label           string `json:"label"`
+	Recipient       string `json:"recipient"`
+	Phone           string `json:"phone"`
+	Street          string `json:"street"`
+	City            string `json:"city"`
+	Province        string `json:"province"`
+	PostalCode      string `json:"postal_code"`
+	Country         string `json:"country"`
+	DefaultShipping bool   `json:"default_shipping"`
+	DefaultBilling  bool   `json:"default_billing"`
+}
+
+type CustomerResponse struct {
+	ID        uint                      `json:"id"`
+	UserID    uint                      `json:"user_id"`
+	Username  string                    `json:"username"`
+	Name      string                    `json:"name"`
+	Email     string                    `json:"email"`
+	Phone     string                    `json:"phone"`
+	Addresses []CustomerAddressResponse `json:"addresses"`
+	CreatedAt time.Time                 `json:"created_at"`
+}
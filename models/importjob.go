@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Import job statuses.
+const (
+	ImportStatusQueued    = "queued"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// ImportRowError describes why a row of an import file was rejected. Row is
+// the line number in the file, counting the header as row 1.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportJob tracks a bulk import processed in the background.
+type ImportJob struct {
+	gorm.Model
+	Type       string `gorm:"size:32;not null"` // jenis data, misalnya products
+	FileName   string
+	Status     string           `gorm:"size:16;not null;index"`
+	TotalRows  int              `gorm:"not null;default:0"`
+	Created    int              `gorm:"not null;default:0"`
+	Updated    int              `gorm:"not null;default:0"`
+	Failed     int              `gorm:"not null;default:0"`
+	RowErrors  []ImportRowError `gorm:"serializer:json"`
+	Error      string           // kegagalan yang menghentikan seluruh impor
+	UserID     *uint
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+type ImportJobResponse struct {
+	ID         uint             `json:"id"`
+	Type       string           `json:"type"`
+	FileName   string           `json:"file_name"`
+	Status     string           `json:"status"`
+	DryRun     bool             `json:"dry_run"`
+	TotalRows  int              `json:"total_rows"`
+	Created    int              `json:"created"`
+	Updated    int              `json:"updated"`
+	Failed     int              `json:"failed"`
+	Errors     []ImportRowError `json:"errors"`
+	Error      string           `json:"error,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	StartedAt  *time.Time       `json:"started_at"`
+	FinishedAt *time.Time       `json:"finished_at"`
+}
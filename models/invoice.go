@@ -0,0 +1,128 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var ErrInvoiceImmutable = errors.New("issued invoices cannot be changed")
+
+// Invoice is the legal invoice of an order. Its number comes from a gap-free
+// sequence per fiscal year, and it keeps a copy of everything it shows so
+// that it never changes once issued, whatever happens to the order.
+type Invoice struct {
+	gorm.Model
+	OrderID    uint      `gorm:"not null;uniqueIndex"`
+	Number     string    `gorm:"size:32;not null;uniqueIndex"`
+	FiscalYear int       `gorm:"not null;uniqueIndex:idx_invoice_sequence"`
+	Sequence   uint      `gorm:"not null;uniqueIndex:idx_invoice_sequence"`
+	IssuedAt   time.Time `gorm:"not null"`
+	// Salinan pelanggan dan alamat tagihan saat faktur diterbitkan
+	CustomerName     string
+	BillingAddress   Address       `gorm:"embedded;embeddedPrefix:billing_"`
+	Items            []InvoiceItem // Relasi has many
+	Subtotal         Money         `gorm:"not null"` // setelah diskon, tanpa pajak
+	DiscountTotal    Money         `gorm:"not null"`
+	ShippingTotal    Money         `gorm:"not null"`
+	TaxTotal         Money         `gorm:"not null"`
+	Total            Money         `gorm:"not null"`
+	Currency         string        `gorm:"size:3;not null"`
+	PricesIncludeTax bool          `gorm:"not null"`
+	Taxes            []InvoiceTax  // Relasi has many
+}
+
+// InvoiceItem is a copy of a line of the invoiced order.
+type InvoiceItem struct {
+	gorm.Model
+	InvoiceID   uint   `gorm:"not null;index"`
+	Description string `gorm:"not null"`
+	SKU         string `gorm:"size:64"`
+	Quantity    uint   `gorm:"not null"`
+	UnitPrice   Money  `gorm:"not null"`
+	Subtotal    Money  `gorm:"not null"` // setelah diskon, tanpa pajak
+}
+
+// InvoiceTax is a line of the tax breakdown of an invoice.
+type InvoiceTax struct {
+	gorm.Model
+	InvoiceID     uint            `gorm:"not null;index"`
+	Name          string          `gorm:"not null"`
+	Region        string          `gorm:"size:16;not null"`
+	Rate          decimal.Decimal `gorm:"type:decimal(7,4);not null"`
+	TaxableAmount Money           `gorm:"not null"`
+	Amount        Money           `gorm:"not null"`
+}
+
+// InvoiceSequence holds the last invoice number issued in a fiscal year.
+type InvoiceSequence struct {
+	FiscalYear int  `gorm:"primaryKey;autoIncrement:false"`
+	LastNumber uint `gorm:"not null;default:0"`
+}
+
+// BeforeUpdate keeps issued invoices unchanged.
+func (i *Invoice) BeforeUpdate(tx *gorm.DB) error {
+	return ErrInvoiceImmutable
+}
+
+// BeforeDelete keeps issued invoices, so that the sequence has no gaps.
+func (i *Invoice) BeforeDelete(tx *gorm.DB) error {
+	return ErrInvoiceImmutable
+}
+
+// BeforeUpdate keeps the lines of issued invoices unchanged.
+func (i *InvoiceItem) BeforeUpdate(tx *gorm.DB) error {
+	return ErrInvoiceImmutable
+}
+
+// BeforeDelete keeps the lines of issued invoices.
+func (i *InvoiceItem) BeforeDelete(tx *gorm.DB) error {
+	return ErrInvoiceImmutable
+}
+
+// BeforeUpdate keeps the tax breakdown of issued invoices unchanged.
+func (t *InvoiceTax) BeforeUpdate(tx *gorm.DB) error {
+	return ErrInvoiceImmutable
+}
+
+// BeforeDelete keeps the tax breakdown of issued invoices.
+func (t *InvoiceTax) BeforeDelete(tx *gorm.DB) error {
+	return ErrInvoiceImmutable
+}
+
+type InvoiceItemResponse struct {
+	Description string `json:"description"`
+	SKU         string `json:"sku"`
+	Quantity    uint   `json:"quantity"`
+	UnitPrice   Money  `json:"unit_price"`
+	Subtotal    Money  `json:"subtotal"`
+}
+
+type InvoiceTaxResponse struct {
+	Name          string          `json:"name"`
+	Region        string          `json:"region"`
+	Rate          decimal.Decimal `json:"rate" swaggertype:"string"`
+	TaxableAmount Money           `json:"taxable_amount"`
+	Amount        Money           `json:"amount"`
+}
+
+type InvoiceResponse struct {
+	ID               uint                  `json:"id"`
+	OrderID          uint                  `json:"order_id"`
+	Number           string                `json:"number"`
+	FiscalYear       int                   `json:"fiscal_year"`
+	IssuedAt         time.Time             `json:"issued_at"`
+	CustomerName     string                `json:"customer_name"`
+	BillingAddress   *Address              `json:"billing_address"`
+	Items            []InvoiceItemResponse `json:"items"`
+	Subtotal         Money                 `json:"subtotal"`
+	DiscountTotal    Money                 `json:"discount_total"`
+	ShippingTotal    Money                 `json:"shipping_total"`
+	TaxTotal         Money                 `json:"tax_total"`
+	Total            Money                 `json:"total"`
+	Currency         string                `json:"currency"`
+	PricesIncludeTax bool                  `json:"prices_include_tax"`
+	Taxes            []InvoiceTaxResponse  `json:"taxes"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Low stock alert statuses.
+const (
+	AlertStatusOpen     = "open"
+	AlertStatusResolved = "resolved"
+)
+
+// LowStockAlert is raised when the total stock of a product drops to or
+// below its reorder point, and resolved once it is replenished.
+type LowStockAlert struct {
+	gorm.Model
+	ProductID    uint    `gorm:"not null;index"`
+	Product      Product // Relasi belongs to
+	SupplierID   uint    `gorm:"not null;index"`
+	OnHand       int     `gorm:"not null"`
+	ReorderPoint int     `gorm:"not null"`
+	Status       string  `gorm:"not null;default:open;index"`
+	DetectedAt   time.Time
+	ResolvedAt   *time.Time
+}
+
+type LowStockAlertResponse struct {
+	ID           uint       `json:"id"`
+	ProductID    uint       `json:"product_id"`
+	SupplierID   uint       `json:"supplier_id"`
+	OnHand       int        `json:"on_hand"`
+	ReorderPoint int        `json:"reorder_point"`
+	Status       string     `json:"status"`
+	DetectedAt   time.Time  `json:"detected_at"`
+	ResolvedAt   *time.Time `json:"resolved_at"`
+}
+
+// ReorderSuggestionItem is one product line of a suggested purchase order.
+type ReorderSuggestionItem struct {
+	ProductID         uint   `json:"product_id"`
+	ProductName       string `json:"product_name"`
+	OnHand            int    `json:"on_hand"`
+	OnOrder           int    `json:"on_order"`
+	ReorderPoint      int    `json:"reorder_point"`
+	ReorderQuantity   int    `json:"reorder_quantity"`
+	SuggestedQuantity int    `json:"suggested_quantity"`
+}
+
+type ReorderPurchaseOrderRequest struct {
+	WarehouseID uint `json:"warehouse_id"`
+}
+
+// ReorderSuggestionResponse is a draft purchase order for one supplier.
+type ReorderSuggestionResponse struct {
+	SupplierID uint                    `json:"supplier_id"`
+	Items      []ReorderSuggestionItem `json:"items"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// MoneyScale is the number of decimal places amounts of money are stored
+// with; costs keep more places than prices usually have.
+const MoneyScale = 4
+
+// Money is an exact decimal amount of money. It is stored as DECIMAL(19,4)
+// and encoded in JSON as a string such as "12.5" so that no precision is
+// lost; requests may send a string or a number.
+//
+// Money carries no currency code: the currency is kept once on the record
+// owning the amounts, such as Order.Currency or ProductPrice.Currency, and
+// amounts of records without one are in the base currency. All amounts of a
+// record are thereby in the same currency and each stays a single column.
+// Exchange rates are Money too, the amount of a currency one unit of the
+// base currency buys, stored with more decimal places.
+type Money struct {
+	decimal.Decimal
+}
+
+// NewMoney returns the amount represented by an integer.
+func NewMoney(value int64) Money {
+	return Money{decimal.NewFromInt(value)}
+}
+
+// ParseMoney parses an amount such as "12.50".
+func ParseMoney(value string) (Money, error) {
+	d, err := decimal.NewFromString(strings.TrimSpace(value))
+	return Money{d}, err
+}
+
+// GormDataType stores money as an exact decimal column.
+func (Money) GormDataType() string {
+	return "decimal(19,4)"
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{m.Decimal.Add(other.Decimal)}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{m.Decimal.Sub(other.Decimal)}
+}
+
+// Times multiplies the amount by a quantity.
+func (m Money) Times(quantity int64) Money {
+	return Money{m.Decimal.Mul(decimal.NewFromInt(quantity))}
+}
+
+// Round rounds the amount to the scale money is stored with.
+func (m Money) Round() Money {
+	return Money{m.Decimal.Round(MoneyScale)}
+}
+
+func (m Money) Equal(other Money) bool {
+	return m.Decimal.Equal(other.Decimal)
+}
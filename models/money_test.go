@@ -0,0 +1,107 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func mustParseMoney(t *testing.T, value string) Money {
+	t.Helper()
+	m, err := ParseMoney(value)
+	if err != nil {
+		t.Fatalf("parse %q: %v", value, err)
+	}
+	return m
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		amount string
+		json   string
+	}{
+		{"12.5", `"12.5"`},
+		{"0", `"0"`},
+		{"-3.25", `"-3.25"`},
+		{"1234567890.1234", `"1234567890.1234"`},
+	}
+	for _, tt := range tests {
+		body, err := json.Marshal(mustParseMoney(t, tt.amount))
+		if err != nil {
+			t.Fatalf("marshal %s: %v", tt.amount, err)
+		}
+		if string(body) != tt.json {
+			t.Errorf("marshal %s: %s, want %s", tt.amount, body, tt.json)
+		}
+		var m Money
+		if err := json.Unmarshal(body, &m); err != nil {
+			t.Fatalf("unmarshal %s: %v", body, err)
+		}
+		if !m.Equal(mustParseMoney(t, tt.amount)) {
+			t.Errorf("unmarshal %s: %s, want %s", body, m, tt.amount)
+		}
+	}
+}
+
+func TestMoneyJSONAcceptsNumbers(t *testing.T) {
+	var req struct {
+		Price Money `json:"price"`
+	}
+	if err := json.Unmarshal([]byte(`{"price":12.5}`), &req); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !req.Price.Equal(mustParseMoney(t, "12.5")) {
+		t.Errorf("price %s, want 12.5", req.Price)
+	}
+	if err := json.Unmarshal([]byte(`{"price":"abc"}`), &req); err == nil {
+		t.Error("no error for a price that is not a number")
+	}
+}
+
+func TestMoneySQL(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want string
+	}{
+		{"19.9900", "19.99"},
+		{[]byte("0.0001"), "0.0001"},
+		{int64(7), "7"},
+		{1.5, "1.5"},
+	}
+	for _, tt := range tests {
+		var m Money
+		if err := m.Scan(tt.src); err != nil {
+			t.Fatalf("scan %v: %v", tt.src, err)
+		}
+		if !m.Equal(mustParseMoney(t, tt.want)) {
+			t.Errorf("scan %v: %s, want %s", tt.src, m, tt.want)
+		}
+		value, err := m.Value()
+		if err != nil {
+			t.Fatalf("value of %s: %v", m, err)
+		}
+		var back Money
+		if err := back.Scan(value); err != nil || !back.Equal(m) {
+			t.Errorf("round trip of %s: %s, %v", m, back, err)
+		}
+	}
+	if got := (Money{}).GormDataType(); got != "decimal(19,4)" {
+		t.Errorf("data type %s, want decimal(19,4)", got)
+	}
+}
+
+func TestMoneyRound(t *testing.T) {
+	tests := map[string]string{
+		"1.23454":  "1.2345",
+		"1.23455":  "1.2346",
+		"-1.23455": "-1.2346",
+		"2":        "2",
+	}
+	for amount, want := range tests {
+		if got := mustParseMoney(t, amount).Round(); !got.Equal(mustParseMoney(t, want)) {
+			t.Errorf("round %s: %s, want %s", amount, got, want)
+		}
+	}
+	if got := mustParseMoney(t, "2.5").Times(3).Sub(NewMoney(1)).Add(mustParseMoney(t, "0.25")); !got.Equal(mustParseMoney(t, "6.75")) {
+		t.Errorf("2.5 * 3 - 1 + 0.25 = %s, want 6.75", got)
+	}
+}
@@ -1,24 +1,143 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"gorm.io/gorm"
+)
+
+const (
+	OrderStatusPending           = "pending"
+	OrderStatusPaid              = "paid"
+	OrderStatusPartiallyRefunded = "partially_refunded"
+	OrderStatusRefunded          = "refunded"
+)
 
 type Order struct {
 	gorm.Model
-	ProductID uint    `gorm:"not null"`
-	Product   Product // Contoh: Relasi has many, sesuaikan dengan struktur Product Anda
-	Quantity  uint    `gorm:"not null"`
-	Total     float64 `gorm:"not null"`
+	// Diperbarui dari pembayaran; pending sampai total pesanan tertagih
+	Status    string      `gorm:"size:24;not null;default:pending;index"`
+	Items     []OrderItem // Relasi has many
+	Subtotal  Money       `gorm:"not null;default:0"` // setelah diskon, tanpa pajak
+	TaxTotal  Money       `gorm:"not null;default:0"`
+	Total     Money       `gorm:"not null"`           // termasuk pajak dan ongkos kirim
+	CostTotal Money       `gorm:"not null;default:0"` // harga pokok saat pesanan dibuat, dalam mata uang dasar
+	Currency  string      `gorm:"size:3;not null"`
+	// Kurs mata uang pesanan terhadap mata uang dasar saat pesanan dihargai
+	ExchangeRate Money `gorm:"type:decimal(24,10);not null;default:1"`
+	// Diskon baris ditambah ongkos kirim yang digratiskan
+	DiscountTotal Money           `gorm:"not null;default:0"`
+	ShippingTotal Money           `gorm:"not null;default:0"`
+	CouponCode    string          `gorm:"size:64;not null;default:''"`
+	Discounts     []OrderDiscount // Relasi has many
+	// Harga produk yang dipakai sudah termasuk pajak atau belum
+	PricesIncludeTax bool       `gorm:"not null;default:false"`
+	TaxRegion        string     `gorm:"size:16;not null;default:''"`
+	Taxes            []OrderTax // Relasi has many
+	UserID           *uint      `gorm:"index"` // user yang membuat pesanan
+	CustomerID       *uint      `gorm:"index"` // pelanggan pemilik pesanan
+	Customer         *Customer  // Relasi belongs to
+	// Salinan alamat saat pesanan dibuat, tidak ikut berubah bila buku alamat diedit
+	ShippingAddress Address `gorm:"embedded;embeddedPrefix:shipping_"`
+	BillingAddress  Address `gorm:"embedded;embeddedPrefix:billing_"`
+}
+
+// OrderItem is a line of an order: a product or variant, how many were
+// ordered and what they were sold for, and the warehouse that shipped them.
+type OrderItem struct {
+	gorm.Model
+	OrderID   uint            `gorm:"not null;index"`
+	ProductID uint            `gorm:"not null;index"`
+	Product   Product         // Relasi belongs to
+	VariantID *uint           `gorm:"index"` // varian yang dipesan, jika produk memiliki varian
+	Variant   *ProductVariant // Relasi belongs to
+	Quantity  uint            `gorm:"not null"`
+	UnitPrice Money           `gorm:"not null"`
+	// Bagian diskon pesanan yang jatuh pada baris ini
+	DiscountTotal Money      `gorm:"not null;default:0"`
+	Subtotal      Money      `gorm:"not null;default:0"` // setelah diskon, tanpa pajak
+	TaxTotal      Money      `gorm:"not null;default:0"`
+	CostTotal     Money      `gorm:"not null;default:0"` // harga pokok saat pesanan dibuat, dalam mata uang dasar
+	WarehouseID   *uint      `gorm:"index"`              // gudang yang mengirim baris ini
+	Warehouse     *Warehouse // Relasi belongs to
+}
+
+type OrderItemRequest struct {
+	ProductID uint `json:"product_id"`
+	// VariantID selects a variant of the product; product_id may be left out when it is set.
+	VariantID *uint `json:"variant_id"`
+	Quantity  uint  `json:"quantity"`
 }
 
 type OrderRequest struct {
-	ProductID uint    `json:"product_id"`
-	Quantity  uint    `json:"quantity"`
-	Total     float64 `json:"total"`
+	// Items are the lines of the order; on update the lines are only replaced when sent.
+	Items []OrderItemRequest `json:"items"`
+	// ProductID, VariantID and Quantity may be sent instead of items for an order of one line.
+	ProductID uint  `json:"product_id"`
+	VariantID *uint `json:"variant_id"`
+	Quantity  uint  `json:"quantity"`
+	// TaxRegion is the region taxes are charged for, such as ID or ID-JK; it defaults to the
+	// country of the shipping address, then to TAX_REGION.
+	TaxRegion string `json:"tax_region"`
+	// CouponCode applies a coupon; on update the coupon is replaced by the one sent, if any.
+	CouponCode string `json:"coupon_code"`
+	// Currency is the currency the order is priced and paid in, the base currency by default.
+	// Prices are the product's price in that currency or are converted at today's rate; the
+	// currency cannot be changed on update.
+	Currency string `json:"currency"`
+	// CustomerID is the customer staff place the order for; customers always order for themselves.
+	CustomerID *uint `json:"customer_id"`
+	// ShippingAddressID and BillingAddressID pick addresses from the customer's
+	// address book; they default to the customer's default addresses, and the
+	// billing address to the shipping address. On update they are only changed when sent.
+	ShippingAddressID *uint `json:"shipping_address_id"`
+	BillingAddressID  *uint `json:"billing_address_id"`
+	// Strategy overrides the default fulfilment strategy (nearest, most_stock, priority).
+	Strategy  string   `json:"strategy"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+// OrderLines returns the lines of the request, the single line given by
+// ProductID, VariantID and Quantity when it has no items.
+func (r OrderRequest) OrderLines() []OrderItemRequest {
+	if len(r.Items) > 0 {
+		return r.Items
+	}
+	if r.ProductID == 0 && r.VariantID == nil && r.Quantity == 0 {
+		return nil
+	}
+	return []OrderItemRequest{{ProductID: r.ProductID, VariantID: r.VariantID, Quantity: r.Quantity}}
+}
+
+type OrderItemResponse struct {
+	ID            uint  `json:"id"`
+	ProductID     uint  `json:"product_id"`
+	VariantID     *uint `json:"variant_id"`
+	Quantity      uint  `json:"quantity"`
+	UnitPrice     Money `json:"unit_price"`
+	DiscountTotal Money `json:"discount_total"`
+	Subtotal      Money `json:"subtotal"`
+	TaxTotal      Money `json:"tax_total"`
+	WarehouseID   *uint `json:"warehouse_id"`
 }
 
 type OrderResponse struct {
-	ID        uint    `json:"id"`
-	ProductID uint    `json:"product_id"`
-	Quantity  uint    `json:"quantity"`
-	Total     float64 `json:"total"`
+	ID               uint                    `json:"id"`
+	Status           string                  `json:"status"`
+	Items            []OrderItemResponse     `json:"items"`
+	Subtotal         Money                   `json:"subtotal"`
+	TaxTotal         Money                   `json:"tax_total"`
+	DiscountTotal    Money                   `json:"discount_total"`
+	ShippingTotal    Money                   `json:"shipping_total"`
+	Total            Money                   `json:"total"`
+	Currency         string                  `json:"currency"`
+	ExchangeRate     Money                   `json:"exchange_rate"`
+	PricesIncludeTax bool                    `json:"prices_include_tax"`
+	TaxRegion        string                  `json:"tax_region"`
+	Taxes            []OrderTaxResponse      `json:"taxes"`
+	CouponCode       string                  `json:"coupon_code"`
+	Discounts        []OrderDiscountResponse `json:"discounts"`
+	UserID           *uint                   `json:"user_id"`
+	CustomerID       *uint                   `json:"customer_id"`
+	ShippingAddress  *Address                `json:"shipping_address"`
+	BillingAddress   *Address                `json:"billing_address"`
 }
@@ -0,0 +1,68 @@
+package models
+
+import "gorm.io/gorm"
+
+const (
+	PaymentStatusPending    = "pending"
+	PaymentStatusAuthorized = "authorized"
+	PaymentStatusCaptured   = "captured"
+	PaymentStatusFailed     = "failed"
+	PaymentStatusRefunded   = "refunded"
+)
+
+// Payment is an attempt to pay an order through a payment provider. A
+// captured payment counts towards the order less what has been refunded.
+type Payment struct {
+	gorm.Model
+	OrderID   uint   `gorm:"not null;index"`
+	Order     *Order // Relasi belongs to
+	Provider  string `gorm:"size:32;not null;uniqueIndex:idx_payment_reference"`
+	Reference string `gorm:"size:128;not null;uniqueIndex:idx_payment_reference"` // id pembayaran di penyedia
+	Status    string `gorm:"size:16;not null;default:pending;index"`
+	Amount    Money  `gorm:"not null"` // jumlah yang diotorisasi
+	Currency  string `gorm:"size:3;not null"`
+	// Jumlah yang benar-benar ditarik dan yang sudah dikembalikan
+	CapturedAmount Money  `gorm:"not null;default:0"`
+	RefundedAmount Money  `gorm:"not null;default:0"`
+	FailureReason  string `gorm:"not null;default:''"`
+}
+
+// PaymentEvent is a webhook of a payment provider that has been handled,
+// kept so that deliveries retried by the provider are only handled once.
+type PaymentEvent struct {
+	gorm.Model
+	Provider  string `gorm:"size:32;not null;uniqueIndex:idx_payment_event"`
+	EventID   string `gorm:"size:128;not null;uniqueIndex:idx_payment_event"`
+	Type      string `gorm:"size:32;not null"`
+	PaymentID uint   `gorm:"not null;index"`
+}
+
+type PaymentRequest struct {
+	// Token is the payment method as tokenised by the provider on the client.
+	// The mock provider declines tok_decline and settles tok_pending later
+	// through its webhook.
+	Token string `json:"token"`
+	// Capture charges the payment at once; it defaults to true. Otherwise the
+	// amount is only authorized and captured later.
+	Capture *bool `json:"capture"`
+}
+
+type PaymentAmountRequest struct {
+	// Amount defaults to the whole amount left to capture or refund.
+	Amount *Money `json:"amount"`
+}
+
+type PaymentResponse struct {
+	ID             uint   `json:"id"`
+	OrderID        uint   `json:"order_id"`
+	Provider       string `json:"provider"`
+	Reference      string `json:"reference"`
+	Status         string `json:"status"`
+	Amount         Money  `json:"amount"`
+	Currency       string `json:"currency"`
+	CapturedAmount Money  `json:"captured_amount"`
+	RefundedAmount Money  `json:"refunded_amount"`
+	FailureReason  string `json:"failure_reason"`
+	// OrderStatus is the status of the order after the payment was handled.
+	OrderStatus string `json:"order_status"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Price change statuses.
+const (
+	PriceChangeStatusScheduled = "scheduled"
+	PriceChangeStatusApplied   = "applied"
+	PriceChangeStatusCancelled = "cancelled"
+	PriceChangeStatusFailed    = "failed"
+)
+
+// PriceChange records a change of the selling price of a product. Changes
+// dated in the future stay scheduled until the price scheduler applies them;
+// applied changes form the price history of the product.
+type PriceChange struct {
+	gorm.Model
+	ProductID   uint   `gorm:"not null;index"`
+	OldPrice    *Money // kosong untuk harga awal produk
+	NewPrice    Money  `gorm:"not null"`
+	Status      string `gorm:"not null;default:scheduled;index"`
+	Note        string
+	EffectiveAt time.Time `gorm:"not null;index"`
+	AppliedAt   *time.Time
+	UserID      *uint
+}
+
+type PriceChangeRequest struct {
+	Price Money `json:"price"`
+	// EffectiveAt defaults to now, which applies the price right away.
+	EffectiveAt *time.Time `json:"effective_at"`
+	Note        string     `json:"note"`
+}
+
+type PriceChangeResponse struct {
+	ID          uint       `json:"id"`
+	ProductID   uint       `json:"product_id"`
+	OldPrice    *Money     `json:"old_price"`
+	NewPrice    Money      `json:"new_price"`
+	Status      string     `json:"status"`
+	Note        string     `json:"note"`
+	EffectiveAt time.Time  `json:"effective_at"`
+	AppliedAt   *time.Time `json:"applied_at"`
+	UserID      *uint      `json:"user_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -5,28 +5,78 @@ import "gorm.io/gorm"
 // Product represents a product entity.
 type Product struct {
 	gorm.Model
-	Name        string `gorm:"not null"`
+	Name        string  `gorm:"not null"`
+	SKU         *string `gorm:"size:64;uniqueIndex"` // kosong untuk produk tanpa SKU
 	Description string
-	Price       float64  `gorm:"not null"`
+	Price       Money    `gorm:"not null"`
 	CategoryID  uint     `gorm:"not null"`
 	Category    Category // Relasi belongs to
 	SupplierID  uint     `gorm:"not null"`
 	Supplier    Supplier // Relasi belongs to
+	TaxClassID  *uint    `gorm:"index"` // kosong berarti mengikuti kategori
+	// Stok total pada atau di bawah ReorderPoint memicu pemesanan ulang sebanyak ReorderQuantity.
+	ReorderPoint    int `gorm:"not null;default:0"`
+	ReorderQuantity int `gorm:"not null;default:0"`
+	// Harga pokok, diperbarui setiap penerimaan barang; satu harga untuk semua varian produk.
+	AverageCost Money `gorm:"not null;default:0"`
+	LastCost    Money `gorm:"not null;default:0"`
+	// SEO
+	Slug            string `gorm:"size:191;index"`
+	MetaTitle       string
+	MetaDescription string
+	// Currency of Price in responses; prices are stored in the base currency.
+	Currency string `gorm:"-"`
 }
 
 type ProductRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	CategoryID  uint    `json:"category_id"`
-	SupplierID  uint    `json:"supplier_id"`
+	Name            string  `json:"name"`
+	SKU             *string `json:"sku"`
+	Description     string  `json:"description"`
+	Price           Money   `json:"price"`
+	CategoryID      uint    `json:"category_id"`
+	SupplierID      uint    `json:"supplier_id"`
+	ReorderPoint    int     `json:"reorder_point"`
+	ReorderQuantity int     `json:"reorder_quantity"`
+	// Slug defaults to one generated from the name on creation; send it to change the URL.
+	Slug            string `json:"slug"`
+	MetaTitle       string `json:"meta_title"`
+	MetaDescription string `json:"meta_description"`
+	// Attributes holds custom attribute values by attribute code; on update, null keeps the current values.
+	Attributes map[string]string `json:"attributes"`
+	// TaxClassID defaults to the tax class of the category.
+	TaxClassID *uint `json:"tax_class_id"`
+}
+
+// CatalogProductResponse is a product as customers and anonymous shoppers
+// see it, without its supplier, costs and reorder settings.
+type CatalogProductResponse struct {
+	ID              uint    `json:"id"`
+	Name            string  `json:"name"`
+	SKU             *string `json:"sku"`
+	Description     string  `json:"description"`
+	Price           Money   `json:"price"`
+	Currency        string  `json:"currency"`
+	CategoryID      uint    `json:"category_id"`
+	Slug            string  `json:"slug"`
+	MetaTitle       string  `json:"meta_title"`
+	MetaDescription string  `json:"meta_description"`
 }
 
 type ProductResponse struct {
-	ID          uint    `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	CategoryID  uint    `json:"category_id"`
-	SupplierID  uint    `json:"supplier_id"`
+	ID              uint    `json:"id"`
+	Name            string  `json:"name"`
+	SKU             *string `json:"sku"`
+	Description     string  `json:"description"`
+	Price           Money   `json:"price"`
+	Currency        string  `json:"currency"`
+	CategoryID      uint    `json:"category_id"`
+	SupplierID      uint    `json:"supplier_id"`
+	TaxClassID      *uint   `json:"tax_class_id"`
+	ReorderPoint    int     `json:"reorder_point"`
+	ReorderQuantity int     `json:"reorder_quantity"`
+	AverageCost     Money   `json:"average_cost"`
+	LastCost        Money   `json:"last_cost"`
+	Slug            string  `json:"slug"`
+	MetaTitle       string  `json:"meta_title"`
+	MetaDescription string  `json:"meta_description"`
 }
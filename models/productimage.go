@@ -0,0 +1,46 @@
+package models
+
+import "gorm.io/gorm"
+
+// ProductImage is an uploaded picture of a product. Thumbnails are stored
+// next to the original under keys derived from Key.
+type ProductImage struct {
+	gorm.Model
+	ProductID   uint   `gorm:"not null;index"`
+	Key         string `gorm:"size:191;not null"` // lokasi berkas asli di storage
+	FileName    string
+	ContentType string `gorm:"size:64;not null"`
+	Size        int64  `gorm:"not null"`
+	Width       int    `gorm:"not null"`
+	Height      int    `gorm:"not null"`
+	AltText     string
+	Position    int  `gorm:"not null;default:0"`
+	IsPrimary   bool `gorm:"not null;default:false"`
+}
+
+type ProductImageRequest struct {
+	AltText string `json:"alt_text"`
+	// Primary makes this the product's primary image.
+	Primary bool `json:"primary"`
+}
+
+type ProductImageOrderRequest struct {
+	// ImageIDs lists every image of the product in the new order.
+	ImageIDs []uint `json:"image_ids"`
+}
+
+type ProductImageResponse struct {
+	ID          uint   `json:"id"`
+	ProductID   uint   `json:"product_id"`
+	URL         string `json:"url"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	AltText     string `json:"alt_text"`
+	Position    int    `json:"position"`
+	Primary     bool   `json:"primary"`
+	// Thumbnails maps size names (small, medium, large) to URLs.
+	Thumbnails map[string]string `json:"thumbnails"`
+}
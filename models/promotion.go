@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PromotionTypePercentage   = "percentage"
+	PromotionTypeFixedAmount  = "fixed_amount"
+	PromotionTypeFreeShipping = "free_shipping"
+	PromotionTypeBuyXGetY     = "buy_x_get_y"
+)
+
+// Promotion is a discount applied when an order is priced. A promotion with
+// a code is a coupon that only applies when the order carries the code;
+// one without a code applies automatically to every order it matches.
+// Promotions restricted to products or categories only match orders for
+// those products or for products in those categories or their children.
+type Promotion struct {
+	gorm.Model
+	Name string  `gorm:"not null"`
+	Code *string `gorm:"size:64;unique"` // kosong untuk promosi otomatis
+	Type string  `gorm:"size:16;not null"`
+	// Persen untuk percentage dan buy_x_get_y, nominal dalam mata uang dasar untuk fixed_amount
+	Value       Money `gorm:"not null;default:0"`
+	BuyQuantity uint  `gorm:"not null;default:0"`
+	GetQuantity uint  `gorm:"not null;default:0"`
+	MinSpend    Money `gorm:"not null;default:0"`
+	UsageLimit  *uint // kosong berarti tanpa batas
+	StartsAt    *time.Time
+	EndsAt      *time.Time
+	Active      bool       `gorm:"not null;default:true"`
+	Products    []Product  `gorm:"many2many:promotion_products"`
+	Categories  []Category `gorm:"many2many:promotion_categories"`
+}
+
+// OrderDiscount is a promotion applied to an order, kept as it was when the
+// order was priced.
+type OrderDiscount struct {
+	gorm.Model
+	OrderID     uint   `gorm:"not null;index"`
+	PromotionID uint   `gorm:"not null;index"`
+	Code        string `gorm:"size:64"`
+	Name        string `gorm:"not null"`
+	Type        string `gorm:"size:16;not null"`
+	Amount      Money  `gorm:"not null"`
+}
+
+type PromotionRequest struct {
+	Name string `json:"name"`
+	// Code makes the promotion a coupon; leave it empty for an automatic promotion.
+	Code string `json:"code"`
+	// Type is percentage, fixed_amount, free_shipping or buy_x_get_y.
+	Type string `json:"type"`
+	// Value is the percentage off for percentage and buy_x_get_y (100 makes the
+	// free items free, the default) and the amount off for fixed_amount.
+	Value Money `json:"value"`
+	// BuyQuantity and GetQuantity make buy_x_get_y discount GetQuantity items
+	// for every BuyQuantity items paid in full.
+	BuyQuantity uint  `json:"buy_quantity"`
+	GetQuantity uint  `json:"get_quantity"`
+	MinSpend    Money `json:"min_spend"`
+	UsageLimit  *uint `json:"usage_limit"`
+	// StartsAt and EndsAt bound the validity window; either may be left out.
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+	// Active defaults to true.
+	Active      *bool  `json:"active"`
+	ProductIDs  []uint `json:"product_ids"`
+	CategoryIDs []uint `json:"category_ids"`
+}
+
+type PromotionResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	Code        *string    `json:"code"`
+	Type        string     `json:"type"`
+	Value       Money      `json:"value"`
+	BuyQuantity uint       `json:"buy_quantity"`
+	GetQuantity uint       `json:"get_quantity"`
+	MinSpend    Money      `json:"min_spend"`
+	UsageLimit  *uint      `json:"usage_limit"`
+	TimesUsed   int64      `json:"times_used"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+	Active      bool       `json:"active"`
+	ProductIDs  []uint     `json:"product_ids"`
+	CategoryIDs []uint     `json:"category_ids"`
+}
+
+type OrderDiscountResponse struct {
+	PromotionID uint   `json:"promotion_id"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Amount      Money  `json:"amount"`
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Purchase order statuses.
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusSent              = "sent"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusClosed            = "closed"
+	PurchaseOrderStatusCancelled         = "cancelled"
+)
+
+// PurchaseOrder is an order for goods placed with a supplier, delivered to a
+// warehouse.
+type PurchaseOrder struct {
+	gorm.Model
+	SupplierID  uint      `gorm:"not null;index"`
+	Supplier    Supplier  // Relasi belongs to
+	WarehouseID uint      `gorm:"not null"`
+	Warehouse   Warehouse // Relasi belongs to
+	Status      string    `gorm:"not null;default:draft;index"`
+	Note        string
+	SentAt      *time.Time
+	// Diisi saat supplier mengonfirmasi pesanan lewat portal.
+	AcknowledgedAt   *time.Time
+	AcknowledgedByID *uint
+	ReceivedAt       *time.Time
+	ClosedAt         *time.Time
+	Items            []PurchaseOrderItem // Relasi has many
+}
+
+// PurchaseOrderItem is an ordered product with the quantity received so far.
+type PurchaseOrderItem struct {
+	gorm.Model
+	PurchaseOrderID  uint    `gorm:"not null;index"`
+	ProductID        uint    `gorm:"not null"`
+	Product          Product // Relasi belongs to
+	VariantID        uint    `gorm:"not null;default:0"` // 0 untuk produk tanpa varian
+	Quantity         uint    `gorm:"not null"`
+	ReceivedQuantity uint    `gorm:"not null;default:0"`
+	UnitCost         Money   `gorm:"not null"`
+}
+
+// GoodsReceipt records a delivery received against a purchase order.
+type GoodsReceipt struct {
+	gorm.Model
+	PurchaseOrderID uint `gorm:"not null;index"`
+	WarehouseID     uint `gorm:"not null"`
+	Note            string
+	UserID          *uint
+	ReceivedAt      time.Time          `gorm:"not null"`
+	Items           []GoodsReceiptItem // Relasi has many
+}
+
+// GoodsReceiptItem holds the actually received quantity and cost of a line.
+type GoodsReceiptItem struct {
+	gorm.Model
+	GoodsReceiptID      uint  `gorm:"not null;index"`
+	PurchaseOrderItemID uint  `gorm:"not null;index"`
+	ProductID           uint  `gorm:"not null"`
+	VariantID           uint  `gorm:"not null;default:0"`
+	Quantity            uint  `gorm:"not null"`
+	UnitCost            Money `gorm:"not null"`
+}
+
+type PurchaseOrderItemRequest struct {
+	ProductID uint `json:"product_id"`
+	// VariantID selects a variant of the product; 0 for products without variants.
+	VariantID uint  `json:"variant_id"`
+	Quantity  uint  `json:"quantity"`
+	UnitCost  Money `json:"unit_cost"`
+}
+
+type PurchaseOrderRequest struct {
+	SupplierID  uint                       `json:"supplier_id"`
+	WarehouseID uint                       `json:"warehouse_id"`
+	Note        string                     `json:"note"`
+	Items       []PurchaseOrderItemRequest `json:"items"`
+}
+
+type PurchaseOrderItemResponse struct {
+	ID               uint  `json:"id"`
+	ProductID        uint  `json:"product_id"`
+	VariantID        uint  `json:"variant_id"`
+	Quantity         uint  `json:"quantity"`
+	ReceivedQuantity uint  `json:"received_quantity"`
+	UnitCost         Money `json:"unit_cost"`
+}
+
+type PurchaseOrderResponse struct {
+	ID             uint                        `json:"id"`
+	SupplierID     uint                        `json:"supplier_id"`
+	WarehouseID    uint                        `json:"warehouse_id"`
+	Status         string                      `json:"status"`
+	Note           string                      `json:"note"`
+	SentAt         *time.Time                  `json:"sent_at"`
+	AcknowledgedAt *time.Time                  `json:"acknowledged_at"`
+	ReceivedAt     *time.Time                  `json:"received_at"`
+	ClosedAt       *time.Time                  `json:"closed_at"`
+	Items          []PurchaseOrderItemResponse `json:"items"`
+}
+
+type GoodsReceiptItemRequest struct {
+	PurchaseOrderItemID uint `json:"purchase_order_item_id"`
+	Quantity            uint `json:"quantity"`
+	// UnitCost is the actual cost; when omitted the ordered unit cost is used.
+	UnitCost *Money `json:"unit_cost"`
+}
+
+type GoodsReceiptRequest struct {
+	// WarehouseID defaults to the warehouse of the purchase order.
+	WarehouseID uint                      `json:"warehouse_id"`
+	Note        string                    `json:"note"`
+	Items       []GoodsReceiptItemRequest `json:"items"`
+}
+
+type GoodsReceiptItemResponse struct {
+	PurchaseOrderItemID uint  `json:"purchase_order_item_id"`
+	ProductID           uint  `json:"product_id"`
+	VariantID           uint  `json:"variant_id"`
+	Quantity            uint  `json:"quantity"`
+	UnitCost            Money `json:"unit_cost"`
+}
+
+type GoodsReceiptResponse struct {
+	ID              uint                       `json:"id"`
+	PurchaseOrderID uint                       `json:"purchase_order_id"`
+	WarehouseID     uint                       `json:"warehouse_id"`
+	Note            string                     `json:"note"`
+	UserID          *uint                      `json:"user_id"`
+	ReceivedAt      time.Time                  `json:"received_at"`
+	Items           []GoodsReceiptItemResponse `json:"items"`
+}
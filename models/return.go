@@ -0,0 +1,141 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Return statuses.
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+	ReturnStatusReceived  = "received"
+	ReturnStatusRefunded  = "refunded"
+)
+
+// Return reason codes.
+const (
+	ReturnReasonDamaged        = "damaged"
+	ReturnReasonDefective      = "defective"
+	ReturnReasonWrongItem      = "wrong_item"
+	ReturnReasonNotAsDescribed = "not_as_described"
+	ReturnReasonNoLongerNeeded = "no_longer_needed"
+	ReturnReasonOther          = "other"
+)
+
+// ReturnReasons lists the reason codes accepted for returns.
+var ReturnReasons = []string{
+	ReturnReasonDamaged,
+	ReturnReasonDefective,
+	ReturnReasonWrongItem,
+	ReturnReasonNotAsDescribed,
+	ReturnReasonNoLongerNeeded,
+	ReturnReasonOther,
+}
+
+// OrderReturn is a request to send back some or all of the goods of an
+// order. It is approved or rejected, then received back, then refunded with
+// a credit note.
+type OrderReturn struct {
+	gorm.Model
+	OrderID      uint   `gorm:"not null;index"`
+	Order        *Order // Relasi belongs to
+	OrderItemID  uint   `gorm:"not null;index"` // baris pesanan yang barangnya dikembalikan
+	CustomerID   *uint  `gorm:"index"`          // pelanggan pemilik pesanan
+	Quantity     uint   `gorm:"not null"`
+	Reason       string `gorm:"size:32;not null"`
+	Note         string
+	Status       string `gorm:"size:16;not null;default:requested;index"`
+	DecisionNote string // catatan petugas saat menyetujui atau menolak
+	// Gudang penerima barang kembali; kosong bila barang tidak masuk stok lagi
+	WarehouseID *uint
+	Restocked   bool  `gorm:"not null;default:false"`
+	UserID      *uint // user yang mengajukan retur
+	ApprovedAt  *time.Time
+	ReceivedAt  *time.Time
+	RefundedAt  *time.Time
+	CreditNote  *CreditNote // Relasi has one
+}
+
+// CreditNote credits a customer for returned goods against the original
+// order, for at most the value of the goods, taxes included. Its total is
+// paid back through the payments of the order as far as
+// they reach; the rest is owed to the customer.
+type CreditNote struct {
+	gorm.Model
+	Number        string `gorm:"size:32;not null;uniqueIndex"`
+	OrderID       uint   `gorm:"not null;index"`
+	OrderReturnID uint   `gorm:"not null;uniqueIndex"`
+	Subtotal      Money  `gorm:"not null"` // tanpa pajak
+	TaxTotal      Money  `gorm:"not null"`
+	Total         Money  `gorm:"not null"`
+	Currency      string `gorm:"size:3;not null"`
+	// Bagian total yang dikembalikan lewat pembayaran pesanan
+	RefundedAmount Money     `gorm:"not null;default:0"`
+	Reason         string    `gorm:"size:32;not null"`
+	IssuedAt       time.Time `gorm:"not null"`
+}
+
+type OrderReturnRequest struct {
+	// OrderItemID is the line of the order whose goods are returned; it may be
+	// left out for orders of one line.
+	OrderItemID uint `json:"order_item_id"`
+	Quantity    uint `json:"quantity"`
+	// Reason is damaged, defective, wrong_item, not_as_described, no_longer_needed or other.
+	Reason string `json:"reason"`
+	Note   string `json:"note"`
+}
+
+type ReturnDecisionRequest struct {
+	Note string `json:"note"`
+}
+
+type ReturnReceiptRequest struct {
+	// WarehouseID is where the goods come back to; it defaults to the
+	// warehouse that fulfilled the order.
+	WarehouseID *uint `json:"warehouse_id"`
+	// Restock books the goods back into stock; it defaults to true. Leave it
+	// false for goods that cannot be sold again.
+	Restock *bool `json:"restock"`
+}
+
+type ReturnRefundRequest struct {
+	// Amount defaults to the value of the returned goods, taxes included,
+	// and may not exceed it.
+	Amount *Money `json:"amount"`
+}
+
+type CreditNoteResponse struct {
+	ID             uint      `json:"id"`
+	Number         string    `json:"number"`
+	OrderID        uint      `json:"order_id"`
+	ReturnID       uint      `json:"return_id"`
+	Subtotal       Money     `json:"subtotal"`
+	TaxTotal       Money     `json:"tax_total"`
+	Total          Money     `json:"total"`
+	Currency       string    `json:"currency"`
+	RefundedAmount Money     `json:"refunded_amount"`
+	Reason         string    `json:"reason"`
+	IssuedAt       time.Time `json:"issued_at"`
+}
+
+type OrderReturnResponse struct {
+	ID           uint                `json:"id"`
+	OrderID      uint                `json:"order_id"`
+	OrderItemID  uint                `json:"order_item_id"`
+	CustomerID   *uint               `json:"customer_id"`
+	Quantity     uint                `json:"quantity"`
+	Reason       string              `json:"reason"`
+	Note         string              `json:"note"`
+	Status       string              `json:"status"`
+	DecisionNote string              `json:"decision_note"`
+	WarehouseID  *uint               `json:"warehouse_id"`
+	Restocked    bool                `json:"restocked"`
+	CreatedAt    time.Time           `json:"created_at"`
+	ApprovedAt   *time.Time          `json:"approved_at"`
+	ReceivedAt   *time.Time          `json:"received_at"`
+	RefundedAt   *time.Time          `json:"refunded_at"`
+	CreditNote   *CreditNoteResponse `json:"credit_note"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "gorm.io/gorm"
+
+// Slug owners.
+const (
+	SlugTypeProduct  = "product"
+	SlugTypeCategory = "category"
+)
+
+// Slug registers every slug a product or category has ever had. The current
+// one is also stored on the entity; old ones are kept so that their URLs can
+// be redirected.
+type Slug struct {
+	gorm.Model
+	EntityType string `gorm:"not null;size:32;uniqueIndex:idx_slug"`
+	Slug       string `gorm:"not null;size:191;uniqueIndex:idx_slug"`
+	EntityID   uint   `gorm:"not null;index"`
+	IsCurrent  bool   `gorm:"not null;default:false"`
+}
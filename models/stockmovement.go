@@ -0,0 +1,102 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Stock movement types.
+const (
+	MovementTypeReceipt    = "receipt"
+	MovementTypeSale       = "sale"
+	MovementTypeReturn     = "return"
+	MovementTypeAdjustment = "adjustment"
+	MovementTypeTransfer   = "transfer"
+)
+
+// Adjustment reason codes.
+const (
+	ReasonOpeningBalance = "opening_balance"
+	ReasonStockCount     = "stock_count"
+	ReasonDamaged        = "damaged"
+	ReasonLost           = "lost"
+	ReasonFound          = "found"
+	ReasonExpired        = "expired"
+	ReasonOther          = "other"
+)
+
+// AdjustmentReasons lists the reason codes accepted for manual adjustments.
+var AdjustmentReasons = []string{
+	ReasonStockCount,
+	ReasonDamaged,
+	ReasonLost,
+	ReasonFound,
+	ReasonExpired,
+	ReasonOther,
+}
+
+var ErrStockMovementImmutable = errors.New("stock movements are append-only")
+
+// StockMovement is an append-only ledger entry recording a change of the
+// quantity of a product in a warehouse.
+type StockMovement struct {
+	gorm.Model
+	WarehouseID     uint   `gorm:"not null;index"`
+	ProductID       uint   `gorm:"not null;index"`
+	VariantID       uint   `gorm:"not null;default:0;index"`
+	Type            string `gorm:"not null;index"`
+	Quantity        int    `gorm:"not null"` // positif masuk, negatif keluar
+	BalanceAfter    int    `gorm:"not null"`
+	ReasonCode      string
+	Note            string
+	OrderID         *uint `gorm:"index"`
+	PurchaseOrderID *uint `gorm:"index"`
+	StockTransferID *uint `gorm:"index"`
+	UserID          *uint
+	OccurredAt      time.Time `gorm:"not null;index"`
+}
+
+// BeforeUpdate keeps the ledger append-only.
+func (m *StockMovement) BeforeUpdate(tx *gorm.DB) error {
+	return ErrStockMovementImmutable
+}
+
+// BeforeDelete keeps the ledger append-only.
+func (m *StockMovement) BeforeDelete(tx *gorm.DB) error {
+	return ErrStockMovementImmutable
+}
+
+type StockMovementResponse struct {
+	ID              uint      `json:"id"`
+	WarehouseID     uint      `json:"warehouse_id"`
+	ProductID       uint      `json:"product_id"`
+	VariantID       uint      `json:"variant_id"`
+	Type            string    `json:"type"`
+	Quantity        int       `json:"quantity"`
+	BalanceAfter    int       `json:"balance_after"`
+	ReasonCode      string    `json:"reason_code"`
+	Note            string    `json:"note"`
+	OrderID         *uint     `json:"order_id"`
+	PurchaseOrderID *uint     `json:"purchase_order_id"`
+	StockTransferID *uint     `json:"stock_transfer_id"`
+	UserID          *uint     `json:"user_id"`
+	OccurredAt      time.Time `json:"occurred_at"`
+}
+
+type StockAdjustmentRequest struct {
+	WarehouseID uint   `json:"warehouse_id"`
+	ProductID   uint   `json:"product_id"`
+	VariantID   uint   `json:"variant_id"`
+	Quantity    int    `json:"quantity"` // selisih, boleh negatif
+	ReasonCode  string `json:"reason_code"`
+	Note        string `json:"note"`
+}
+
+// StockOnDateResponse is the reconstructed quantity of a product at a point in time.
+type StockOnDateResponse struct {
+	ProductID   uint  `json:"product_id"`
+	WarehouseID *uint `json:"warehouse_id,omitempty"`
+	Quantity    int   `json:"quantity"`
+}
@@ -5,17 +5,126 @@ import "gorm.io/gorm"
 // Supplier represents a supplier entity.
 type Supplier struct {
 	gorm.Model
-	Name  string `gorm:"unique;not null"`
-	Email string `gorm:"unique;not null"`
+	Name            string `gorm:"unique;not null"`
+	Email           string `gorm:"unique;not null"`
+	Phone           string
+	TaxID           string            // NPWP
+	PaymentTermDays int               `gorm:"not null;default:0"`
+	Contacts        []SupplierContact // Relasi has many
+	Addresses       []SupplierAddress // Relasi has many
 }
 
 type SupplierRequest struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	Phone           string `json:"phone"`
+	TaxID           string `json:"tax_id"`
+	PaymentTermDays int    `json:"payment_term_days"`
 }
 
 type SupplierResponse struct {
-	ID    uint   `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID              uint   `json:"id"`
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	Phone           string `json:"phone"`
+	TaxID           string `json:"tax_id"`
+	PaymentTermDays int    `json:"payment_term_days"`
+}
+
+// SupplierContact is a person to reach at a supplier.
+type SupplierContact struct {
+	gorm.Model
+	SupplierID uint   `gorm:"not null;index"`
+	Name       string `gorm:"not null"`
+	Position   string
+	Email      string
+	Phone      string
+	IsPrimary  bool `gorm:"not null;default:false"`
+}
+
+type SupplierContactRequest struct {
+	Name     string `json:"name"`
+	Position string `json:"position"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Primary  bool   `json:"primary"`
+}
+
+type SupplierContactResponse struct {
+	ID         uint   `json:"id"`
+	SupplierID uint   `json:"supplier_id"`
+	Name       string `json:"name"`
+	Position   string `json:"position"`
+	Email      string `json:"email"`
+	Phone      string `json:"phone"`
+	Primary    bool   `json:"primary"`
+}
+
+// SupplierAddress is an office, billing or pick-up address of a supplier.
+type SupplierAddress struct {
+	gorm.Model
+	SupplierID uint   `gorm:"not null;index"`
+	Type       string `gorm:"not null;default:office"` // office, billing, pickup
+	Street     string `gorm:"not null"`
+	City       string `gorm:"not null"`
+	Province   string
+	PostalCode string
+	Country    string `gorm:"not null;default:ID"`
+}
+
+type SupplierAddressRequest struct {
+	Type       string `json:"type"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	Province   string `json:"province"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+type SupplierAddressResponse struct {
+	ID         uint   `json:"id"`
+	SupplierID uint   `json:"supplier_id"`
+	Type       string `json:"type"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	Province   string `json:"province"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+// ProductSupplier links a product to one of the suppliers it can be bought
+// from. The preferred supplier is mirrored in Product.SupplierID.
+type ProductSupplier struct {
+	gorm.Model
+	ProductID   uint     `gorm:"not null;uniqueIndex:idx_product_supplier"`
+	SupplierID  uint     `gorm:"not null;uniqueIndex:idx_product_supplier"`
+	Supplier    Supplier // Relasi belongs to
+	SupplierSKU string
+	Preferred   bool `gorm:"not null;default:false"`
+}
+
+type ProductSupplierRequest struct {
+	SupplierID  uint   `json:"supplier_id"`
+	SupplierSKU string `json:"supplier_sku"`
+	Preferred   bool   `json:"preferred"`
+}
+
+type ProductSupplierResponse struct {
+	ProductID    uint   `json:"product_id"`
+	ProductName  string `json:"product_name,omitempty"`
+	SupplierID   uint   `json:"supplier_id"`
+	SupplierName string `json:"supplier_name"`
+	SupplierSKU  string `json:"supplier_sku"`
+	Preferred    bool   `json:"preferred"`
+}
+
+// PortalProductRequest is the supplier data a supplier may maintain itself.
+type PortalProductRequest struct {
+	SupplierSKU string `json:"supplier_sku"`
+}
+
+// PriceListImportResponse reports the outcome of a price list upload.
+type PriceListImportResponse struct {
+	Imported int      `json:"imported"`
+	Errors   []string `json:"errors,omitempty"`
 }
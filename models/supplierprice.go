@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SupplierPrice is the cost price a supplier charges for a product within a
+// validity period.
+type SupplierPrice struct {
+	gorm.Model
+	SupplierID       uint      `gorm:"not null;index"`
+	Supplier         Supplier  // Relasi belongs to
+	ProductID        uint      `gorm:"not null;index"`
+	Product          Product   // Relasi belongs to
+	UnitCost         Money     `gorm:"not null"`
+	MinOrderQuantity uint      `gorm:"not null;default:1"`
+	ValidFrom        time.Time `gorm:"not null"`
+	ValidTo          *time.Time
+}
+
+type SupplierPriceRequest struct {
+	ProductID        uint  `json:"product_id"`
+	UnitCost         Money `json:"unit_cost"`
+	MinOrderQuantity uint  `json:"min_order_quantity"`
+	// ValidFrom and ValidTo are dates (YYYY-MM-DD); ValidTo is inclusive and optional.
+	ValidFrom string `json:"valid_from"`
+	ValidTo   string `json:"valid_to"`
+}
+
+type SupplierPriceResponse struct {
+	ID               uint       `json:"id"`
+	SupplierID       uint       `json:"supplier_id"`
+	ProductID        uint       `json:"product_id"`
+	UnitCost         Money      `json:"unit_cost"`
+	MinOrderQuantity uint       `json:"min_order_quantity"`
+	ValidFrom        time.Time  `json:"valid_from"`
+	ValidTo          *time.Time `json:"valid_to"`
+}
+
+// MarginReportRow is the sales margin of one product, category or supplier.
+type MarginReportRow struct {
+	ID            uint    `json:"id"`
+	Name          string  `json:"name"`
+	Quantity      uint    `json:"quantity"`
+	Revenue       Money   `json:"revenue"`
+	Cost          Money   `json:"cost"`
+	Margin        Money   `json:"margin"`
+	MarginPercent float64 `json:"margin_percent"`
+}
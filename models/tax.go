@@ -0,0 +1,81 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// TaxClass groups products taxed the same way, such as standard rated or
+// exempt goods. Products use the class of their own, of their category or
+// of the nearest parent category that has one, and otherwise the default
+// class.
+type TaxClass struct {
+	gorm.Model
+	Name        string `gorm:"size:64;unique;not null"`
+	Description string
+	IsDefault   bool      `gorm:"not null;default:false"`
+	Rates       []TaxRate // Relasi has many
+}
+
+// TaxRate is a tax levied on a class in a region. Region is an ISO 3166
+// country code such as ID, optionally with a subdivision such as ID-JK, or
+// empty for every region; the most specific region with rates wins, and
+// all rates of that region apply.
+type TaxRate struct {
+	gorm.Model
+	TaxClassID uint            `gorm:"not null;index"`
+	Region     string          `gorm:"size:16;not null;default:'';index"`
+	Name       string          `gorm:"not null"`                   // misalnya PPN
+	Rate       decimal.Decimal `gorm:"type:decimal(7,4);not null"` // persen
+}
+
+// OrderTax is one tax charged on the line of an order, kept as it was
+// calculated when the order was priced.
+type OrderTax struct {
+	gorm.Model
+	OrderID       uint            `gorm:"not null;index"`
+	TaxRateID     uint            `gorm:"not null"`
+	Name          string          `gorm:"not null"`
+	Region        string          `gorm:"size:16;not null"`
+	Rate          decimal.Decimal `gorm:"type:decimal(7,4);not null"`
+	TaxableAmount Money           `gorm:"not null"`
+	Amount        Money           `gorm:"not null"`
+}
+
+type TaxClassRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Default makes this the class of products and categories without one.
+	Default bool `json:"default"`
+}
+
+type TaxRateRequest struct {
+	Region string `json:"region"`
+	Name   string `json:"name"`
+	// Rate is a percentage, for example 11 for 11%.
+	Rate decimal.Decimal `json:"rate" swaggertype:"string"`
+}
+
+type TaxRateResponse struct {
+	ID         uint            `json:"id"`
+	TaxClassID uint            `json:"tax_class_id"`
+	Region     string          `json:"region"`
+	Name       string          `json:"name"`
+	Rate       decimal.Decimal `json:"rate" swaggertype:"string"`
+}
+
+type TaxClassResponse struct {
+	ID          uint              `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Default     bool              `json:"default"`
+	Rates       []TaxRateResponse `json:"rates"`
+}
+
+type OrderTaxResponse struct {
+	Name          string          `json:"name"`
+	Region        string          `json:"region"`
+	Rate          decimal.Decimal `json:"rate" swaggertype:"string"`
+	TaxableAmount Money           `json:"taxable_amount"`
+	Amount        Money           `json:"amount"`
+}
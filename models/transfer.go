@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Stock transfer statuses.
+const (
+	TransferStatusDraft     = "draft"
+	TransferStatusInTransit = "in_transit"
+	TransferStatusReceived  = "received"
+	TransferStatusCancelled = "cancelled"
+)
+
+// StockTransfer represents a movement of goods between two warehouses.
+type StockTransfer struct {
+	gorm.Model
+	FromWarehouseID uint      `gorm:"not null"`
+	FromWarehouse   Warehouse // Relasi belongs to
+	ToWarehouseID   uint      `gorm:"not null"`
+	ToWarehouse     Warehouse // Relasi belongs to
+	Status          string    `gorm:"not null;default:draft"`
+	Note            string
+	ShippedAt       *time.Time
+	ReceivedAt      *time.Time
+	Items           []StockTransferItem // Relasi has many
+}
+
+// StockTransferItem is a single product line on a stock transfer.
+type StockTransferItem struct {
+	gorm.Model
+	StockTransferID uint    `gorm:"not null"`
+	ProductID       uint    `gorm:"not null"`
+	Product         Product // Relasi belongs to
+	VariantID       uint    `gorm:"not null;default:0"` // 0 untuk produk tanpa varian
+	Quantity        uint    `gorm:"not null"`
+}
+
+type StockTransferItemRequest struct {
+	ProductID uint `json:"product_id"`
+	// VariantID selects a variant of the product; 0 for products without variants.
+	VariantID uint `json:"variant_id"`
+	Quantity  uint `json:"quantity"`
+}
+
+type StockTransferRequest struct {
+	FromWarehouseID uint                       `json:"from_warehouse_id"`
+	ToWarehouseID   uint                       `json:"to_warehouse_id"`
+	Note            string                     `json:"note"`
+	Items           []StockTransferItemRequest `json:"items"`
+}
+
+type StockTransferItemResponse struct {
+	ProductID uint `json:"product_id"`
+	VariantID uint `json:"variant_id"`
+	Quantity  uint `json:"quantity"`
+}
+
+type StockTransferResponse struct {
+	ID              uint                        `json:"id"`
+	FromWarehouseID uint                        `json:"from_warehouse_id"`
+	ToWarehouseID   uint                        `json:"to_warehouse_id"`
+	Status          string                      `json:"status"`
+	Note            string                      `json:"note"`
+	ShippedAt       *time.Time                  `json:"shipped_at"`
+	ReceivedAt      *time.Time                  `json:"received_at"`
+	Items           []StockTransferItemResponse `json:"items"`
+}
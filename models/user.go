@@ -4,10 +4,32 @@ import (
 	"gorm.io/gorm"
 )
 
+// User roles.
+const (
+	RoleStaff    = "staff"
+	RoleSupplier = "supplier"
+	RoleCustomer = "customer"
+)
+
 type User struct {
 	gorm.Model
 	Username string `json:"username" gorm:"uniqueIndex;not null"`
 	Password string `json:"password" gorm:"not null"`
+	Role     string `json:"role" gorm:"not null;default:staff"`
+	// SupplierID links a supplier portal user to its supplier.
+	SupplierID *uint     `json:"supplier_id"`
+	Supplier   *Supplier `json:"-"` // Relasi belongs to
+}
+
+type SupplierUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type SupplierUserResponse struct {
+	ID         uint   `json:"id"`
+	Username   string `json:"username"`
+	SupplierID uint   `json:"supplier_id"`
 }
 
 type RegisterRequest struct {
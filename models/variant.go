@@ -0,0 +1,78 @@
+package models
+
+import "gorm.io/gorm"
+
+// OptionType is a dimension products vary along, such as size or colour.
+type OptionType struct {
+	gorm.Model
+	Name   string        `gorm:"size:191;unique;not null"`
+	Values []OptionValue // Relasi has many
+}
+
+// OptionValue is one choice of an option type, such as XL or red.
+type OptionValue struct {
+	gorm.Model
+	OptionTypeID uint   `gorm:"not null;index"`
+	Value        string `gorm:"not null"`
+	Position     int    `gorm:"not null;default:0"`
+}
+
+// ProductVariant is a sellable version of a product with its own SKU and
+// stock. Stock rows and movements reference it through VariantID.
+type ProductVariant struct {
+	gorm.Model
+	ProductID uint          `gorm:"not null;index"`
+	Product   Product       // Relasi belongs to
+	SKU       string        `gorm:"size:64;unique;not null"`
+	Barcode   string        `gorm:"size:64;index"`
+	Price     *Money        // kosong berarti memakai harga produk
+	Options   []OptionValue `gorm:"many2many:variant_option_values"`
+}
+
+// EffectivePrice returns the variant's price override or the product price.
+func (v ProductVariant) EffectivePrice(product Product) Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+type OptionTypeRequest struct {
+	Name string `json:"name"`
+	// Values replaces the list of values, in display order.
+	Values []string `json:"values"`
+}
+
+type OptionValueResponse struct {
+	ID           uint   `json:"id"`
+	OptionTypeID uint   `json:"option_type_id"`
+	OptionType   string `json:"option_type,omitempty"`
+	Value        string `json:"value"`
+}
+
+type OptionTypeResponse struct {
+	ID     uint                  `json:"id"`
+	Name   string                `json:"name"`
+	Values []OptionValueResponse `json:"values"`
+}
+
+type ProductVariantRequest struct {
+	SKU     string `json:"sku"`
+	Barcode string `json:"barcode"`
+	Price   *Money `json:"price"`
+	// OptionValueIDs holds at most one value per option type.
+	OptionValueIDs []uint `json:"option_value_ids"`
+}
+
+type ProductVariantResponse struct {
+	ID        uint   `json:"id"`
+	ProductID uint   `json:"product_id"`
+	SKU       string `json:"sku"`
+	Barcode   string `json:"barcode"`
+	Price     *Money `json:"price"`
+	// EffectivePrice is the override or, without one, the product price.
+	EffectivePrice Money `json:"effective_price"`
+	// Stock is the total stock of the variant, shown to staff only.
+	Stock   *int                  `json:"stock,omitempty"`
+	Options []OptionValueResponse `json:"options"`
+}
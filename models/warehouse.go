@@ -0,0 +1,61 @@
+package models
+
+import "gorm.io/gorm"
+
+// Warehouse represents a stock location.
+type Warehouse struct {
+	gorm.Model
+	Code      string `gorm:"unique;not null"`
+	Name      string `gorm:"not null"`
+	Address   string
+	Latitude  float64
+	Longitude float64
+	Priority  int  `gorm:"not null;default:0"` // semakin kecil semakin diutamakan
+	Active    bool `gorm:"not null;default:true"`
+}
+
+type WarehouseRequest struct {
+	Code      string  `json:"code"`
+	Name      string  `json:"name"`
+	Address   string  `json:"address"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Priority  int     `json:"priority"`
+	Active    *bool   `json:"active"`
+}
+
+type WarehouseResponse struct {
+	ID        uint    `json:"id"`
+	Code      string  `json:"code"`
+	Name      string  `json:"name"`
+	Address   string  `json:"address"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Priority  int     `json:"priority"`
+	Active    bool    `json:"active"`
+}
+
+// WarehouseStock holds the on-hand quantity of a product, or of one of its
+// variants, in a warehouse. VariantID is 0 for products without variants.
+type WarehouseStock struct {
+	gorm.Model
+	WarehouseID uint      `gorm:"not null;uniqueIndex:idx_warehouse_product_variant"`
+	Warehouse   Warehouse // Relasi belongs to
+	ProductID   uint      `gorm:"not null;uniqueIndex:idx_warehouse_product_variant"`
+	Product     Product   // Relasi belongs to
+	VariantID   uint      `gorm:"not null;default:0;uniqueIndex:idx_warehouse_product_variant"`
+	Quantity    int       `gorm:"not null;default:0"`
+}
+
+type WarehouseStockRequest struct {
+	ProductID uint `json:"product_id"`
+	VariantID uint `json:"variant_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+type WarehouseStockResponse struct {
+	WarehouseID uint `json:"warehouse_id"`
+	ProductID   uint `json:"product_id"`
+	VariantID   uint `json:"variant_id"`
+	Quantity    int  `json:"quantity"`
+}
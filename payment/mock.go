@@ -0,0 +1,225 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+)
+
+// Tokens the mock provider treats specially; any other token is authorized.
+const (
+	MockTokenDecline = "tok_decline"
+	MockTokenPending = "tok_pending"
+)
+
+// MockSignatureHeader carries the signature of the webhooks of the mock
+// provider: the hex-encoded HMAC-SHA256 of the body keyed with the secret.
+const MockSignatureHeader = "X-Mock-Signature"
+
+type mockPayment struct {
+	status     string
+	authorized models.Money
+	captured   models.Money
+	refunded   models.Money
+}
+
+// MockRecord is what the caller has on record about a payment of the mock
+// provider.
+type MockRecord struct {
+	Status     string
+	Authorized models.Money
+	Captured   models.Money
+	Refunded   models.Money
+}
+
+// Mock is a payment provider that never leaves the process, for development
+// and tests. It keeps its payments in memory; payments taken before a
+// restart can still be captured, settled and refunded afterwards when the
+// mock is given the records of the caller with RestoreFrom.
+type Mock struct {
+	secret   string
+	mu       sync.Mutex
+	payments map[string]*mockPayment
+	records  func(reference string) (MockRecord, error)
+}
+
+// NewMock returns a mock provider signing its webhooks with secret. Without
+// a secret no webhook is accepted.
+func NewMock(secret string) *Mock {
+	return &Mock{secret: secret, payments: make(map[string]*mockPayment)}
+}
+
+// RestoreFrom has the mock rebuild the payments it no longer has in memory
+// from the records of the caller, which are the source of truth for them.
+// records returns an error for references the caller does not know.
+func (m *Mock) RestoreFrom(records func(reference string) (MockRecord, error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = records
+}
+
+func (m *Mock) Name() string {
+	return "mock"
+}
+
+func mockID(prefix string) string {
+	random := make([]byte, 12)
+	rand.Read(random)
+	return prefix + hex.EncodeToString(random)
+}
+
+// lookup returns a payment of the mock. A payment it no longer has in memory
+// is rebuilt from the records of the caller, with the status and amounts
+// recorded there.
+func (m *Mock) lookup(reference string) (*mockPayment, error) {
+	if p, ok := m.payments[reference]; ok {
+		return p, nil
+	}
+	if m.records == nil {
+		return nil, ErrUnknownPayment
+	}
+	record, err := m.records(reference)
+	if err != nil {
+		return nil, ErrUnknownPayment
+	}
+	p := &mockPayment{
+		status:     record.Status,
+		authorized: record.Authorized,
+		captured:   record.Captured,
+		refunded:   record.Refunded,
+	}
+	m.payments[reference] = p
+	return p, nil
+}
+
+func (m *Mock) Authorize(ctx context.Context, req AuthorizeRequest) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := Result{Reference: mockID("mock_pay_"), Status: StatusAuthorized}
+	switch req.Token {
+	case MockTokenDecline:
+		result.Status = StatusFailed
+		result.FailureReason = "card declined"
+	case MockTokenPending:
+		result.Status = StatusPending
+	}
+	m.payments[result.Reference] = &mockPayment{status: result.Status, authorized: req.Amount}
+	return result, nil
+}
+
+func (m *Mock) Capture(ctx context.Context, reference string, amount models.Money) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, err := m.lookup(reference)
+	if err != nil {
+		return Result{}, err
+	}
+	if p.status != StatusAuthorized {
+		return Result{}, ErrDeclined
+	}
+	if amount.GreaterThan(p.authorized.Decimal) {
+		return Result{}, ErrInvalidAmount
+	}
+	p.status = StatusCaptured
+	p.captured = amount
+	return Result{Reference: reference, Status: p.status}, nil
+}
+
+func (m *Mock) Void(ctx context.Context, reference string) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, err := m.lookup(reference)
+	if err != nil {
+		return Result{}, err
+	}
+	if p.status != StatusAuthorized {
+		return Result{}, ErrDeclined
+	}
+	p.status = StatusVoided
+	return Result{Reference: reference, Status: p.status}, nil
+}
+
+func (m *Mock) Refund(ctx context.Context, reference string, amount models.Money) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, err := m.lookup(reference)
+	if err != nil {
+		return Result{}, err
+	}
+	if p.status != StatusCaptured {
+		return Result{}, ErrDeclined
+	}
+	if p.refunded.Add(amount).GreaterThan(p.captured.Decimal) {
+		return Result{}, ErrInvalidAmount
+	}
+	p.refunded = p.refunded.Add(amount)
+	return Result{Reference: reference, Status: p.status}, nil
+}
+
+// mockEvent is the body of the webhooks of the mock provider.
+type mockEvent struct {
+	ID            string       `json:"id"`
+	Type          string       `json:"type"`
+	Reference     string       `json:"reference"`
+	Amount        models.Money `json:"amount"`
+	FailureReason string       `json:"failure_reason,omitempty"`
+}
+
+// Sign returns the signature of a webhook body.
+func (m *Mock) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(m.secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (m *Mock) VerifyWebhook(header func(key string) string, body []byte) (Event, error) {
+	signature, err := hex.DecodeString(header(MockSignatureHeader))
+	if m.secret == "" || err != nil {
+		return Event{}, ErrInvalidSignature
+	}
+	expected, _ := hex.DecodeString(m.Sign(body))
+	if !hmac.Equal(signature, expected) {
+		return Event{}, ErrInvalidSignature
+	}
+	var event mockEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return Event{}, err
+	}
+	return Event(event), nil
+}
+
+// Settle decides a pending payment the way a gateway settling
+// asynchronously would, capturing it in full or failing it, and returns the
+// signed webhook the gateway would send about it.
+func (m *Mock) Settle(reference string, succeed bool) (body []byte, signature string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, err := m.lookup(reference)
+	if err != nil {
+		return nil, "", err
+	}
+	if p.status != StatusPending {
+		return nil, "", ErrDeclined
+	}
+	event := mockEvent{ID: mockID("mock_evt_"), Reference: reference}
+	if succeed {
+		p.status = StatusCaptured
+		p.captured = p.authorized
+		event.Type = EventCaptured
+		event.Amount = p.captured
+	} else {
+		p.status = StatusFailed
+		event.Type = EventFailed
+		event.FailureReason = "payment not completed"
+	}
+	if body, err = json.Marshal(event); err != nil {
+		return nil, "", err
+	}
+	return body, m.Sign(body), nil
+}
@@ -0,0 +1,193 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+)
+
+func mustAuthorize(t *testing.T, m *Mock, token string, amount int64) Result {
+	t.Helper()
+	result, err := m.Authorize(context.Background(), AuthorizeRequest{OrderID: 1, Amount: models.NewMoney(amount), Currency: "IDR", Token: token})
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	return result
+}
+
+func TestMockAuthorize(t *testing.T) {
+	m := NewMock("secret")
+	tests := []struct {
+		token  string
+		status string
+	}{
+		{"tok_visa", StatusAuthorized},
+		{MockTokenDecline, StatusFailed},
+		{MockTokenPending, StatusPending},
+	}
+	for _, tt := range tests {
+		result := mustAuthorize(t, m, tt.token, 100)
+		if result.Status != tt.status {
+			t.Errorf("token %s: status %s, want %s", tt.token, result.Status, tt.status)
+		}
+		if result.Reference == "" {
+			t.Errorf("token %s: no reference", tt.token)
+		}
+	}
+}
+
+func TestMockCaptureAndRefund(t *testing.T) {
+	ctx := context.Background()
+	m := NewMock("secret")
+	ref := mustAuthorize(t, m, "tok_visa", 100).Reference
+
+	if _, err := m.Refund(ctx, ref, models.NewMoney(10)); !errors.Is(err, ErrDeclined) {
+		t.Fatalf("refund before capture: %v, want ErrDeclined", err)
+	}
+	if _, err := m.Capture(ctx, ref, models.NewMoney(150)); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("capture above authorized: %v, want ErrInvalidAmount", err)
+	}
+	result, err := m.Capture(ctx, ref, models.NewMoney(100))
+	if err != nil || result.Status != StatusCaptured {
+		t.Fatalf("capture: %v, %s", err, result.Status)
+	}
+	if _, err := m.Capture(ctx, ref, models.NewMoney(100)); !errors.Is(err, ErrDeclined) {
+		t.Fatalf("second capture: %v, want ErrDeclined", err)
+	}
+	if _, err := m.Refund(ctx, ref, models.NewMoney(60)); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if _, err := m.Refund(ctx, ref, models.NewMoney(50)); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("refund above captured: %v, want ErrInvalidAmount", err)
+	}
+	if _, err := m.Refund(ctx, ref, models.NewMoney(40)); err != nil {
+		t.Fatalf("refund of the rest: %v", err)
+	}
+	if _, err := m.Capture(ctx, "mock_pay_unknown", models.NewMoney(1)); !errors.Is(err, ErrUnknownPayment) {
+		t.Fatalf("capture of unknown payment: %v, want ErrUnknownPayment", err)
+	}
+}
+
+func TestMockVoid(t *testing.T) {
+	ctx := context.Background()
+	m := NewMock("secret")
+	ref := mustAuthorize(t, m, "tok_visa", 100).Reference
+
+	result, err := m.Void(ctx, ref)
+	if err != nil || result.Status != StatusVoided {
+		t.Fatalf("void: %v, %s", err, result.Status)
+	}
+	if _, err := m.Capture(ctx, ref, models.NewMoney(100)); !errors.Is(err, ErrDeclined) {
+		t.Fatalf("capture after void: %v, want ErrDeclined", err)
+	}
+}
+
+func TestMockAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	authorized := mustAuthorize(t, NewMock("secret"), "tok_visa", 100).Reference
+	captured := mustAuthorize(t, NewMock("secret"), "tok_visa", 80).Reference
+	pending := mustAuthorize(t, NewMock("secret"), MockTokenPending, 50).Reference
+	voided := mustAuthorize(t, NewMock("secret"), "tok_visa", 30).Reference
+	records := map[string]MockRecord{
+		authorized: {Status: StatusAuthorized, Authorized: models.NewMoney(100)},
+		captured:   {Status: StatusCaptured, Authorized: models.NewMoney(80), Captured: models.NewMoney(80), Refunded: models.NewMoney(20)},
+		pending:    {Status: StatusPending, Authorized: models.NewMoney(50)},
+		voided:     {Status: StatusVoided, Authorized: models.NewMoney(30)},
+	}
+
+	m := NewMock("secret")
+	if _, err := m.Capture(ctx, authorized, models.NewMoney(100)); !errors.Is(err, ErrUnknownPayment) {
+		t.Fatalf("capture after restart without records: %v, want ErrUnknownPayment", err)
+	}
+	m.RestoreFrom(func(reference string) (MockRecord, error) {
+		record, ok := records[reference]
+		if !ok {
+			return MockRecord{}, errors.New("not found")
+		}
+		return record, nil
+	})
+	if _, err := m.Capture(ctx, "mock_pay_unknown", models.NewMoney(1)); !errors.Is(err, ErrUnknownPayment) {
+		t.Fatalf("capture of unrecorded payment: %v, want ErrUnknownPayment", err)
+	}
+	if _, err := m.Capture(ctx, voided, models.NewMoney(30)); !errors.Is(err, ErrDeclined) {
+		t.Fatalf("capture of voided payment after restart: %v, want ErrDeclined", err)
+	}
+	if _, err := m.Capture(ctx, authorized, models.NewMoney(120)); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("capture above authorized after restart: %v, want ErrInvalidAmount", err)
+	}
+	if _, err := m.Capture(ctx, authorized, models.NewMoney(100)); err != nil {
+		t.Fatalf("capture after restart: %v", err)
+	}
+	if _, err := m.Refund(ctx, captured, models.NewMoney(80)); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("refund above what is left after restart: %v, want ErrInvalidAmount", err)
+	}
+	if _, err := m.Refund(ctx, captured, models.NewMoney(60)); err != nil {
+		t.Fatalf("refund after restart: %v", err)
+	}
+	if _, _, err := m.Settle(pending, true); err != nil {
+		t.Fatalf("settle after restart: %v", err)
+	}
+}
+
+func TestMockSettle(t *testing.T) {
+	m := NewMock("secret")
+	ref := mustAuthorize(t, m, MockTokenPending, 100).Reference
+
+	body, signature, err := m.Settle(ref, true)
+	if err != nil {
+		t.Fatalf("settle: %v", err)
+	}
+	event, err := m.VerifyWebhook(func(string) string { return signature }, body)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if event.Type != EventCaptured || event.Reference != ref || !event.Amount.Equal(models.NewMoney(100)) {
+		t.Fatalf("event %+v", event)
+	}
+	if _, _, err := m.Settle(ref, true); !errors.Is(err, ErrDeclined) {
+		t.Fatalf("second settle: %v, want ErrDeclined", err)
+	}
+	if _, err := m.Refund(context.Background(), ref, models.NewMoney(100)); err != nil {
+		t.Fatalf("refund of settled payment: %v", err)
+	}
+
+	failed := mustAuthorize(t, m, MockTokenPending, 100).Reference
+	body, signature, err = m.Settle(failed, false)
+	if err != nil {
+		t.Fatalf("settle failed: %v", err)
+	}
+	event, err = m.VerifyWebhook(func(string) string { return signature }, body)
+	if err != nil || event.Type != EventFailed || event.FailureReason == "" {
+		t.Fatalf("failed event %+v, %v", event, err)
+	}
+}
+
+func TestMockVerifyWebhook(t *testing.T) {
+	m := NewMock("secret")
+	body := []byte(`{"id":"evt_1","type":"payment.refunded","reference":"mock_pay_1","amount":"25"}`)
+
+	event, err := m.VerifyWebhook(func(string) string { return m.Sign(body) }, body)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if event.ID != "evt_1" || event.Type != EventRefunded || !event.Amount.Equal(models.NewMoney(25)) {
+		t.Fatalf("event %+v", event)
+	}
+
+	tests := map[string]struct {
+		mock      *Mock
+		signature string
+	}{
+		"no signature":    {m, ""},
+		"not hex":         {m, "zz"},
+		"wrong signature": {m, NewMock("other").Sign(body)},
+		"no secret":       {NewMock(""), NewMock("").Sign(body)},
+	}
+	for name, tt := range tests {
+		if _, err := tt.mock.VerifyWebhook(func(string) string { return tt.signature }, body); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: %v, want ErrInvalidSignature", name, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package payment takes payments for orders through a payment provider such
+// as a card gateway. Only a local mock provider is built in; real gateways
+// implement Provider.
+package payment
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+)
+
+var (
+	ErrDeclined         = errors.New("payment declined")
+	ErrUnknownPayment   = errors.New("payment not known to the provider")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidAmount    = errors.New("amount exceeds what the payment allows")
+)
+
+// Statuses a provider reports for a payment.
+const (
+	StatusPending    = "pending" // hasil menyusul lewat webhook
+	StatusAuthorized = "authorized"
+	StatusCaptured   = "captured"
+	StatusFailed     = "failed"
+	StatusVoided     = "voided"
+)
+
+// Types of the events providers send to the webhook.
+const (
+	EventAuthorized = "payment.authorized"
+	EventCaptured   = "payment.captured"
+	EventFailed     = "payment.failed"
+	EventRefunded   = "payment.refunded"
+)
+
+// AuthorizeRequest asks a provider to reserve an amount on a payment method.
+type AuthorizeRequest struct {
+	OrderID  uint
+	Amount   models.Money
+	Currency string
+	// Token is the payment method as tokenised by the provider on the client.
+	Token string
+}
+
+// Result is the outcome of a call to a provider.
+type Result struct {
+	// Reference identifies the payment at the provider.
+	Reference     string
+	Status        string
+	FailureReason string
+}
+
+// Event is a verified callback of a provider about one of its payments.
+type Event struct {
+	// ID identifies the event so that deliveries retried by the provider are
+	// only handled once.
+	ID        string
+	Type      string
+	Reference string
+	// Amount is the amount captured, or for refunds the total refunded so
+	// far, so that refunds reported again are not counted twice.
+	Amount        models.Money
+	FailureReason string
+}
+
+// Provider takes payments at a payment service.
+type Provider interface {
+	// Name identifies the provider in payment records and webhook URLs.
+	Name() string
+	// Authorize reserves the amount; declined payment methods give a result
+	// with StatusFailed rather than an error. Providers settling
+	// asynchronously answer StatusPending and report the outcome through the
+	// webhook.
+	Authorize(ctx context.Context, req AuthorizeRequest) (Result, error)
+	// Capture charges an authorized payment, up to the authorized amount.
+	Capture(ctx context.Context, reference string, amount models.Money) (Result, error)
+	// Void releases an authorization that will not be captured.
+	Void(ctx context.Context, reference string) (Result, error)
+	// Refund pays back part or all of the captured amount.
+	Refund(ctx context.Context, reference string, amount models.Money) (Result, error)
+	// VerifyWebhook checks the signature of a callback, reading its headers
+	// through header, and parses it.
+	VerifyWebhook(header func(key string) string, body []byte) (Event, error)
+}
+
+// Default is the provider used by the handlers, set up by Init.
+var Default Provider
+
+// Init configures Default from the environment. PAYMENT_PROVIDER selects
+// the provider; only "mock" (the default) is built in. The mock provider
+// signs its webhooks with PAYMENT_WEBHOOK_SECRET.
+func Init() error {
+	switch provider := strings.ToLower(os.Getenv("PAYMENT_PROVIDER")); provider {
+	case "", "mock":
+		Default = NewMock(os.Getenv("PAYMENT_WEBHOOK_SECRET"))
+	default:
+		return errors.New("unknown payment provider " + provider)
+	}
+	return nil
+}
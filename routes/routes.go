@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/DewiKresnawati/DewiWebService/handlers"
 	"github.com/DewiKresnawati/DewiWebService/middlewares"
+	"github.com/DewiKresnawati/DewiWebService/models"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -13,36 +14,214 @@ func RouteInit(app *fiber.App) {
 	})
 
 	// auth route
-	r.Post("/register", handlers.Register)
+	r.Post("/register", middlewares.OptionalAuthMiddleware(), handlers.Register)
 	r.Post("/login", handlers.Login)
 	r.Get("/protected", middlewares.AuthMiddleware(), handlers.ProtectedRoute)
 	r.Post("/logout", middlewares.AuthMiddleware(), handlers.Logout)
 
+	// Catalog reads are open to customers and anonymous shoppers; costs,
+	// suppliers and stock stay with staff
+	catalog := middlewares.OptionalAuthMiddleware(models.RoleStaff, models.RoleCustomer)
+
 	// Product routes
 	r.Post("/products", middlewares.AuthMiddleware(), handlers.CreateProduct)
-	r.Get("/products", middlewares.AuthMiddleware(), handlers.GetAllProducts)
-	r.Get("/products/:id", middlewares.AuthMiddleware(), handlers.GetProductByID)
+	r.Post("/products/import", middlewares.AuthMiddleware(), handlers.ImportProducts)
+	r.Get("/products/export", middlewares.AuthMiddleware(), handlers.ExportProducts)
+	r.Get("/imports/:id", middlewares.AuthMiddleware(), handlers.GetImportJob)
+	r.Get("/products", catalog, handlers.GetAllProducts)
+	r.Get("/products/by-slug/:slug", catalog, handlers.GetProductBySlug)
+	r.Get("/products/:id", catalog, handlers.GetProductByID)
 	r.Put("/products/:id", middlewares.AuthMiddleware(), handlers.UpdateProduct)
 	r.Delete("/products/:id", handlers.DeleteProduct)
+	r.Get("/products/:id/stock", middlewares.AuthMiddleware(), handlers.GetProductStock)
+	r.Get("/products/:id/suppliers", middlewares.AuthMiddleware(), handlers.GetProductSuppliers)
+	r.Post("/products/:id/suppliers", middlewares.AuthMiddleware(), handlers.AddProductSupplier)
+	r.Put("/products/:id/suppliers/:supplierId", middlewares.AuthMiddleware(), handlers.UpdateProductSupplier)
+	r.Delete("/products/:id/suppliers/:supplierId", middlewares.AuthMiddleware(), handlers.RemoveProductSupplier)
+	r.Get("/products/:id/attributes", catalog, handlers.GetProductAttributes)
+	r.Put("/products/:id/attributes", middlewares.AuthMiddleware(), handlers.SetProductAttributes)
+	r.Get("/products/:id/images", catalog, handlers.GetProductImages)
+	r.Post("/products/:id/images", middlewares.AuthMiddleware(), handlers.UploadProductImage)
+	r.Put("/products/:id/images/order", middlewares.AuthMiddleware(), handlers.ReorderProductImages)
+	r.Put("/products/:id/images/:imageId", middlewares.AuthMiddleware(), handlers.UpdateProductImage)
+	r.Delete("/products/:id/images/:imageId", middlewares.AuthMiddleware(), handlers.DeleteProductImage)
+	r.Get("/products/:id/price-history", middlewares.AuthMiddleware(), handlers.GetProductPriceHistory)
+	r.Post("/products/:id/price-changes", middlewares.AuthMiddleware(), handlers.ScheduleProductPrice)
+	r.Delete("/products/:id/price-changes/:changeId", middlewares.AuthMiddleware(), handlers.CancelProductPriceChange)
+	r.Get("/products/:id/prices", middlewares.AuthMiddleware(), handlers.GetProductPrices)
+	r.Put("/products/:id/prices/:currency", middlewares.AuthMiddleware(), handlers.SetProductPrice)
+	r.Delete("/products/:id/prices/:currency", middlewares.AuthMiddleware(), handlers.DeleteProductPrice)
+	r.Get("/products/:id/variants", catalog, handlers.GetProductVariants)
+	r.Post("/products/:id/variants", middlewares.AuthMiddleware(), handlers.CreateProductVariant)
+
+	// Variants and option types
+	r.Get("/variants", catalog, handlers.GetVariants)
+	r.Get("/variants/:id", catalog, handlers.GetVariantByID)
+	r.Put("/variants/:id", middlewares.AuthMiddleware(), handlers.UpdateVariant)
+	r.Delete("/variants/:id", middlewares.AuthMiddleware(), handlers.DeleteVariant)
+	r.Get("/option-types", catalog, handlers.GetOptionTypes)
+	r.Post("/option-types", middlewares.AuthMiddleware(), handlers.CreateOptionType)
+	r.Put("/option-types/:id", middlewares.AuthMiddleware(), handlers.UpdateOptionType)
+	r.Delete("/option-types/:id", middlewares.AuthMiddleware(), handlers.DeleteOptionType)
+
+	// Currencies
+	r.Get("/exchange-rates", middlewares.AuthMiddleware(), handlers.GetExchangeRates)
+	r.Post("/exchange-rates", middlewares.AuthMiddleware(), handlers.CreateExchangeRate)
+	r.Post("/exchange-rates/import", middlewares.AuthMiddleware(), handlers.ImportExchangeRates)
+	r.Delete("/exchange-rates/:id", middlewares.AuthMiddleware(), handlers.DeleteExchangeRate)
+
+	// Taxes
+	r.Get("/tax-classes", middlewares.AuthMiddleware(), handlers.GetTaxClasses)
+	r.Post("/tax-classes", middlewares.AuthMiddleware(), handlers.CreateTaxClass)
+	r.Get("/tax-classes/:id", middlewares.AuthMiddleware(), handlers.GetTaxClass)
+	r.Put("/tax-classes/:id", middlewares.AuthMiddleware(), handlers.UpdateTaxClass)
+	r.Delete("/tax-classes/:id", middlewares.AuthMiddleware(), handlers.DeleteTaxClass)
+	r.Post("/tax-classes/:id/rates", middlewares.AuthMiddleware(), handlers.CreateTaxRate)
+	r.Put("/tax-rates/:id", middlewares.AuthMiddleware(), handlers.UpdateTaxRate)
+	r.Delete("/tax-rates/:id", middlewares.AuthMiddleware(), handlers.DeleteTaxRate)
+
+	// Promotions and coupons
+	r.Get("/promotions", middlewares.AuthMiddleware(), handlers.GetPromotions)
+	r.Post("/promotions", middlewares.AuthMiddleware(), handlers.CreatePromotion)
+	r.Get("/promotions/:id", middlewares.AuthMiddleware(), handlers.GetPromotion)
+	r.Put("/promotions/:id", middlewares.AuthMiddleware(), handlers.UpdatePromotion)
+	r.Delete("/promotions/:id", middlewares.AuthMiddleware(), handlers.DeletePromotion)
 
 	// Category routes
 	r.Post("/categories", middlewares.AuthMiddleware(), handlers.CreateCategory)
-	r.Get("/categories", middlewares.AuthMiddleware(), handlers.GetAllCategories)
-	r.Get("/categories/:id", middlewares.AuthMiddleware(), handlers.GetCategoryByID)
+	r.Get("/categories", catalog, handlers.GetAllCategories)
+	r.Get("/categories/tree", catalog, handlers.GetCategoryTree)
+	r.Get("/categories/by-slug/:slug", catalog, handlers.GetCategoryBySlug)
+	r.Get("/categories/:id", catalog, handlers.GetCategoryByID)
 	r.Put("/categories/:id", middlewares.AuthMiddleware(), handlers.UpdateCategory)
 	r.Delete("/categories/:id", handlers.DeleteCategory)
+	r.Post("/categories/:id/move", middlewares.AuthMiddleware(), handlers.MoveCategory)
+	r.Get("/categories/:id/attributes", catalog, handlers.GetCategoryAttributes)
+	r.Post("/categories/:id/attributes", middlewares.AuthMiddleware(), handlers.CreateCategoryAttribute)
+	r.Put("/categories/:id/attributes/:attributeId", middlewares.AuthMiddleware(), handlers.UpdateCategoryAttribute)
+	r.Delete("/categories/:id/attributes/:attributeId", middlewares.AuthMiddleware(), handlers.DeleteCategoryAttribute)
 
-	// Order routes
-	r.Post("/orders", middlewares.AuthMiddleware(), handlers.CreateOrder)
-	r.Get("/orders", middlewares.AuthMiddleware(), handlers.GetAllOrders)
-	r.Get("/orders/:id", middlewares.AuthMiddleware(), handlers.GetOrderByID)
+	// Order routes; customers may place orders and see their own
+	r.Post("/orders", middlewares.AuthMiddleware(models.RoleStaff, models.RoleCustomer), handlers.CreateOrder)
+	r.Get("/orders", middlewares.AuthMiddleware(models.RoleStaff, models.RoleCustomer), handlers.GetAllOrders)
+	r.Get("/orders/export", middlewares.AuthMiddleware(), handlers.ExportOrders)
+	r.Get("/orders/:id", middlewares.AuthMiddleware(models.RoleStaff, models.RoleCustomer), handlers.GetOrderByID)
 	r.Put("/orders/:id", middlewares.AuthMiddleware(), handlers.UpdateOrder)
-	r.Delete("/orders/:id", handlers.DeleteOrder)
+	r.Delete("/orders/:id", middlewares.AuthMiddleware(), handlers.DeleteOrder)
+
+	// Payment routes; the webhook is called by the payment provider and checked by its signature
+	r.Post("/orders/:id/payments", middlewares.AuthMiddleware(models.RoleStaff, models.RoleCustomer), handlers.CreateOrderPayment)
+	r.Get("/orders/:id/payments", middlewares.AuthMiddleware(models.RoleStaff, models.RoleCustomer), handlers.GetOrderPayments)
+	r.Post("/payments/webhooks/:provider", handlers.PaymentWebhook)
+	r.Post("/payments/:id/capture", middlewares.AuthMiddleware(), handlers.CapturePayment)
+	r.Post("/payments/:id/refund", middlewares.AuthMiddleware(), handlers.RefundPayment)
+
+	// Invoice routes; customers may download the invoices of their own orders
+	r.Post("/orders/:id/invoice", middlewares.AuthMiddleware(), handlers.IssueOrderInvoice)
+	r.Get("/orders/:id/invoice", middlewares.AuthMiddleware(models.RoleStaff, models.RoleCustomer), handlers.GetOrderInvoice)
+	r.Get("/orders/:id/invoice.pdf", middlewares.AuthMiddleware(models.RoleStaff, models.RoleCustomer), handlers.GetOrderInvoicePDF)
+	r.Get("/invoices", middlewares.AuthMiddleware(), handlers.GetAllInvoices)
+
+	// Return routes; customers may ask to return goods of their own orders
+	r.Post("/orders/:id/returns", middlewares.AuthMiddleware(models.RoleStaff, models.RoleCustomer), handlers.CreateOrderReturn)
+	r.Get("/orders/:id/returns", middlewares.AuthMiddleware(models.RoleStaff, models.RoleCustomer), handlers.GetOrderReturns)
+	r.Get("/orders/:id/credit-notes", middlewares.AuthMiddleware(models.RoleStaff, models.RoleCustomer), handlers.GetOrderCreditNotes)
+	r.Get("/returns", middlewares.AuthMiddleware(), handlers.GetAllReturns)
+	r.Get("/returns/:id", middlewares.AuthMiddleware(models.RoleStaff, models.RoleCustomer), handlers.GetReturnByID)
+	r.Post("/returns/:id/approve", middlewares.AuthMiddleware(), handlers.ApproveReturn)
+	r.Post("/returns/:id/reject", middlewares.AuthMiddleware(), handlers.RejectReturn)
+	r.Post("/returns/:id/receive", middlewares.AuthMiddleware(), handlers.ReceiveReturn)
+	r.Post("/returns/:id/refund", middlewares.AuthMiddleware(), handlers.RefundReturn)
+
+	// Customer routes
+	r.Post("/customers/register", handlers.RegisterCustomer)
+	r.Get("/customers/me", middlewares.AuthMiddleware(models.RoleCustomer), handlers.GetMyCustomerProfile)
+	r.Put("/customers/me", middlewares.AuthMiddleware(models.RoleCustomer), handlers.UpdateMyCustomerProfile)
+	r.Get("/customers/me/addresses", middlewares.AuthMiddleware(models.RoleCustomer), handlers.GetMyCustomerAddresses)
+	r.Post("/customers/me/addresses", middlewares.AuthMiddleware(models.RoleCustomer), handlers.CreateMyCustomerAddress)
+	r.Put("/customers/me/addresses/:addressId", middlewares.AuthMiddleware(models.RoleCustomer), handlers.UpdateMyCustomerAddress)
+	r.Delete("/customers/me/addresses/:addressId", middlewares.AuthMiddleware(models.RoleCustomer), handlers.DeleteMyCustomerAddress)
+	r.Get("/customers", middlewares.AuthMiddleware(), handlers.GetCustomers)
+	r.Get("/customers/:id", middlewares.AuthMiddleware(), handlers.GetCustomerByID)
+	r.Get("/customers/:id/orders", middlewares.AuthMiddleware(), handlers.GetCustomerOrders)
+
+	// Cart routes; anonymous shoppers are identified by the X-Cart-Token header
+	cart := middlewares.OptionalAuthMiddleware(models.RoleStaff, models.RoleCustomer)
+	r.Get("/cart", cart, handlers.GetCart)
+	r.Delete("/cart", cart, handlers.ClearCart)
+	r.Post("/cart/items", cart, handlers.AddCartItem)
+	r.Put("/cart/items/:itemId", cart, handlers.UpdateCartItem)
+	r.Delete("/cart/items/:itemId", cart, handlers.DeleteCartItem)
+	r.Post("/cart/checkout", cart, handlers.CheckoutCart)
 
 	// Supplier routes
 	r.Post("/suppliers", middlewares.AuthMiddleware(), handlers.CreateSupplier)
 	r.Get("/suppliers", middlewares.AuthMiddleware(), handlers.GetAllSuppliers)
+	r.Get("/suppliers/export", middlewares.AuthMiddleware(), handlers.ExportSuppliers)
 	r.Get("/suppliers/:id", middlewares.AuthMiddleware(), handlers.GetSupplierByID)
 	r.Put("/suppliers/:id", middlewares.AuthMiddleware(), handlers.UpdateSupplier)
 	r.Delete("/suppliers/:id", handlers.DeleteSupplier)
+	r.Get("/suppliers/:id/reorder-suggestions", middlewares.AuthMiddleware(), handlers.GetReorderSuggestions)
+	r.Post("/suppliers/:id/reorder-suggestions/purchase-order", middlewares.AuthMiddleware(), handlers.CreateReorderPurchaseOrder)
+	r.Post("/suppliers/:id/prices", middlewares.AuthMiddleware(), handlers.CreateSupplierPrice)
+	r.Get("/suppliers/:id/prices", middlewares.AuthMiddleware(), handlers.GetSupplierPrices)
+	r.Put("/suppliers/:id/prices/:priceId", middlewares.AuthMiddleware(), handlers.UpdateSupplierPrice)
+	r.Delete("/suppliers/:id/prices/:priceId", middlewares.AuthMiddleware(), handlers.DeleteSupplierPrice)
+	r.Get("/suppliers/:id/contacts", middlewares.AuthMiddleware(), handlers.GetSupplierContacts)
+	r.Post("/suppliers/:id/contacts", middlewares.AuthMiddleware(), handlers.CreateSupplierContact)
+	r.Put("/suppliers/:id/contacts/:contactId", middlewares.AuthMiddleware(), handlers.UpdateSupplierContact)
+	r.Delete("/suppliers/:id/contacts/:contactId", middlewares.AuthMiddleware(), handlers.DeleteSupplierContact)
+	r.Get("/suppliers/:id/addresses", middlewares.AuthMiddleware(), handlers.GetSupplierAddresses)
+	r.Post("/suppliers/:id/addresses", middlewares.AuthMiddleware(), handlers.CreateSupplierAddress)
+	r.Put("/suppliers/:id/addresses/:addressId", middlewares.AuthMiddleware(), handlers.UpdateSupplierAddress)
+	r.Delete("/suppliers/:id/addresses/:addressId", middlewares.AuthMiddleware(), handlers.DeleteSupplierAddress)
+	r.Post("/suppliers/:id/users", middlewares.AuthMiddleware(), handlers.CreateSupplierUser)
+	r.Get("/suppliers/:id/users", middlewares.AuthMiddleware(), handlers.GetSupplierUsers)
+
+	// Warehouse routes
+	r.Post("/warehouses", middlewares.AuthMiddleware(), handlers.CreateWarehouse)
+	r.Get("/warehouses", middlewares.AuthMiddleware(), handlers.GetAllWarehouses)
+	r.Get("/warehouses/:id", middlewares.AuthMiddleware(), handlers.GetWarehouseByID)
+	r.Put("/warehouses/:id", middlewares.AuthMiddleware(), handlers.UpdateWarehouse)
+	r.Delete("/warehouses/:id", middlewares.AuthMiddleware(), handlers.DeleteWarehouse)
+	r.Get("/warehouses/:id/stock", middlewares.AuthMiddleware(), handlers.GetWarehouseStock)
+	r.Put("/warehouses/:id/stock", middlewares.AuthMiddleware(), handlers.SetWarehouseStock)
+
+	// Stock transfer routes
+	r.Post("/transfers", middlewares.AuthMiddleware(), handlers.CreateTransfer)
+	r.Get("/transfers", middlewares.AuthMiddleware(), handlers.GetAllTransfers)
+	r.Get("/transfers/:id", middlewares.AuthMiddleware(), handlers.GetTransferByID)
+	r.Post("/transfers/:id/ship", middlewares.AuthMiddleware(), handlers.ShipTransfer)
+	r.Post("/transfers/:id/receive", middlewares.AuthMiddleware(), handlers.ReceiveTransfer)
+	r.Post("/transfers/:id/cancel", middlewares.AuthMiddleware(), handlers.CancelTransfer)
+
+	// Stock ledger routes
+	r.Post("/stock/adjustments", middlewares.AuthMiddleware(), handlers.CreateStockAdjustment)
+	r.Get("/stock/movements", middlewares.AuthMiddleware(), handlers.GetStockMovements)
+	r.Get("/stock/reports/on-date", middlewares.AuthMiddleware(), handlers.GetStockOnDate)
+	r.Get("/stock/alerts", middlewares.AuthMiddleware(), handlers.GetLowStockAlerts)
+
+	// Purchase order routes
+	r.Post("/purchase-orders", middlewares.AuthMiddleware(), handlers.CreatePurchaseOrder)
+	r.Get("/purchase-orders", middlewares.AuthMiddleware(), handlers.GetAllPurchaseOrders)
+	r.Get("/purchase-orders/:id", middlewares.AuthMiddleware(), handlers.GetPurchaseOrderByID)
+	r.Put("/purchase-orders/:id", middlewares.AuthMiddleware(), handlers.UpdatePurchaseOrder)
+	r.Post("/purchase-orders/:id/send", middlewares.AuthMiddleware(), handlers.SendPurchaseOrder)
+	r.Post("/purchase-orders/:id/close", middlewares.AuthMiddleware(), handlers.ClosePurchaseOrder)
+	r.Post("/purchase-orders/:id/cancel", middlewares.AuthMiddleware(), handlers.CancelPurchaseOrder)
+	r.Post("/purchase-orders/:id/receipts", middlewares.AuthMiddleware(), handlers.CreateGoodsReceipt)
+	r.Get("/purchase-orders/:id/receipts", middlewares.AuthMiddleware(), handlers.GetGoodsReceipts)
+
+	// Report routes
+	r.Get("/reports/margins", middlewares.AuthMiddleware(), handlers.GetMarginReport)
+
+	// Supplier portal routes, scoped to the supplier of the logged in user
+	portal := r.Group("/portal", middlewares.AuthMiddleware(models.RoleSupplier))
+	portal.Get("/products", handlers.GetPortalProducts)
+	portal.Put("/products/:id", handlers.UpdatePortalProduct)
+	portal.Get("/prices", handlers.GetPortalPrices)
+	portal.Post("/price-lists", handlers.UploadPortalPriceList)
+	portal.Get("/purchase-orders", handlers.GetPortalPurchaseOrders)
+	portal.Get("/purchase-orders/:id", handlers.GetPortalPurchaseOrderByID)
+	portal.Post("/purchase-orders/:id/acknowledge", handlers.AcknowledgePortalPurchaseOrder)
 }
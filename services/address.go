@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidAddress = errors.New("invalid address")
+	ErrUnknownAddress = errors.New("address does not belong to the customer of the order")
+)
+
+// addressFormat describes how addresses are written in a country.
+type addressFormat struct {
+	postalCode      *regexp.Regexp
+	example         string // contoh kode pos untuk pesan kesalahan
+	requireProvince bool
+}
+
+// addressFormats lists the countries whose postal codes and provinces are
+// checked. Addresses in other countries only need a street and city.
+var addressFormats = map[string]addressFormat{
+	"ID": {regexp.MustCompile(`^\d{5}$`), "40115", true},
+	"MY": {regexp.MustCompile(`^\d{5}$`), "50450", true},
+	"SG": {regexp.MustCompile(`^\d{6}$`), "018956", false},
+	"TH": {regexp.MustCompile(`^\d{5}$`), "10200", true},
+	"PH": {regexp.MustCompile(`^\d{4}$`), "1000", true},
+	"VN": {regexp.MustCompile(`^\d{6}$`), "100000", true},
+	"AU": {regexp.MustCompile(`^\d{4}$`), "2000", true},
+	"NZ": {regexp.MustCompile(`^\d{4}$`), "6011", false},
+	"JP": {regexp.MustCompile(`^\d{3}-\d{4}$`), "100-0001", true},
+	"US": {regexp.MustCompile(`^\d{5}(-\d{4})?$`), "94105", true},
+	"CA": {regexp.MustCompile(`^[A-Z]\d[A-Z] \d[A-Z]\d$`), "K1A 0B1", true},
+	"GB": {regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2}$`), "SW1A 1AA", false},
+	"DE": {regexp.MustCompile(`^\d{5}$`), "10115", false},
+	"FR": {regexp.MustCompile(`^\d{5}$`), "75001", false},
+	"NL": {regexp.MustCompile(`^\d{4} [A-Z]{2}$`), "1012 AB", false},
+}
+
+var countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// NormaliseAddress trims the fields of an address and upper-cases its
+// country and postal code; the country defaults to ID.
+func NormaliseAddress(address *models.Address) {
+	address.Recipient = strings.TrimSpace(address.Recipient)
+	address.Phone = strings.TrimSpace(address.Phone)
+	address.Street = strings.TrimSpace(address.Street)
+	address.City = strings.TrimSpace(address.City)
+	address.Province = strings.TrimSpace(address.Province)
+	address.PostalCode = strings.ToUpper(strings.TrimSpace(address.PostalCode))
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	if address.Country == "" {
+		address.Country = "ID"
+	}
+}
+
+// ValidateAddress checks that an address has the fields its country needs
+// and that its postal code is written the way the country writes them.
+func ValidateAddress(address models.Address) error {
+	if address.Recipient == "" || address.Street == "" || address.City == "" {
+		return fmt.Errorf("%w: recipient, street and city are required", ErrInvalidAddress)
+	}
+	if !countryCode.MatchString(address.Country) {
+		return fmt.Errorf("%w: country must be a two letter ISO 3166 code", ErrInvalidAddress)
+	}
+	format, ok := addressFormats[address.Country]
+	if !ok {
+		return nil
+	}
+	if format.requireProvince && address.Province == "" {
+		return fmt.Errorf("%w: province is required in %s", ErrInvalidAddress, address.Country)
+	}
+	if !format.postalCode.MatchString(address.PostalCode) {
+		return fmt.Errorf("%w: postal code in %s must look like %s", ErrInvalidAddress, address.Country, format.example)
+	}
+	return nil
+}
+
+// SaveCustomerAddress validates and stores a saved address. A default
+// shipping or billing address takes the flag away from the customer's other
+// addresses, and the first address of a customer becomes both.
+func SaveCustomerAddress(tx *gorm.DB, address *models.CustomerAddress) error {
+	snapshot := address.Address()
+	NormaliseAddress(&snapshot)
+	if err := ValidateAddress(snapshot); err != nil {
+		return err
+	}
+	address.SetAddress(snapshot)
+
+	var others int64
+	if err := tx.Model(&models.CustomerAddress{}).Where("customer_id = ? AND id <> ?", address.CustomerID, address.ID).Count(&others).Error; err != nil {
+		return err
+	}
+	if others == 0 {
+		address.IsDefaultShipping, address.IsDefaultBilling = true, true
+	}
+	if err := tx.Save(address).Error; err != nil {
+		return err
+	}
+	for column, isDefault := range map[string]bool{
+		"is_default_shipping": address.IsDefaultShipping,
+		"is_default_billing":  address.IsDefaultBilling,
+	} {
+		if !isDefault {
+			continue
+		}
+		err := tx.Model(&models.CustomerAddress{}).
+			Where("customer_id = ? AND id <> ?", address.CustomerID, address.ID).
+			UpdateColumn(column, false).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// customerAddress returns the saved address with the given ID, or the
+// customer's default address of the given kind (is_default_shipping or
+// is_default_billing) without one. It returns nil when the customer has
+// no default.
+func customerAddress(tx *gorm.DB, customerID uint, id *uint, defaultColumn string) (*models.CustomerAddress, error) {
+	var addresses []models.CustomerAddress
+	query := tx.Where("customer_id = ?", customerID)
+	if id != nil {
+		query = query.Where("id = ?", *id)
+	} else {
+		query = query.Where(defaultColumn+" = ?", true)
+	}
+	if err := query.Limit(1).Find(&addresses).Error; err != nil {
+		return nil, err
+	}
+	if len(addresses) == 0 {
+		if id != nil {
+			return nil, ErrUnknownAddress
+		}
+		return nil, nil
+	}
+	return &addresses[0], nil
+}
+
+// SnapshotOrderAddresses copies the shipping and billing addresses of an
+// order from the address book of its customer, so later edits of the saved
+// addresses do not change the order. Without an address ID the customer's
+// default is used, and the billing address falls back to the shipping
+// address. Orders without a customer cannot use saved addresses.
+func SnapshotOrderAddresses(tx *gorm.DB, order *models.Order, shippingID, billingID *uint) error {
+	if order.CustomerID == nil {
+		if shippingID != nil || billingID != nil {
+			return ErrUnknownAddress
+		}
+		return nil
+	}
+	shipping, err := customerAddress(tx, *order.CustomerID, shippingID, "is_default_shipping")
+	if err != nil {
+		return err
+	}
+	billing, err := customerAddress(tx, *order.CustomerID, billingID, "is_default_billing")
+	if err != nil {
+		return err
+	}
+	if billing == nil {
+		billing = shipping
+	}
+
+	order.ShippingAddress, order.BillingAddress = models.Address{}, models.Address{}
+	if shipping != nil {
+		order.ShippingAddress = shipping.Address()
+		if err := ValidateAddress(order.ShippingAddress); err != nil {
+			return err
+		}
+	}
+	if billing != nil {
+		order.BillingAddress = billing.Address()
+		if err := ValidateAddress(order.BillingAddress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
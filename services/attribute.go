@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidAttribute      = errors.New("invalid attribute definition")
+	ErrInvalidAttributeValue = errors.New("invalid attribute value")
+)
+
+// ValidateAttribute checks an attribute definition before it is saved.
+func ValidateAttribute(attribute *models.Attribute) error {
+	attribute.Code = strings.TrimSpace(attribute.Code)
+	if attribute.Code == "" || strings.ContainsAny(attribute.Code, ". ") {
+		return fmt.Errorf("%w: code is required and may not contain dots or spaces", ErrInvalidAttribute)
+	}
+	if attribute.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+	valid := false
+	for _, t := range models.AttributeTypes {
+		if attribute.Type == t {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidAttribute, attribute.Type)
+	}
+	if attribute.Type == models.AttributeTypeSelect && len(attribute.Options) == 0 {
+		return fmt.Errorf("%w: select attributes need options", ErrInvalidAttribute)
+	}
+	if attribute.Min != nil && attribute.Max != nil && *attribute.Min > *attribute.Max {
+		return fmt.Errorf("%w: min is greater than max", ErrInvalidAttribute)
+	}
+	return nil
+}
+
+// CategoryAttributes returns the attributes that apply to products of the
+// category: its own and those of its ancestors, ordered by position.
+func CategoryAttributes(db *gorm.DB, categoryID uint) ([]models.Attribute, error) {
+	ids, err := CategoryAncestry(db, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes []models.Attribute
+	err = db.Where("category_id IN ?", ids).Order("position, id").Find(&attributes).Error
+	return attributes, err
+}
+
+// NormaliseAttributeValue checks a raw value against the attribute type and
+// returns its normalised form and, for numbers, its numeric value.
+func NormaliseAttributeValue(attribute models.Attribute, raw string) (string, *float64, error) {
+	raw = strings.TrimSpace(raw)
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %s %s", ErrInvalidAttributeValue, attribute.Code, reason)
+	}
+
+	switch attribute.Type {
+	case models.AttributeTypeNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return "", nil, invalid("must be a number")
+		}
+		if attribute.Min != nil && number < *attribute.Min {
+			return "", nil, invalid(fmt.Sprintf("must be at least %g", *attribute.Min))
+		}
+		if attribute.Max != nil && number > *attribute.Max {
+			return "", nil, invalid(fmt.Sprintf("must be at most %g", *attribute.Max))
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), &number, nil
+	case models.AttributeTypeBoolean:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return "", nil, invalid("must be true or false")
+		}
+		return strconv.FormatBool(value), nil, nil
+	case models.AttributeTypeDate:
+		date, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return "", nil, invalid("must be a date (YYYY-MM-DD)")
+		}
+		return date.Format("2006-01-02"), nil, nil
+	case models.AttributeTypeSelect:
+		for _, option := range attribute.Options {
+			if raw == option {
+				return raw, nil, nil
+			}
+		}
+		return "", nil, invalid("must be one of " + strings.Join(attribute.Options, ", "))
+	default:
+		if len(raw) > 191 {
+			return "", nil, invalid("must be at most 191 characters")
+		}
+		return raw, nil, nil
+	}
+}
+
+// SetProductAttributes validates and stores the attribute values of a
+// product, keyed by attribute code. When values is nil the current values are
+// re-validated against the product's category, e.g. after it changed. Empty
+// values remove the attribute; values of attributes that no longer apply are
+// dropped.
+func SetProductAttributes(tx *gorm.DB, product *models.Product, values map[string]string) error {
+	attributes, err := CategoryAttributes(tx, product.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	var existing []models.ProductAttributeValue
+	if err := tx.Preload("Attribute").Where("product_id = ?", product.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+	merged := make(map[string]string, len(existing)+len(values))
+	for _, value := range existing {
+		merged[value.Attribute.Code] = value.Value
+	}
+	byCode := make(map[string]models.Attribute, len(attributes))
+	for _, attribute := range attributes {
+		// Atribut kategori yang lebih spesifik menimpa milik induknya
+		if _, ok := byCode[attribute.Code]; !ok || attribute.CategoryID == product.CategoryID {
+			byCode[attribute.Code] = attribute
+		}
+	}
+	for code, value := range values {
+		if _, ok := byCode[code]; !ok {
+			return fmt.Errorf("%w: %s is not an attribute of this category", ErrInvalidAttributeValue, code)
+		}
+		merged[code] = value
+	}
+
+	if err := tx.Unscoped().Where("product_id = ?", product.ID).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+		return err
+	}
+	for code, attribute := range byCode {
+		raw, ok := merged[code]
+		if !ok || strings.TrimSpace(raw) == "" {
+			if attribute.Required {
+				return fmt.Errorf("%w: %s is required", ErrInvalidAttributeValue, code)
+			}
+			continue
+		}
+		value, number, err := NormaliseAttributeValue(attribute, raw)
+		if err != nil {
+			return err
+		}
+		err = tx.Create(&models.ProductAttributeValue{
+			ProductID:   product.ID,
+			AttributeID: attribute.ID,
+			Value:       value,
+			NumberValue: number,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FilterByAttribute restricts a product query to products whose attribute
+// with the given code matches. Operator is "" for equality with any of the
+// comma separated values, or "min"/"max" for an inclusive range; numeric
+// bounds compare numbers, other bounds compare the normalised text, which
+// orders dates correctly.
+func FilterByAttribute(query *gorm.DB, code, operator, value string) *gorm.DB {
+	const subquery = "products.id IN (SELECT v.product_id FROM product_attribute_values v JOIN attributes a ON a.id = v.attribute_id AND a.deleted_at IS NULL WHERE v.deleted_at IS NULL AND a.code = ? AND "
+	switch operator {
+	case "min", "max":
+		comparison := ">="
+		if operator == "max" {
+			comparison = "<="
+		}
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return query.Where(subquery+"v.number_value "+comparison+" ?)", code, number)
+		}
+		return query.Where(subquery+"v.value "+comparison+" ?)", code, value)
+	default:
+		return query.Where(subquery+"v.value IN ?)", code, strings.Split(value, ","))
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmptyCart       = errors.New("cart is empty")
+	ErrInvalidQuantity = errors.New("quantity must be greater than zero")
+)
+
+// createCart starts an empty cart with a new token.
+func createCart(tx *gorm.DB, userID *uint) (*models.Cart, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	cart := &models.Cart{Token: hex.EncodeToString(random), UserID: userID}
+	return cart, tx.Create(cart).Error
+}
+
+// FindCart returns the cart of a shopper, starting one when they have none.
+// Anonymous shoppers are identified by the token of their cart. A logged in
+// user gets their own cart; an anonymous cart whose token they send is
+// merged into it, or becomes theirs when they had no cart yet. Tokens of
+// carts owned by users are only accepted from those users.
+func FindCart(tx *gorm.DB, userID *uint, token string) (*models.Cart, error) {
+	var anonymous *models.Cart
+	if token != "" {
+		var carts []models.Cart
+		if err := tx.Where("token = ? AND user_id IS NULL", token).Limit(1).Find(&carts).Error; err != nil {
+			return nil, err
+		}
+		if len(carts) > 0 {
+			anonymous = &carts[0]
+		}
+	}
+	if userID == nil {
+		if anonymous != nil {
+			return anonymous, nil
+		}
+		return createCart(tx, nil)
+	}
+
+	var carts []models.Cart
+	if err := tx.Where("user_id = ?", *userID).Limit(1).Find(&carts).Error; err != nil {
+		return nil, err
+	}
+	switch {
+	case len(carts) > 0 && anonymous != nil:
+		return &carts[0], MergeCarts(tx, &carts[0], anonymous)
+	case len(carts) > 0:
+		return &carts[0], nil
+	case anonymous != nil:
+		anonymous.UserID = userID
+		return anonymous, tx.Model(anonymous).UpdateColumn("user_id", *userID).Error
+	default:
+		return createCart(tx, userID)
+	}
+}
+
+// MergeCarts moves the items of one cart into another, adding up the
+// quantities of items for the same product and variant, and deletes the
+// emptied cart.
+func MergeCarts(tx *gorm.DB, into, from *models.Cart) error {
+	var items []models.CartItem
+	if err := tx.Where("cart_id = ?", from.ID).Find(&items).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		if _, err := addCartItem(tx, into.ID, item.ProductID, item.VariantID, item.Quantity, item.PriceWhenAdded); err != nil {
+			return err
+		}
+	}
+	if err := tx.Unscoped().Where("cart_id = ?", from.ID).Delete(&models.CartItem{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(from).Error
+}
+
+// addCartItem adds a quantity of a product or variant to a cart, to the
+// item already holding it if there is one.
+func addCartItem(tx *gorm.DB, cartID, productID uint, variantID *uint, quantity uint, price models.Money) (*models.CartItem, error) {
+	query := tx.Where("cart_id = ? AND product_id = ?", cartID, productID)
+	if variantID != nil {
+		query = query.Where("variant_id = ?", *variantID)
+	} else {
+		query = query.Where("variant_id IS NULL")
+	}
+	var items []models.CartItem
+	if err := query.Limit(1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) > 0 {
+		item := &items[0]
+		item.Quantity += quantity
+		return item, tx.Model(item).UpdateColumn("quantity", item.Quantity).Error
+	}
+	item := &models.CartItem{
+		CartID:         cartID,
+		ProductID:      productID,
+		VariantID:      variantID,
+		Quantity:       quantity,
+		PriceWhenAdded: price,
+	}
+	return item, tx.Create(item).Error
+}
+
+// AddCartItem puts a product or variant in a cart at its current price.
+func AddCartItem(tx *gorm.DB, cart *models.Cart, req models.CartItemRequest) (*models.CartItem, error) {
+	if req.Quantity == 0 {
+		return nil, ErrInvalidQuantity
+	}
+	var variant *models.ProductVariant
+	if req.VariantID != nil {
+		var err error
+		if variant, err = OrderVariant(tx, req.ProductID, *req.VariantID); err != nil {
+			return nil, err
+		}
+		req.ProductID = variant.ProductID
+	}
+	var product models.Product
+	if err := tx.First(&product, req.ProductID).Error; err != nil {
+		return nil, err
+	}
+	price := product.Price
+	if variant != nil {
+		price = variant.EffectivePrice(product)
+	}
+	return addCartItem(tx, cart.ID, product.ID, req.VariantID, req.Quantity, price)
+}
+
+// SetCartItemQuantity changes the quantity of an item of a cart, removing
+// the item for a quantity of zero.
+func SetCartItemQuantity(tx *gorm.DB, cart *models.Cart, itemID uint, quantity uint) error {
+	var item models.CartItem
+	if err := tx.Where("cart_id = ?", cart.ID).First(&item, itemID).Error; err != nil {
+		return err
+	}
+	if quantity == 0 {
+		return tx.Unscoped().Delete(&item).Error
+	}
+	return tx.Model(&item).UpdateColumn("quantity", quantity).Error
+}
+
+// ClearCart removes every item from a cart.
+func ClearCart(tx *gorm.DB, cart *models.Cart) error {
+	cart.Items = nil
+	return tx.Unscoped().Where("cart_id = ?", cart.ID).Delete(&models.CartItem{}).Error
+}
+
+// LoadCartItems loads the items of a cart with their products and variants.
+// Items whose product or variant has been deleted are dropped from the cart.
+func LoadCartItems(tx *gorm.DB, cart *models.Cart) error {
+	var items []models.CartItem
+	if err := tx.Preload("Product").Preload("Variant").Where("cart_id = ?", cart.ID).Order("id").Find(&items).Error; err != nil {
+		return err
+	}
+	cart.Items = items[:0]
+	var gone []uint
+	for _, item := range items {
+		if item.Product.ID == 0 || (item.VariantID != nil && item.Variant == nil) {
+			gone = append(gone, item.ID)
+			continue
+		}
+		cart.Items = append(cart.Items, item)
+	}
+	if len(gone) > 0 {
+		return tx.Unscoped().Delete(&models.CartItem{}, gone).Error
+	}
+	return nil
+}
+
+// CartItemPrice returns the current unit price of a loaded cart item.
+func CartItemPrice(item models.CartItem) models.Money {
+	if item.Variant != nil {
+		return item.Variant.EffectivePrice(item.Product)
+	}
+	return item.Product.Price
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCategoryCycle       = errors.New("a category cannot be moved below itself or one of its descendants")
+	ErrCategoryHasChildren = errors.New("category still has sub-categories")
+)
+
+func loadCategories(db *gorm.DB) ([]models.Category, error) {
+	var categories []models.Category
+	err := db.Order("position, id").Find(&categories).Error
+	return categories, err
+}
+
+// childrenByParent indexes categories by parent ID; top level categories are
+// under key 0.
+func childrenByParent(categories []models.Category) map[uint][]models.Category {
+	children := make(map[uint][]models.Category)
+	for _, category := range categories {
+		var parent uint
+		if category.ParentID != nil {
+			parent = *category.ParentID
+		}
+		children[parent] = append(children[parent], category)
+	}
+	return children
+}
+
+// CategoryTree returns all categories as a nested tree ordered by position.
+func CategoryTree(db *gorm.DB) ([]models.CategoryTreeNode, error) {
+	categories, err := loadCategories(db)
+	if err != nil {
+		return nil, err
+	}
+	children := childrenByParent(categories)
+
+	var build func(parent uint, seen map[uint]bool) []models.CategoryTreeNode
+	build = func(parent uint, seen map[uint]bool) []models.CategoryTreeNode {
+		nodes := []models.CategoryTreeNode{}
+		for _, category := range children[parent] {
+			if seen[category.ID] {
+				continue
+			}
+			seen[category.ID] = true
+			nodes = append(nodes, models.CategoryTreeNode{
+				ID:       category.ID,
+				Name:     category.Name,
+				Slug:     category.Slug,
+				ParentID: category.ParentID,
+				Position: category.Position,
+				Children: build(category.ID, seen),
+			})
+		}
+		return nodes
+	}
+	return build(0, map[uint]bool{}), nil
+}
+
+// CategoryAncestry returns the ID of a category followed by those of its
+// parent, grandparent and so on up to the top level.
+func CategoryAncestry(db *gorm.DB, id uint) ([]uint, error) {
+	categories, err := loadCategories(db)
+	if err != nil {
+		return nil, err
+	}
+	parents := make(map[uint]*uint, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+
+	ids := []uint{}
+	seen := map[uint]bool{}
+	for current := &id; current != nil && !seen[*current]; current = parents[*current] {
+		seen[*current] = true
+		ids = append(ids, *current)
+	}
+	return ids, nil
+}
+
+// CategoryWithDescendants returns the ID of the category followed by the IDs
+// of all categories below it.
+func CategoryWithDescendants(db *gorm.DB, id uint) ([]uint, error) {
+	categories, err := loadCategories(db)
+	if err != nil {
+		return nil, err
+	}
+	children := childrenByParent(categories)
+
+	ids := []uint{id}
+	seen := map[uint]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !seen[child.ID] {
+				seen[child.ID] = true
+				ids = append(ids, child.ID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// siblings returns the categories under parent except the given one, locked
+// for update and ordered by position.
+func siblings(tx *gorm.DB, parentID *uint, except uint) ([]models.Category, error) {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id <> ?", except)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	var categories []models.Category
+	err := query.Order("position, id").Find(&categories).Error
+	return categories, err
+}
+
+// renumber stores consecutive positions for the given categories.
+func renumber(tx *gorm.DB, categories []models.Category) error {
+	for i, category := range categories {
+		if category.Position == i {
+			continue
+		}
+		if err := tx.Model(&models.Category{}).Where("id = ?", category.ID).Update("position", i).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NextCategoryPosition returns the position after the last child of parent.
+func NextCategoryPosition(tx *gorm.DB, parentID *uint) (int, error) {
+	others, err := siblings(tx, parentID, 0)
+	return len(others), err
+}
+
+// MoveCategory places a category under a new parent (nil for the top level)
+// at the given position among its new siblings, rejecting moves that would
+// create a cycle. Positions of the old and new siblings are renumbered.
+func MoveCategory(tx *gorm.DB, category *models.Category, parentID *uint, position int) error {
+	if parentID != nil {
+		descendants, err := CategoryWithDescendants(tx, category.ID)
+		if err != nil {
+			return err
+		}
+		for _, id := range descendants {
+			if id == *parentID {
+				return ErrCategoryCycle
+			}
+		}
+		var parent models.Category
+		if err := tx.First(&parent, *parentID).Error; err != nil {
+			return err
+		}
+	}
+
+	oldParentID := category.ParentID
+	newSiblings, err := siblings(tx, parentID, category.ID)
+	if err != nil {
+		return err
+	}
+	if position < 0 {
+		position = 0
+	}
+	if position > len(newSiblings) {
+		position = len(newSiblings)
+	}
+
+	category.ParentID = parentID
+	category.Position = position
+	if err := tx.Model(category).Select("parent_id", "position").Updates(category).Error; err != nil {
+		return err
+	}
+
+	ordered := make([]models.Category, 0, len(newSiblings)+1)
+	ordered = append(ordered, newSiblings[:position]...)
+	ordered = append(ordered, *category)
+	ordered = append(ordered, newSiblings[position:]...)
+	if err := renumber(tx, ordered); err != nil {
+		return err
+	}
+
+	if !sameParent(oldParentID, parentID) {
+		oldSiblings, err := siblings(tx, oldParentID, category.ID)
+		if err != nil {
+			return err
+		}
+		return renumber(tx, oldSiblings)
+	}
+	return nil
+}
+
+// DeleteCategory deletes a category without sub-categories and closes the
+// gap among its siblings.
+func DeleteCategory(tx *gorm.DB, category *models.Category) error {
+	var children int64
+	if err := tx.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children).Error; err != nil {
+		return err
+	}
+	if children > 0 {
+		return ErrCategoryHasChildren
+	}
+	if err := tx.Delete(category).Error; err != nil {
+		return err
+	}
+	others, err := siblings(tx, category.ParentID, category.ID)
+	if err != nil {
+		return err
+	}
+	return renumber(tx, others)
+}
+
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
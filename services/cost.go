@@ -0,0 +1,165 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrUnknownGrouping = errors.New("unknown grouping, expected product, category or supplier")
+
+// UpdateProductCost records a purchase of quantity units at unitCost on the
+// product: the last cost is replaced and the average cost becomes the
+// weighted average of the stock on hand and the purchased goods. It must be
+// called before the goods are put into stock.
+//
+// Costs are kept per product, not per variant: purchases of any variant,
+// and the stock of all variants, blend into the one average cost of the
+// product. Variants whose purchase costs differ widely are therefore costed
+// at the blend, which is accepted in exchange for a single cost to report
+// margins on.
+func UpdateProductCost(tx *gorm.DB, productID uint, quantity uint, unitCost models.Money) error {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+		return err
+	}
+
+	var onHand int
+	err := tx.Model(&models.WarehouseStock{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ?", productID).
+		Scan(&onHand).Error
+	if err != nil {
+		return err
+	}
+	if onHand < 0 {
+		onHand = 0
+	}
+
+	totalQuantity := decimal.NewFromInt(int64(onHand) + int64(quantity))
+	averageCost := unitCost
+	if totalQuantity.IsPositive() {
+		value := product.AverageCost.Times(int64(onHand)).Add(unitCost.Times(int64(quantity)))
+		averageCost = models.Money{Decimal: value.Div(totalQuantity)}.Round()
+	}
+
+	return tx.Model(&product).Updates(map[string]interface{}{
+		"average_cost": averageCost,
+		"last_cost":    unitCost,
+	}).Error
+}
+
+// validSupplierPrices returns the prices of a supplier for a product that are
+// valid at the given moment, cheapest first.
+func validSupplierPrices(db *gorm.DB, supplierID, productID uint, at time.Time) ([]models.SupplierPrice, error) {
+	var prices []models.SupplierPrice
+	err := db.Where("supplier_id = ? AND product_id = ?", supplierID, productID).
+		Where("valid_from <= ? AND (valid_to IS NULL OR valid_to >= ?)", at, at).
+		Order("unit_cost, min_order_quantity").
+		Find(&prices).Error
+	return prices, err
+}
+
+// SupplierQuote picks the cheapest valid supplier price whose minimum order
+// quantity is met. When none is met, the quantity is raised to the lowest
+// minimum order quantity. ok is false when the supplier has no valid price.
+func SupplierQuote(db *gorm.DB, supplierID, productID, quantity uint, at time.Time) (unitCost models.Money, orderQuantity uint, ok bool, err error) {
+	prices, err := validSupplierPrices(db, supplierID, productID, at)
+	if err != nil || len(prices) == 0 {
+		return models.Money{}, quantity, false, err
+	}
+
+	var lowestMOQ *models.SupplierPrice
+	for i, price := range prices {
+		if price.MinOrderQuantity <= quantity {
+			return price.UnitCost, quantity, true, nil
+		}
+		if lowestMOQ == nil || price.MinOrderQuantity < lowestMOQ.MinOrderQuantity {
+			lowestMOQ = &prices[i]
+		}
+	}
+	return lowestMOQ.UnitCost, lowestMOQ.MinOrderQuantity, true, nil
+}
+
+// MarginReport sums revenue and cost of goods sold of the order lines of
+// orders placed in [from, to) per product, category or supplier. Revenue is
+// what the lines sold for after discounts, without tax and shipping. Goods
+// that came back in returns are taken out of the quantity, revenue and cost
+// in proportion, and refunded orders are left out. Zero times leave the
+// period open.
+func MarginReport(db *gorm.DB, groupBy string, from, to time.Time) ([]models.MarginReportRow, error) {
+	query, err := marginReportQuery(db, groupBy, from, to)
+	if err != nil {
+		return nil, err
+	}
+	rows := []models.MarginReportRow{}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].Margin = rows[i].Revenue.Sub(rows[i].Cost)
+		if !rows[i].Revenue.IsZero() {
+			percent := rows[i].Margin.Div(rows[i].Revenue.Decimal).Mul(decimal.NewFromInt(100))
+			rows[i].MarginPercent = percent.Round(2).InexactFloat64()
+		}
+	}
+	return rows, nil
+}
+
+// marginReportQuery builds the query of MarginReport, giving the id, name,
+// quantity, revenue and cost of each group.
+func marginReportQuery(db *gorm.DB, groupBy string, from, to time.Time) (*gorm.DB, error) {
+	var key, name, join string
+	switch groupBy {
+	case "", "product":
+		key, name = "products.id", "products.name"
+	case "category":
+		key, name = "categories.id", "categories.name"
+		join = "JOIN categories ON categories.id = products.category_id"
+	case "supplier":
+		key, name = "suppliers.id", "suppliers.name"
+		join = "JOIN suppliers ON suppliers.id = products.supplier_id"
+	default:
+		return nil, ErrUnknownGrouping
+	}
+
+	// Bagian baris yang tidak dikembalikan
+	kept := "(order_items.quantity - COALESCE(returned.quantity, 0)) / order_items.quantity"
+	returned := db.Model(&models.OrderReturn{}).
+		Select("order_item_id, SUM(quantity) AS quantity").
+		Where("status IN ?", []string{models.ReturnStatusReceived, models.ReturnStatusRefunded}).
+		Group("order_item_id")
+	query := db.Table("order_items").
+		Select(key+" AS id, "+name+" AS name, SUM(order_items.quantity - COALESCE(returned.quantity, 0)) AS quantity, "+
+			"ROUND(SUM(order_items.subtotal / orders.exchange_rate * "+kept+"), ?) AS revenue, ROUND(SUM(order_items.cost_total * "+kept+"), ?) AS cost",
+			models.MoneyScale, models.MoneyScale).
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Joins("JOIN products ON products.id = order_items.product_id").
+		Joins("LEFT JOIN (?) AS returned ON returned.order_item_id = order_items.id", returned).
+		Where("order_items.deleted_at IS NULL AND order_items.quantity > 0 AND orders.status <> ?", models.OrderStatusRefunded)
+	if join != "" {
+		query = query.Joins(join)
+	}
+	if !from.IsZero() {
+		query = query.Where("orders.created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("orders.created_at < ?", to)
+	}
+	return query.Group(key + ", " + name).Order(key), nil
+}
+
+// OrderCost returns the cost of goods sold for quantity units of a product at
+// its current average cost, whichever variant is sold, as costs are not kept
+// per variant (see UpdateProductCost).
+func OrderCost(tx *gorm.DB, productID uint, quantity uint) (models.Money, error) {
+	var product models.Product
+	if err := tx.First(&product, productID).Error; err != nil {
+		return models.Money{}, err
+	}
+	return product.AverageCost.Times(int64(quantity)), nil
+}
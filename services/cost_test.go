@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// dryRunDB returns a database that builds MySQL statements without
+// connecting to a server.
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "test:test@tcp(localhost:3306)/test?parseTime=True", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return db
+}
+
+func marginReportSQL(t *testing.T, groupBy string, from, to time.Time) string {
+	t.Helper()
+	db := dryRunDB(t)
+	return db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		query, err := marginReportQuery(tx, groupBy, from, to)
+		if err != nil {
+			t.Fatalf("%s: %v", groupBy, err)
+		}
+		var rows []models.MarginReportRow
+		return query.Find(&rows)
+	})
+}
+
+func TestMarginReportNetOfReturns(t *testing.T) {
+	sql := marginReportSQL(t, "product", time.Time{}, time.Time{})
+	// Pendapatan dan HPP baris dikurangi sebanding dengan bagian yang dikembalikan
+	kept := "(order_items.quantity - COALESCE(returned.quantity, 0)) / order_items.quantity"
+	for _, want := range []string{
+		"SUM(order_items.quantity - COALESCE(returned.quantity, 0)) AS quantity",
+		"ROUND(SUM(order_items.subtotal / orders.exchange_rate * " + kept + "), 4) AS revenue",
+		"ROUND(SUM(order_items.cost_total * " + kept + "), 4) AS cost",
+		"LEFT JOIN (SELECT order_item_id, SUM(quantity) AS quantity FROM `order_returns` WHERE status IN ('received','refunded')",
+		"GROUP BY `order_item_id`) AS returned ON returned.order_item_id = order_items.id",
+		"order_items.quantity > 0",
+		"orders.status <> 'refunded'",
+		"GROUP BY products.id, products.name",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("margin report does not contain %q:\n%s", want, sql)
+		}
+	}
+}
+
+func TestMarginReportGrouping(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	tests := []struct {
+		groupBy string
+		want    []string
+	}{
+		{"", []string{"GROUP BY products.id, products.name"}},
+		{"category", []string{"JOIN categories ON categories.id = products.category_id", "GROUP BY categories.id, categories.name"}},
+		{"supplier", []string{"JOIN suppliers ON suppliers.id = products.supplier_id", "GROUP BY suppliers.id, suppliers.name"}},
+	}
+	for _, tt := range tests {
+		sql := marginReportSQL(t, tt.groupBy, from, to)
+		for _, want := range append(tt.want, "orders.created_at >= '2026-01-01", "orders.created_at < '2026-02-01") {
+			if !strings.Contains(sql, want) {
+				t.Errorf("%q: margin report does not contain %q:\n%s", tt.groupBy, want, sql)
+			}
+		}
+	}
+
+	if _, err := marginReportQuery(dryRunDB(t), "warehouse", from, to); !errors.Is(err, ErrUnknownGrouping) {
+		t.Errorf("grouping by warehouse: %v, want ErrUnknownGrouping", err)
+	}
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUnknownCurrency   = errors.New("currency must be a three letter ISO 4217 code")
+	ErrInvalidRate       = errors.New("exchange rate must be greater than zero")
+	ErrBaseCurrencyRate  = errors.New("the base currency has no exchange rate")
+	ErrBaseCurrencyPrice = errors.New("the price in the base currency is the product price")
+	ErrNoExchangeRate    = errors.New("no exchange rate")
+)
+
+// ConvertedDecimals is the number of decimal places converted amounts are
+// rounded to.
+const ConvertedDecimals = 2
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// NormaliseCurrency upper-cases a currency code and checks its form. An
+// empty code stands for the base currency.
+func NormaliseCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return BaseCurrency(), nil
+	}
+	if !currencyCode.MatchString(code) {
+		return "", ErrUnknownCurrency
+	}
+	return code, nil
+}
+
+// SaveExchangeRate validates a rate and stores it, replacing the rate the
+// currency already has on the same date.
+func SaveExchangeRate(tx *gorm.DB, rate *models.ExchangeRate) error {
+	currency, err := NormaliseCurrency(rate.Currency)
+	if err != nil {
+		return err
+	}
+	if currency == BaseCurrency() {
+		return ErrBaseCurrencyRate
+	}
+	if !rate.Rate.IsPositive() {
+		return ErrInvalidRate
+	}
+	rate.Currency = currency
+	err = tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "user_id", "updated_at"}),
+	}).Create(rate).Error
+	if err != nil {
+		return err
+	}
+	// ID baris yang diperbarui tidak dikembalikan oleh upsert
+	return tx.Where("currency = ? AND effective_from = ?", rate.Currency, rate.EffectiveFrom).First(rate).Error
+}
+
+// ExchangeRateImportColumns lists the columns of an exchange rate file.
+var ExchangeRateImportColumns = []string{"currency", "rate", "effective_from"}
+
+// ImportExchangeRates stores the rates of an import file read with
+// ReadImportFile. Valid rows are saved and every rejected row is reported.
+func ImportExchangeRates(tx *gorm.DB, rows [][]string, userID *uint) (int, []models.ImportRowError, error) {
+	if len(rows) == 0 {
+		return 0, []models.ImportRowError{{Row: 1, Message: "file is empty"}}, nil
+	}
+	columns := make(map[string]int, len(rows[0]))
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	var missing []string
+	for _, required := range ExchangeRateImportColumns {
+		if _, ok := columns[required]; !ok {
+			missing = append(missing, required)
+		}
+	}
+	if len(missing) > 0 {
+		return 0, []models.ImportRowError{{Row: 1, Message: "missing columns: " + strings.Join(missing, ", ")}}, nil
+	}
+
+	imported := 0
+	var rowErrors []models.ImportRowError
+	for i, record := range rows[1:] {
+		line := i + 2
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		rate := models.ExchangeRate{Currency: field("currency"), Source: "import", UserID: userID}
+		var err error
+		if rate.Rate, err = models.ParseMoney(field("rate")); err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: line, Field: "rate", Message: "must be a number"})
+			continue
+		}
+		if rate.EffectiveFrom, err = time.ParseInLocation("2006-01-02", field("effective_from"), time.Local); err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: line, Field: "effective_from", Message: "must be a date (YYYY-MM-DD)"})
+			continue
+		}
+		err = SaveExchangeRate(tx, &rate)
+		switch {
+		case errors.Is(err, ErrUnknownCurrency), errors.Is(err, ErrBaseCurrencyRate):
+			rowErrors = append(rowErrors, models.ImportRowError{Row: line, Field: "currency", Message: err.Error()})
+		case errors.Is(err, ErrInvalidRate):
+			rowErrors = append(rowErrors, models.ImportRowError{Row: line, Field: "rate", Message: err.Error()})
+		case err != nil:
+			return 0, nil, err
+		default:
+			imported++
+		}
+	}
+	return imported, rowErrors, nil
+}
+
+// CurrencyConverter converts amounts between currencies with the exchange
+// rates in effect at a given time. Rates are loaded once per currency, so a
+// converter should only live as long as a request.
+type CurrencyConverter struct {
+	db    *gorm.DB
+	rates map[string][]models.ExchangeRate // terlama lebih dulu
+}
+
+func NewCurrencyConverter(db *gorm.DB) *CurrencyConverter {
+	return &CurrencyConverter{db: db, rates: map[string][]models.ExchangeRate{}}
+}
+
+// rate returns the rate of a currency in effect at the given time.
+func (c *CurrencyConverter) rate(currency string, at time.Time) (models.Money, error) {
+	rates, ok := c.rates[currency]
+	if !ok {
+		if err := c.db.Where("currency = ?", currency).Order("effective_from").Find(&rates).Error; err != nil {
+			return models.Money{}, err
+		}
+		c.rates[currency] = rates
+	}
+	for i := len(rates) - 1; i >= 0; i-- {
+		if !rates[i].EffectiveFrom.After(at) {
+			return rates[i].Rate, nil
+		}
+	}
+	return models.Money{}, fmt.Errorf("%w for %s on %s", ErrNoExchangeRate, currency, at.Format("2006-01-02"))
+}
+
+// Convert converts an amount from one currency to another at the rates in
+// effect at the given time, going through the base currency when neither is
+// the base currency. Converted amounts are rounded to ConvertedDecimals.
+func (c *CurrencyConverter) Convert(amount models.Money, from, to string, at time.Time) (models.Money, error) {
+	if from == to {
+		return amount, nil
+	}
+	value := amount.Decimal
+	if from != BaseCurrency() {
+		rate, err := c.rate(from, at)
+		if err != nil {
+			return models.Money{}, err
+		}
+		value = value.Div(rate.Decimal)
+	}
+	if to != BaseCurrency() {
+		rate, err := c.rate(to, at)
+		if err != nil {
+			return models.Money{}, err
+		}
+		value = value.Mul(rate.Decimal)
+	}
+	return models.Money{Decimal: value.Round(ConvertedDecimals)}, nil
+}
+
+// ProductPricesIn returns the price overrides of the products in a currency
+// by product ID.
+func ProductPricesIn(db *gorm.DB, currency string, productIDs []uint) (map[uint]models.Money, error) {
+	prices := map[uint]models.Money{}
+	if len(productIDs) == 0 {
+		return prices, nil
+	}
+	var overrides []models.ProductPrice
+	if err := db.Where("currency = ? AND product_id IN ?", currency, productIDs).Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	for _, override := range overrides {
+		prices[override.ProductID] = override.Price
+	}
+	return prices, nil
+}
+
+// ConvertProductPrices replaces the prices of the products, which are in the
+// base currency, with their price in another currency: the override for that
+// currency when there is one, the price converted at today's rate otherwise.
+func ConvertProductPrices(db *gorm.DB, products []models.Product, currency string) error {
+	base := BaseCurrency()
+	if currency != base {
+		ids := make([]uint, len(products))
+		for i, product := range products {
+			ids[i] = product.ID
+		}
+		overrides, err := ProductPricesIn(db, currency, ids)
+		if err != nil {
+			return err
+		}
+		converter := NewCurrencyConverter(db)
+		now := time.Now()
+		for i := range products {
+			if price, ok := overrides[products[i].ID]; ok {
+				products[i].Price = price
+				continue
+			}
+			if products[i].Price, err = converter.Convert(products[i].Price, base, currency, now); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range products {
+		products[i].Currency = currency
+	}
+	return nil
+}
+
+// SetProductPrice sets the price override of a product in a currency other
+// than the base currency.
+func SetProductPrice(tx *gorm.DB, productID uint, currency string, price models.Money) (*models.ProductPrice, error) {
+	currency, err := NormaliseCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if currency == BaseCurrency() {
+		return nil, ErrBaseCurrencyPrice
+	}
+	if price.IsNegative() {
+		return nil, ErrInvalidPrice
+	}
+	override := &models.ProductPrice{ProductID: productID, Currency: currency, Price: price.Round()}
+	err = tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"price", "updated_at"}),
+	}).Create(override).Error
+	return override, err
+}
+
+// ConvertOrderTotals replaces the amounts of the orders, lines, taxes and
+// discounts included, with their amount in another currency. Amounts go back
+// to the base currency at the exchange rate kept on the order and on from
+// there at the rate in effect when the order was placed. Costs are kept in
+// the base currency.
+func ConvertOrderTotals(db *gorm.DB, orders []models.Order, currency string) error {
+	base := BaseCurrency()
+	converter := NewCurrencyConverter(db)
+	for i := range orders {
+		order := &orders[i]
+		from := order.Currency
+		if from == "" {
+			from = base
+		}
+		amounts := []*models.Money{
+			&order.Subtotal, &order.TaxTotal, &order.DiscountTotal, &order.ShippingTotal, &order.Total,
+		}
+		costs := []*models.Money{&order.CostTotal}
+		for j := range order.Items {
+			item := &order.Items[j]
+			amounts = append(amounts, &item.UnitPrice, &item.DiscountTotal, &item.Subtotal, &item.TaxTotal)
+			costs = append(costs, &item.CostTotal)
+		}
+		for j := range order.Taxes {
+			amounts = append(amounts, &order.Taxes[j].TaxableAmount, &order.Taxes[j].Amount)
+		}
+		for j := range order.Discounts {
+			amounts = append(amounts, &order.Discounts[j].Amount)
+		}
+		if from != currency {
+			for _, amount := range amounts {
+				converted := *amount
+				if from != base {
+					converted = models.Money{Decimal: amount.Div(order.ExchangeRate.Decimal).Round(ConvertedDecimals)}
+				}
+				converted, err := converter.Convert(converted, base, currency, order.CreatedAt)
+				if err != nil {
+					return err
+				}
+				*amount = converted
+			}
+		}
+		for _, cost := range costs {
+			converted, err := converter.Convert(*cost, base, currency, order.CreatedAt)
+			if err != nil {
+				return err
+			}
+			*cost = converted
+		}
+		order.Currency = currency
+		order.ExchangeRate = models.NewMoney(1)
+		if currency != base {
+			rate, err := converter.rate(currency, order.CreatedAt)
+			if err != nil {
+				return err
+			}
+			order.ExchangeRate = rate
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+)
+
+func TestCurrencyConverterConvert(t *testing.T) {
+	t.Setenv("CURRENCY", "IDR")
+	now := time.Now()
+	rate := func(value string, from time.Time) models.ExchangeRate {
+		return models.ExchangeRate{Rate: models.Money{Decimal: dec(t, value)}, EffectiveFrom: from}
+	}
+	// Kurs sudah dimuat, sehingga konverter tidak perlu membaca basis data
+	converter := &CurrencyConverter{rates: map[string][]models.ExchangeRate{
+		"USD": {rate("0.00007", now.AddDate(0, 0, -30)), rate("0.000065", now.AddDate(0, 0, -1))},
+		"EUR": {rate("0.00006", now.AddDate(0, 0, -1))},
+	}}
+	tests := []struct {
+		amount   string
+		from, to string
+		at       time.Time
+		want     string
+	}{
+		{"12.345", "IDR", "IDR", now, "12.345"},
+		{"150000", "IDR", "USD", now, "9.75"},
+		{"150000", "IDR", "USD", now.AddDate(0, 0, -7), "10.5"},
+		{"100", "IDR", "USD", now, "0.01"},
+		{"9.75", "USD", "IDR", now, "150000"},
+		{"1", "USD", "IDR", now, "15384.62"},
+		{"10", "USD", "EUR", now, "9.23"},
+	}
+	for _, tt := range tests {
+		got, err := converter.Convert(models.Money{Decimal: dec(t, tt.amount)}, tt.from, tt.to, tt.at)
+		if err != nil {
+			t.Fatalf("%s %s to %s: %v", tt.amount, tt.from, tt.to, err)
+		}
+		if !got.Equal(models.Money{Decimal: dec(t, tt.want)}) {
+			t.Errorf("%s %s to %s: %s, want %s", tt.amount, tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	if _, err := converter.Convert(models.NewMoney(1), "IDR", "EUR", now.AddDate(0, 0, -7)); !errors.Is(err, ErrNoExchangeRate) {
+		t.Errorf("convert before the first rate: %v, want ErrNoExchangeRate", err)
+	}
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+)
+
+var ErrUnknownCustomer = errors.New("unknown customer")
+
+// CheckCustomer returns ErrUnknownCustomer when a customer is given that
+// does not exist.
+func CheckCustomer(db *gorm.DB, id *uint) error {
+	if id == nil {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&models.Customer{}).Where("id = ?", *id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrUnknownCustomer
+	}
+	return nil
+}
+
+// CustomerByUser returns the customer profile of a user.
+func CustomerByUser(db *gorm.DB, userID uint) (*models.Customer, error) {
+	var customer models.Customer
+	if err := db.Where("user_id = ?", userID).First(&customer).Error; err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
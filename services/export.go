@@ -0,0 +1,258 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Export formats.
+const (
+	ExportCSV    = "csv"
+	ExportXLSX   = "xlsx"
+	ExportNDJSON = "ndjson"
+)
+
+var ErrUnknownExportFormat = errors.New("unknown export format, use csv, xlsx or ndjson")
+
+// exportBatchSize is the number of records loaded at a time while exporting.
+const exportBatchSize = 500
+
+// ExportContentTypes maps export formats to their content type.
+var ExportContentTypes = map[string]string{
+	ExportCSV:    "text/csv; charset=utf-8",
+	ExportXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	ExportNDJSON: "application/x-ndjson",
+}
+
+// ExportWriter writes records one at a time in an export format.
+type ExportWriter interface {
+	WriteRow(values []interface{}) error
+	// Close flushes what is buffered; the writer cannot be used afterwards.
+	Close() error
+}
+
+// NewExportWriter returns a writer for the format that writes the columns as
+// a header (or, for NDJSON, as the keys of every object) to w.
+func NewExportWriter(format string, w io.Writer, columns []string) (ExportWriter, error) {
+	switch format {
+	case ExportCSV:
+		writer := &csvExportWriter{w: csv.NewWriter(w)}
+		return writer, writer.w.Write(columns)
+	case ExportNDJSON:
+		return &ndjsonExportWriter{w: w, columns: columns}, nil
+	case ExportXLSX:
+		return newXLSXExportWriter(w, columns)
+	default:
+		return nil, ErrUnknownExportFormat
+	}
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (e *csvExportWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatExportValue(value)
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExportWriter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonExportWriter struct {
+	w       io.Writer
+	columns []string
+}
+
+func (e *ndjsonExportWriter) WriteRow(values []interface{}) error {
+	// Objek ditulis manual agar urutan kolom tetap sama dengan format lain
+	line := []byte{'{'}
+	for i, column := range e.columns {
+		if i > 0 {
+			line = append(line, ',')
+		}
+		key, _ := json.Marshal(column)
+		value, err := json.Marshal(values[i])
+		if err != nil {
+			return err
+		}
+		line = append(append(append(line, key...), ':'), value...)
+	}
+	line = append(line, '}', '\n')
+	_, err := e.w.Write(line)
+	return err
+}
+
+func (e *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// xlsxExportWriter uses the excelize stream writer, which keeps rows on disk
+// rather than in memory until the workbook is written out on Close.
+type xlsxExportWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXExportWriter(w io.Writer, columns []string) (*xlsxExportWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(file.GetSheetName(0))
+	if err != nil {
+		return nil, err
+	}
+	writer := &xlsxExportWriter{w: w, file: file, stream: stream, row: 1}
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	return writer, writer.WriteRow(header)
+}
+
+func (e *xlsxExportWriter) WriteRow(values []interface{}) error {
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case *uint, *string, *time.Time, time.Time:
+			cells[i] = formatExportValue(v)
+		case models.Money:
+			cells[i] = v.InexactFloat64()
+		case decimal.Decimal:
+			cells[i] = v.InexactFloat64()
+		default:
+			cells[i] = v
+		}
+	}
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	e.row++
+	return e.stream.SetRow(cell, cells)
+}
+
+func (e *xlsxExportWriter) Close() error {
+	defer e.file.Close()
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.w)
+}
+
+// formatExportValue renders a value as text, leaving nil pointers empty.
+func formatExportValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case *uint:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(*v)
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ProductExportColumns lists the columns of a product export.
+var ProductExportColumns = []string{
+	"id", "sku", "name", "description", "price", "category_id", "category", "supplier_id", "supplier",
+	"average_cost", "last_cost", "reorder_point", "reorder_quantity", "slug", "created_at", "updated_at",
+}
+
+// ExportProducts writes the products matched by query in batches.
+func ExportProducts(query *gorm.DB, w ExportWriter) error {
+	var batch []models.Product
+	return query.Preload("Category").Preload("Supplier").
+		FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, p := range batch {
+				err := w.WriteRow([]interface{}{
+					p.ID, p.SKU, p.Name, p.Description, p.Price, p.CategoryID, p.Category.Name, p.SupplierID, p.Supplier.Name,
+					p.AverageCost, p.LastCost, p.ReorderPoint, p.ReorderQuantity, p.Slug, p.CreatedAt, p.UpdatedAt,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// OrderExportColumns lists the columns of an order export: one row per
+// order line, the line first and then the order it belongs to.
+var OrderExportColumns = []string{
+	"id", "created_at", "item_id", "product_id", "product", "variant_id", "quantity", "unit_price", "item_discount_total", "item_subtotal",
+	"item_tax_total", "item_cost_total", "warehouse_id", "subtotal", "tax_total", "total", "cost_total", "currency", "exchange_rate",
+	"tax_region", "discount_total", "shipping_total", "coupon_code",
+	"customer_id", "user_id", "shipping_city", "shipping_postal_code", "shipping_country", "status",
+}
+
+// ExportOrders writes the lines of the orders matched by query in batches.
+func ExportOrders(query *gorm.DB, w ExportWriter) error {
+	var batch []models.Order
+	return query.Preload("Items.Product", unscoped).
+		FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, o := range batch {
+				for _, item := range o.Items {
+					err := w.WriteRow([]interface{}{
+						o.ID, o.CreatedAt, item.ID, item.ProductID, item.Product.Name, item.VariantID, item.Quantity, item.UnitPrice, item.DiscountTotal, item.Subtotal,
+						item.TaxTotal, item.CostTotal, item.WarehouseID, o.Subtotal, o.TaxTotal, o.Total, o.CostTotal, o.Currency, o.ExchangeRate,
+						o.TaxRegion, o.DiscountTotal, o.ShippingTotal, o.CouponCode,
+						o.CustomerID, o.UserID, o.ShippingAddress.City, o.ShippingAddress.PostalCode, o.ShippingAddress.Country, o.Status,
+					})
+					if err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}).Error
+}
+
+// SupplierExportColumns lists the columns of a supplier export.
+var SupplierExportColumns = []string{
+	"id", "name", "email", "phone", "tax_id", "payment_term_days", "created_at",
+}
+
+// ExportSuppliers writes the suppliers matched by query in batches.
+func ExportSuppliers(query *gorm.DB, w ExportWriter) error {
+	var batch []models.Supplier
+	return query.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, s := range batch {
+			err := w.WriteRow([]interface{}{
+				s.ID, s.Name, s.Email, s.Phone, s.TaxID, s.PaymentTermDays, s.CreatedAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
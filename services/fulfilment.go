@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Fulfilment strategies.
+const (
+	StrategyNearest   = "nearest"
+	StrategyMostStock = "most_stock"
+	StrategyPriority  = "priority"
+)
+
+var ErrUnknownStrategy = errors.New("unknown fulfilment strategy")
+
+// DefaultStrategy returns the strategy configured through FULFILMENT_STRATEGY,
+// falling back to priority.
+func DefaultStrategy() string {
+	if s := os.Getenv("FULFILMENT_STRATEGY"); s != "" {
+		return s
+	}
+	return StrategyPriority
+}
+
+// FulfilmentRequest describes what has to be shipped and, optionally, where to.
+type FulfilmentRequest struct {
+	ProductID uint
+	VariantID uint
+	Quantity  uint
+	Strategy  string
+	Latitude  *float64
+	Longitude *float64
+	OrderID   uint
+	UserID    *uint
+}
+
+type candidate struct {
+	warehouse models.Warehouse
+	quantity  int
+}
+
+// ChooseWarehouse picks an active warehouse able to ship the whole quantity
+// using the requested strategy. The nearest strategy falls back to priority
+// when no destination coordinates are given.
+func ChooseWarehouse(tx *gorm.DB, req FulfilmentRequest) (*models.Warehouse, error) {
+	strategy := req.Strategy
+	if strategy == "" {
+		strategy = DefaultStrategy()
+	}
+	if strategy != StrategyNearest && strategy != StrategyMostStock && strategy != StrategyPriority {
+		return nil, ErrUnknownStrategy
+	}
+	if strategy == StrategyNearest && (req.Latitude == nil || req.Longitude == nil) {
+		strategy = StrategyPriority
+	}
+
+	var stocks []models.WarehouseStock
+	err := tx.Preload("Warehouse").
+		Joins("JOIN warehouses ON warehouses.id = warehouse_stocks.warehouse_id AND warehouses.deleted_at IS NULL").
+		Where("warehouse_stocks.product_id = ? AND warehouse_stocks.variant_id = ? AND warehouse_stocks.quantity >= ? AND warehouses.active = ?", req.ProductID, req.VariantID, req.Quantity, true).
+		Find(&stocks).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(stocks) == 0 {
+		return nil, ErrInsufficientStock
+	}
+
+	candidates := make([]candidate, 0, len(stocks))
+	for _, s := range stocks {
+		candidates = append(candidates, candidate{warehouse: s.Warehouse, quantity: s.Quantity})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch strategy {
+		case StrategyNearest:
+			da := distanceKm(*req.Latitude, *req.Longitude, a.warehouse.Latitude, a.warehouse.Longitude)
+			db := distanceKm(*req.Latitude, *req.Longitude, b.warehouse.Latitude, b.warehouse.Longitude)
+			if da != db {
+				return da < db
+			}
+		case StrategyMostStock:
+			if a.quantity != b.quantity {
+				return a.quantity > b.quantity
+			}
+		}
+		if a.warehouse.Priority != b.warehouse.Priority {
+			return a.warehouse.Priority < b.warehouse.Priority
+		}
+		return a.warehouse.ID < b.warehouse.ID
+	})
+
+	chosen := candidates[0].warehouse
+	return &chosen, nil
+}
+
+// Fulfil chooses a warehouse for the request and books the quantity out of
+// its stock as a sale.
+func Fulfil(tx *gorm.DB, req FulfilmentRequest) (*models.Warehouse, error) {
+	warehouse, err := ChooseWarehouse(tx, req)
+	if err != nil {
+		return nil, err
+	}
+	err = MoveStock(tx, &models.StockMovement{
+		WarehouseID: warehouse.ID,
+		ProductID:   req.ProductID,
+		VariantID:   req.VariantID,
+		Type:        models.MovementTypeSale,
+		Quantity:    -int(req.Quantity),
+		OrderID:     &req.OrderID,
+		UserID:      req.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return warehouse, nil
+}
+
+// FulfilOrder takes the stock of every line of a saved order from the
+// warehouse the strategy of the request chooses for it, and works out the
+// cost of goods sold of the lines and the order.
+func FulfilOrder(tx *gorm.DB, order *models.Order, req FulfilmentRequest) error {
+	order.CostTotal = models.Money{}
+	for i := range order.Items {
+		item := &order.Items[i]
+		lineReq := req
+		lineReq.ProductID = item.ProductID
+		lineReq.VariantID = 0
+		if item.VariantID != nil {
+			lineReq.VariantID = *item.VariantID
+		}
+		lineReq.Quantity = item.Quantity
+		lineReq.OrderID = order.ID
+		warehouse, err := Fulfil(tx, lineReq)
+		if err != nil {
+			return err
+		}
+		item.WarehouseID = &warehouse.ID
+		if item.CostTotal, err = OrderCost(tx, item.ProductID, item.Quantity); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(item).Error; err != nil {
+			return err
+		}
+		order.CostTotal = order.CostTotal.Add(item.CostTotal)
+	}
+	return nil
+}
+
+// ReleaseOrderStock puts the stock taken by the lines of an order back into
+// the warehouses that shipped them.
+func ReleaseOrderStock(tx *gorm.DB, order *models.Order, movementType, note string, userID *uint) error {
+	for _, item := range order.Items {
+		if item.WarehouseID == nil {
+			continue
+		}
+		var variantID uint
+		if item.VariantID != nil {
+			variantID = *item.VariantID
+		}
+		err := MoveStock(tx, &models.StockMovement{
+			WarehouseID: *item.WarehouseID,
+			ProductID:   item.ProductID,
+			VariantID:   variantID,
+			Type:        movementType,
+			Quantity:    int(item.Quantity),
+			Note:        note,
+			OrderID:     &order.ID,
+			UserID:      userID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// distanceKm returns the great-circle distance between two coordinates.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := rad(lat2 - lat1)
+	dLon := rad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/storage"
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp" // decoder untuk unggahan WebP
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image type, use JPEG, PNG, GIF or WebP")
+	ErrImageTooLarge    = errors.New("image is too large")
+	ErrImageDimensions  = errors.New("image has too many pixels")
+	ErrImageOrder       = errors.New("the new order must list every image of the product exactly once")
+)
+
+// AllowedImageTypes are the content types accepted for uploads, detected from
+// the file contents rather than trusted from the client.
+var AllowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Thumbnail is a generated size of every product image; it fits within
+// Size x Size pixels and is never upscaled.
+type Thumbnail struct {
+	Name string
+	Size int
+}
+
+// ThumbnailSizes lists the thumbnails generated for every upload.
+var ThumbnailSizes = []Thumbnail{
+	{Name: "small", Size: 150},
+	{Name: "medium", Size: 400},
+	{Name: "large", Size: 800},
+}
+
+// MaxImageSize returns the largest accepted upload in bytes, configured
+// through MAX_IMAGE_SIZE and defaulting to 5 MiB.
+func MaxImageSize() int64 {
+	if size, err := strconv.ParseInt(os.Getenv("MAX_IMAGE_SIZE"), 10, 64); err == nil && size > 0 {
+		return size
+	}
+	return 5 << 20
+}
+
+// MaxImagePixels returns the largest accepted width times height of an
+// upload, configured through MAX_IMAGE_PIXELS and defaulting to 40
+// megapixels. Small files can claim huge dimensions, so this is checked
+// before the image is decoded.
+func MaxImagePixels() int64 {
+	if pixels, err := strconv.ParseInt(os.Getenv("MAX_IMAGE_PIXELS"), 10, 64); err == nil && pixels > 0 {
+		return pixels
+	}
+	return 40_000_000
+}
+
+// ThumbnailKey returns the storage key of a thumbnail of the image. Images
+// with transparency keep it by using PNG thumbnails.
+func ThumbnailKey(img models.ProductImage, name string) string {
+	ext := ".jpg"
+	if img.ContentType == "image/png" || img.ContentType == "image/gif" {
+		ext = ".png"
+	}
+	return path.Join(path.Dir(img.Key), name+ext)
+}
+
+// imageKeys returns the keys of the original and all thumbnails.
+func imageKeys(img models.ProductImage) []string {
+	keys := []string{img.Key}
+	for _, thumbnail := range ThumbnailSizes {
+		keys = append(keys, ThumbnailKey(img, thumbnail.Name))
+	}
+	return keys
+}
+
+// UploadProductImage validates an uploaded image, writes it and its
+// thumbnails to the store and returns the unsaved image record.
+func UploadProductImage(ctx context.Context, store storage.Storage, productID uint, fileName string, data []byte) (*models.ProductImage, error) {
+	if int64(len(data)) > MaxImageSize() {
+		return nil, fmt.Errorf("%w: the limit is %d bytes", ErrImageTooLarge, MaxImageSize())
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := AllowedImageTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if int64(config.Width)*int64(config.Height) > MaxImagePixels() {
+		return nil, fmt.Errorf("%w: the limit is %d pixels", ErrImageDimensions, MaxImagePixels())
+	}
+	src, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	img := &models.ProductImage{
+		ProductID:   productID,
+		Key:         fmt.Sprintf("products/%d/%s/original%s", productID, hex.EncodeToString(random), ext),
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       src.Bounds().Dx(),
+		Height:      src.Bounds().Dy(),
+	}
+
+	if err := store.Put(ctx, img.Key, bytes.NewReader(data), img.Size, contentType); err != nil {
+		return nil, err
+	}
+	for _, thumbnail := range ThumbnailSizes {
+		if err := putThumbnail(ctx, store, *img, thumbnail, src); err != nil {
+			RemoveProductImageFiles(ctx, store, *img)
+			return nil, err
+		}
+	}
+	return img, nil
+}
+
+func putThumbnail(ctx context.Context, store storage.Storage, img models.ProductImage, thumbnail Thumbnail, src image.Image) error {
+	key := ThumbnailKey(img, thumbnail.Name)
+	format, contentType := imaging.JPEG, "image/jpeg"
+	if path.Ext(key) == ".png" {
+		format, contentType = imaging.PNG, "image/png"
+	}
+
+	var buf bytes.Buffer
+	resized := imaging.Fit(src, thumbnail.Size, thumbnail.Size, imaging.Lanczos)
+	if err := imaging.Encode(&buf, resized, format, imaging.JPEGQuality(85)); err != nil {
+		return err
+	}
+	return store.Put(ctx, key, &buf, int64(buf.Len()), contentType)
+}
+
+// RemoveProductImageFiles deletes the original and thumbnails of an image
+// from the store. Failures are ignored: an orphaned file is harmless.
+func RemoveProductImageFiles(ctx context.Context, store storage.Storage, img models.ProductImage) {
+	for _, key := range imageKeys(img) {
+		_ = store.Delete(ctx, key)
+	}
+}
+
+// productImages returns the images of a product locked for update, in order.
+func productImages(tx *gorm.DB, productID uint) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ?", productID).
+		Order("position, id").
+		Find(&images).Error
+	return images, err
+}
+
+// AddProductImage saves an uploaded image at the end of the product's
+// images. The first image of a product becomes its primary image.
+func AddProductImage(tx *gorm.DB, img *models.ProductImage) error {
+	images, err := productImages(tx, img.ProductID)
+	if err != nil {
+		return err
+	}
+	img.Position = len(images)
+	img.IsPrimary = len(images) == 0
+	return tx.Create(img).Error
+}
+
+// SetPrimaryImage makes one image the primary image of its product.
+func SetPrimaryImage(tx *gorm.DB, img *models.ProductImage) error {
+	err := tx.Model(&models.ProductImage{}).
+		Where("product_id = ?", img.ProductID).
+		Update("is_primary", gorm.Expr("id = ?", img.ID)).Error
+	if err != nil {
+		return err
+	}
+	img.IsPrimary = true
+	return nil
+}
+
+// ReorderProductImages puts the images of a product in the given order.
+func ReorderProductImages(tx *gorm.DB, productID uint, ids []uint) ([]models.ProductImage, error) {
+	images, err := productImages(tx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) != len(images) {
+		return nil, ErrImageOrder
+	}
+	byID := make(map[uint]models.ProductImage, len(images))
+	for _, img := range images {
+		byID[img.ID] = img
+	}
+
+	ordered := make([]models.ProductImage, 0, len(ids))
+	for position, id := range ids {
+		img, ok := byID[id]
+		if !ok {
+			return nil, ErrImageOrder
+		}
+		delete(byID, id)
+		img.Position = position
+		if err := tx.Model(&img).UpdateColumn("position", position).Error; err != nil {
+			return nil, err
+		}
+		ordered = append(ordered, img)
+	}
+	return ordered, nil
+}
+
+// DeleteProductImage removes an image record, closes the gap in the order
+// and promotes the next image when the primary one was removed. The files
+// are left for the caller to remove once the transaction has committed.
+func DeleteProductImage(tx *gorm.DB, img *models.ProductImage) error {
+	if err := tx.Unscoped().Delete(img).Error; err != nil {
+		return err
+	}
+	images, err := productImages(tx, img.ProductID)
+	if err != nil {
+		return err
+	}
+	for position, other := range images {
+		if other.Position != position {
+			if err := tx.Model(&other).UpdateColumn("position", position).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if img.IsPrimary && len(images) > 0 {
+		return SetPrimaryImage(tx, &images[0])
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrOrderInvoiced = errors.New("order has been invoiced and can no longer be changed")
+
+// FiscalYearStartMonth returns the month fiscal years start in, configured
+// through FISCAL_YEAR_START_MONTH (1 to 12) and defaulting to January.
+func FiscalYearStartMonth() time.Month {
+	if month, err := strconv.Atoi(os.Getenv("FISCAL_YEAR_START_MONTH")); err == nil && month >= 1 && month <= 12 {
+		return time.Month(month)
+	}
+	return time.January
+}
+
+// FiscalYear returns the fiscal year a moment falls in, named after the
+// calendar year it starts in.
+func FiscalYear(t time.Time) int {
+	if t.Month() < FiscalYearStartMonth() {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+// InvoiceNumber formats the number of an invoice, such as INV-2026-000042.
+// The prefix is configured through INVOICE_PREFIX and defaults to INV.
+func InvoiceNumber(fiscalYear int, sequence uint) string {
+	prefix := strings.TrimSpace(os.Getenv("INVOICE_PREFIX"))
+	if prefix == "" {
+		prefix = "INV"
+	}
+	return fmt.Sprintf("%s-%d-%06d", prefix, fiscalYear, sequence)
+}
+
+// nextInvoiceSequence takes the next number of the fiscal year. The
+// sequence row stays locked until the transaction ends, so numbers are
+// handed out one at a time, and a number taken by a transaction that rolls
+// back is taken again by the next one: the sequence has no gaps.
+func nextInvoiceSequence(tx *gorm.DB, fiscalYear int) (uint, error) {
+	sequence := models.InvoiceSequence{FiscalYear: fiscalYear}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sequence).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sequence, "fiscal_year = ?", fiscalYear).Error; err != nil {
+		return 0, err
+	}
+	sequence.LastNumber++
+	err := tx.Model(&sequence).Where("fiscal_year = ?", fiscalYear).UpdateColumn("last_number", sequence.LastNumber).Error
+	return sequence.LastNumber, err
+}
+
+// OrderInvoiced reports whether an invoice has been issued for an order.
+func OrderInvoiced(tx *gorm.DB, orderID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Invoice{}).Where("order_id = ?", orderID).Count(&count).Error
+	return count > 0, err
+}
+
+// unscoped lets preloads find products and variants deleted since the
+// order was placed.
+func unscoped(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// IssueInvoice issues the invoice of an order with the next number of the
+// current fiscal year. An order is only invoiced once: when it already has
+// an invoice, that invoice is returned and issued is false.
+func IssueInvoice(tx *gorm.DB, orderID uint) (invoice *models.Invoice, issued bool, err error) {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Items.Product", unscoped).Preload("Items.Variant", unscoped).Preload("Taxes").Preload("Customer").
+		First(&order, orderID).Error; err != nil {
+		return nil, false, err
+	}
+	var existing []models.Invoice
+	if err := tx.Preload("Items").Preload("Taxes").Where("order_id = ?", order.ID).Limit(1).Find(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	if len(existing) > 0 {
+		return &existing[0], false, nil
+	}
+
+	now := time.Now()
+	fiscalYear := FiscalYear(now)
+	sequence, err := nextInvoiceSequence(tx, fiscalYear)
+	if err != nil {
+		return nil, false, err
+	}
+	invoice = &models.Invoice{
+		OrderID:          order.ID,
+		Number:           InvoiceNumber(fiscalYear, sequence),
+		FiscalYear:       fiscalYear,
+		Sequence:         sequence,
+		IssuedAt:         now,
+		BillingAddress:   order.BillingAddress,
+		Subtotal:         order.Subtotal,
+		DiscountTotal:    order.DiscountTotal,
+		ShippingTotal:    order.ShippingTotal,
+		TaxTotal:         order.TaxTotal,
+		Total:            order.Total,
+		Currency:         order.Currency,
+		PricesIncludeTax: order.PricesIncludeTax,
+	}
+	if order.Customer != nil {
+		invoice.CustomerName = order.Customer.Name
+	} else {
+		invoice.CustomerName = order.BillingAddress.Recipient
+	}
+	for _, item := range order.Items {
+		line := models.InvoiceItem{
+			Description: item.Product.Name,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Subtotal:    item.Subtotal,
+		}
+		if item.Variant != nil {
+			line.SKU = item.Variant.SKU
+		} else if item.Product.SKU != nil {
+			line.SKU = *item.Product.SKU
+		}
+		invoice.Items = append(invoice.Items, line)
+	}
+	for _, tax := range order.Taxes {
+		invoice.Taxes = append(invoice.Taxes, models.InvoiceTax{
+			Name:          tax.Name,
+			Region:        tax.Region,
+			Rate:          tax.Rate,
+			TaxableAmount: tax.TaxableAmount,
+			Amount:        tax.Amount,
+		})
+	}
+	if err := tx.Create(invoice).Error; err != nil {
+		return nil, false, err
+	}
+	return invoice, true, nil
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/go-pdf/fpdf"
+)
+
+// InvoiceTemplate is the layout of invoice PDFs: the company shown in the
+// header and the texts printed around the figures.
+type InvoiceTemplate struct {
+	CompanyName    string
+	CompanyAddress []string
+	CompanyTaxID   string
+	CompanyEmail   string
+	Title          string
+	Footer         string
+}
+
+// InvoiceTemplateFromEnv returns the invoice template configured through
+// COMPANY_NAME, COMPANY_ADDRESS (lines separated by semicolons),
+// COMPANY_TAX_ID, COMPANY_EMAIL, INVOICE_TITLE (default "INVOICE") and
+// INVOICE_FOOTER.
+func InvoiceTemplateFromEnv() InvoiceTemplate {
+	tmpl := InvoiceTemplate{
+		CompanyName:  strings.TrimSpace(os.Getenv("COMPANY_NAME")),
+		CompanyTaxID: strings.TrimSpace(os.Getenv("COMPANY_TAX_ID")),
+		CompanyEmail: strings.TrimSpace(os.Getenv("COMPANY_EMAIL")),
+		Title:        strings.TrimSpace(os.Getenv("INVOICE_TITLE")),
+		Footer:       strings.TrimSpace(os.Getenv("INVOICE_FOOTER")),
+	}
+	for _, line := range strings.Split(os.Getenv("COMPANY_ADDRESS"), ";") {
+		if line = strings.TrimSpace(line); line != "" {
+			tmpl.CompanyAddress = append(tmpl.CompanyAddress, line)
+		}
+	}
+	if tmpl.Title == "" {
+		tmpl.Title = "INVOICE"
+	}
+	return tmpl
+}
+
+// addressLines returns the lines an address is printed on.
+func addressLines(a models.Address) []string {
+	var lines []string
+	for _, line := range []string{
+		a.Street,
+		strings.TrimSpace(strings.Join([]string{a.City, a.Province, a.PostalCode}, " ")),
+		a.Country,
+		a.Phone,
+	} {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// invoiceColumn is a column of a table of an invoice PDF.
+type invoiceColumn struct {
+	title string
+	width float64
+	align string
+}
+
+// RenderInvoicePDF writes an invoice as an A4 PDF laid out by the template:
+// the company header, the invoice and customer details, the items, the tax
+// breakdown and the totals.
+func RenderInvoicePDF(w io.Writer, invoice models.Invoice, tmpl InvoiceTemplate) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(invoice.Number, true)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	// Font bawaan PDF hanya mengenal cp1252
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	money := func(m models.Money) string {
+		return tr(m.StringFixed(2) + " " + invoice.Currency)
+	}
+	if tmpl.Footer != "" {
+		pdf.SetFooterFunc(func() {
+			pdf.SetY(-15)
+			pdf.SetFont("Helvetica", "I", 8)
+			pdf.CellFormat(0, 5, tr(tmpl.Footer), "", 0, "C", false, 0, "")
+		})
+	}
+	pdf.AddPage()
+
+	// Kop perusahaan di kiri, judul dan nomor faktur di kanan
+	top := pdf.GetY()
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(100, 7, tr(tmpl.CompanyName), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range tmpl.CompanyAddress {
+		pdf.CellFormat(100, 4.5, tr(line), "", 1, "L", false, 0, "")
+	}
+	if tmpl.CompanyTaxID != "" {
+		pdf.CellFormat(100, 4.5, tr("Tax ID: "+tmpl.CompanyTaxID), "", 1, "L", false, 0, "")
+	}
+	if tmpl.CompanyEmail != "" {
+		pdf.CellFormat(100, 4.5, tr(tmpl.CompanyEmail), "", 1, "L", false, 0, "")
+	}
+	bottom := pdf.GetY()
+
+	pdf.SetXY(115, top)
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(80, 9, tr(tmpl.Title), "", 2, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range []string{
+		"Number: " + invoice.Number,
+		"Date: " + invoice.IssuedAt.Format("2006-01-02"),
+		fmt.Sprintf("Order: #%d", invoice.OrderID),
+	} {
+		pdf.CellFormat(80, 4.5, tr(line), "", 2, "R", false, 0, "")
+	}
+	if pdf.GetY() > bottom {
+		bottom = pdf.GetY()
+	}
+	pdf.SetXY(15, bottom+8)
+
+	// Alamat tagihan
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 5, "Bill to", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	if invoice.CustomerName != "" {
+		pdf.CellFormat(0, 4.5, tr(invoice.CustomerName), "", 1, "L", false, 0, "")
+	}
+	if invoice.BillingAddress.Recipient != "" && invoice.BillingAddress.Recipient != invoice.CustomerName {
+		pdf.CellFormat(0, 4.5, tr(invoice.BillingAddress.Recipient), "", 1, "L", false, 0, "")
+	}
+	for _, line := range addressLines(invoice.BillingAddress) {
+		pdf.CellFormat(0, 4.5, tr(line), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	// Tabel barang
+	columns := []invoiceColumn{
+		{"Description", 70, "L"},
+		{"SKU", 35, "L"},
+		{"Qty", 15, "R"},
+		{"Unit price", 30, "R"},
+		{"Amount", 30, "R"},
+	}
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	for _, column := range columns {
+		pdf.CellFormat(column.width, 7, column.title, "1", 0, column.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 9)
+	for _, item := range invoice.Items {
+		row := []string{
+			tr(item.Description),
+			tr(item.SKU),
+			fmt.Sprint(item.Quantity),
+			money(item.UnitPrice),
+			money(item.UnitPrice.Times(int64(item.Quantity))),
+		}
+		for i, column := range columns {
+			pdf.CellFormat(column.width, 7, row[i], "1", 0, column.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	if invoice.PricesIncludeTax {
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 5, "Prices include tax.", "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Rincian pajak
+	if len(invoice.Taxes) > 0 {
+		taxColumns := []invoiceColumn{
+			{"Tax", 60, "L"},
+			{"Region", 30, "L"},
+			{"Rate", 30, "R"},
+			{"Taxable amount", 30, "R"},
+			{"Tax amount", 30, "R"},
+		}
+		pdf.SetFont("Helvetica", "B", 9)
+		for _, column := range taxColumns {
+			pdf.CellFormat(column.width, 6, column.title, "1", 0, column.align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+		for _, tax := range invoice.Taxes {
+			row := []string{tr(tax.Name), tr(tax.Region), tax.Rate.String() + "%", money(tax.TaxableAmount), money(tax.Amount)}
+			for i, column := range taxColumns {
+				pdf.CellFormat(column.width, 6, row[i], "1", 0, column.align, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		pdf.Ln(4)
+	}
+
+	// Ringkasan total di kanan
+	totals := []struct {
+		label  string
+		amount models.Money
+	}{
+		{"Discount", models.Money{Decimal: invoice.DiscountTotal.Neg()}},
+		{"Subtotal (excl. tax)", invoice.Subtotal},
+		{"Tax", invoice.TaxTotal},
+		{"Shipping", invoice.ShippingTotal},
+	}
+	for _, total := range totals {
+		if total.label == "Discount" && invoice.DiscountTotal.IsZero() {
+			continue
+		}
+		pdf.SetX(115)
+		pdf.CellFormat(45, 6, total.label, "", 0, "L", false, 0, "")
+		pdf.CellFormat(35, 6, money(total.amount), "", 1, "R", false, 0, "")
+	}
+	pdf.SetX(115)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(45, 7, "Total", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(35, 7, money(invoice.Total), "T", 1, "R", false, 0, "")
+
+	return pdf.Output(w)
+}
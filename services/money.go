@@ -0,0 +1,18 @@
+package services
+
+import (
+	"os"
+	"strings"
+)
+
+// DefaultCurrency is used when the CURRENCY environment variable is not set.
+const DefaultCurrency = "IDR"
+
+// BaseCurrency returns the ISO 4217 code of the currency amounts are kept in,
+// taken from the CURRENCY environment variable.
+func BaseCurrency() string {
+	if currency := strings.ToUpper(strings.TrimSpace(os.Getenv("CURRENCY"))); currency != "" {
+		return currency
+	}
+	return DefaultCurrency
+}
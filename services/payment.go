@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/payment"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOrderPaid            = errors.New("order is already paid")
+	ErrInvalidPaymentAmount = errors.New("amount must be greater than zero and at most what is left to capture or refund")
+	ErrPaymentProvider      = errors.New("payment was taken through another provider")
+)
+
+// paymentDue returns what is left to pay of an order: its total less the
+// payments captured, authorized or still pending.
+func paymentDue(tx *gorm.DB, order models.Order) (models.Money, error) {
+	var payments []models.Payment
+	if err := tx.Where("order_id = ? AND status <> ?", order.ID, models.PaymentStatusFailed).Find(&payments).Error; err != nil {
+		return models.Money{}, err
+	}
+	due := order.Total
+	for _, p := range payments {
+		if p.Status == models.PaymentStatusPending || p.Status == models.PaymentStatusAuthorized {
+			due = due.Sub(p.Amount)
+		} else {
+			due = due.Sub(p.CapturedAmount)
+		}
+	}
+	return due, nil
+}
+
+// SyncOrderStatus sets the status of an order from its payments: paid once
+// its total has been captured, then partially refunded or refunded as the
+// captured amount is paid back. An order is invoiced when it becomes paid.
+func SyncOrderStatus(tx *gorm.DB, orderID uint) (*models.Order, error) {
+	var order models.Order
+	if err := tx.First(&order, orderID).Error; err != nil {
+		return nil, err
+	}
+	var payments []models.Payment
+	if err := tx.Where("order_id = ?", orderID).Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	var captured, refunded models.Money
+	for _, p := range payments {
+		captured = captured.Add(p.CapturedAmount)
+		refunded = refunded.Add(p.RefundedAmount)
+	}
+	status := models.OrderStatusPaid
+	switch {
+	case captured.IsZero() || captured.LessThan(order.Total.Decimal):
+		status = models.OrderStatusPending
+	case refunded.GreaterThanOrEqual(captured.Decimal):
+		status = models.OrderStatusRefunded
+	case refunded.IsPositive():
+		status = models.OrderStatusPartiallyRefunded
+	}
+	if status != order.Status {
+		order.Status = status
+		if err := tx.Model(&order).UpdateColumn("status", status).Error; err != nil {
+			return nil, err
+		}
+		if status == models.OrderStatusPaid {
+			if _, _, err := IssueInvoice(tx, order.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &order, nil
+}
+
+// CreatePayment pays what is left to pay of an order through the provider,
+// capturing it at once unless asked not to. A payment the provider declines
+// is recorded as failed and returned without an error. The provider is
+// called inside the transaction so that two payments for the same order
+// cannot both be taken.
+func CreatePayment(ctx context.Context, tx *gorm.DB, provider payment.Provider, orderID uint, req models.PaymentRequest) (*models.Payment, error) {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+		return nil, err
+	}
+	due, err := paymentDue(tx, order)
+	if err != nil {
+		return nil, err
+	}
+	if !due.IsPositive() {
+		return nil, ErrOrderPaid
+	}
+
+	result, err := provider.Authorize(ctx, payment.AuthorizeRequest{
+		OrderID:  order.ID,
+		Amount:   due,
+		Currency: order.Currency,
+		Token:    req.Token,
+	})
+	if err != nil {
+		return nil, err
+	}
+	p := &models.Payment{
+		OrderID:       order.ID,
+		Provider:      provider.Name(),
+		Reference:     result.Reference,
+		Status:        result.Status,
+		Amount:        due,
+		Currency:      order.Currency,
+		FailureReason: result.FailureReason,
+	}
+	if p.Status == models.PaymentStatusCaptured {
+		p.CapturedAmount = due
+	}
+	if p.Status == models.PaymentStatusAuthorized && (req.Capture == nil || *req.Capture) {
+		if _, err := provider.Capture(ctx, p.Reference, due); err != nil {
+			// jangan biarkan dana pelanggan tertahan oleh otorisasi yang
+			// tidak pernah tercatat
+			provider.Void(ctx, p.Reference)
+			return nil, err
+		}
+		p.Status = models.PaymentStatusCaptured
+		p.CapturedAmount = due
+	}
+	if err := tx.Omit(clause.Associations).Create(p).Error; err != nil {
+		return nil, err
+	}
+	p.Order, err = SyncOrderStatus(tx, order.ID)
+	return p, err
+}
+
+// MockPaymentRecords returns the records of the payments taken through the
+// mock provider named provider, which it rebuilds the payments it has
+// forgotten from, such as after a restart.
+func MockPaymentRecords(db *gorm.DB, provider string) func(reference string) (payment.MockRecord, error) {
+	return func(reference string) (payment.MockRecord, error) {
+		var p models.Payment
+		if err := db.Where("provider = ? AND reference = ?", provider, reference).First(&p).Error; err != nil {
+			return payment.MockRecord{}, err
+		}
+		return payment.MockRecord{
+			Status:     p.Status,
+			Authorized: p.Amount,
+			Captured:   p.CapturedAmount,
+			Refunded:   p.RefundedAmount,
+		}, nil
+	}
+}
+
+// lockPayment loads a payment for update and checks it was taken through
+// the provider.
+func lockPayment(tx *gorm.DB, provider payment.Provider, paymentID uint) (*models.Payment, error) {
+	var p models.Payment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, paymentID).Error; err != nil {
+		return nil, err
+	}
+	if p.Provider != provider.Name() {
+		return nil, ErrPaymentProvider
+	}
+	return &p, nil
+}
+
+// CapturePayment charges an authorized payment, by default for the whole
+// authorized amount.
+func CapturePayment(ctx context.Context, tx *gorm.DB, provider payment.Provider, paymentID uint, amount *models.Money) (*models.Payment, error) {
+	p, err := lockPayment(tx, provider, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != models.PaymentStatusAuthorized {
+		return nil, ErrInvalidTransition
+	}
+	capture := p.Amount
+	if amount != nil {
+		capture = *amount
+	}
+	if !capture.IsPositive() || capture.GreaterThan(p.Amount.Decimal) {
+		return nil, ErrInvalidPaymentAmount
+	}
+	if _, err := provider.Capture(ctx, p.Reference, capture); err != nil {
+		return nil, err
+	}
+	p.Status = models.PaymentStatusCaptured
+	p.CapturedAmount = capture
+	if err := tx.Omit(clause.Associations).Save(p).Error; err != nil {
+		return nil, err
+	}
+	p.Order, err = SyncOrderStatus(tx, p.OrderID)
+	return p, err
+}
+
+// RefundPayment pays back part of a captured payment, by default all that
+// has not been refunded yet. A payment refunded in full becomes refunded.
+func RefundPayment(ctx context.Context, tx *gorm.DB, provider payment.Provider, paymentID uint, amount *models.Money) (*models.Payment, error) {
+	p, err := lockPayment(tx, provider, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != models.PaymentStatusCaptured {
+		return nil, ErrInvalidTransition
+	}
+	left := p.CapturedAmount.Sub(p.RefundedAmount)
+	refund := left
+	if amount != nil {
+		refund = *amount
+	}
+	if !refund.IsPositive() || refund.GreaterThan(left.Decimal) {
+		return nil, ErrInvalidPaymentAmount
+	}
+	if _, err := provider.Refund(ctx, p.Reference, refund); err != nil {
+		return nil, err
+	}
+	p.RefundedAmount = p.RefundedAmount.Add(refund)
+	if p.RefundedAmount.Equal(p.CapturedAmount) {
+		p.Status = models.PaymentStatusRefunded
+	}
+	if err := tx.Omit(clause.Associations).Save(p).Error; err != nil {
+		return nil, err
+	}
+	p.Order, err = SyncOrderStatus(tx, p.OrderID)
+	return p, err
+}
+
+// HandlePaymentEvent applies a verified webhook of a provider to the payment
+// it is about. Events already handled, and events that no longer apply to
+// the payment, such as a late authorization of a captured payment, change
+// nothing. A capture is recorded for at most the authorized amount.
+func HandlePaymentEvent(tx *gorm.DB, provider string, event payment.Event) (*models.Payment, error) {
+	var p models.Payment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("provider = ? AND reference = ?", provider, event.Reference).First(&p).Error; err != nil {
+		return nil, err
+	}
+	var handled int64
+	if err := tx.Model(&models.PaymentEvent{}).Where("provider = ? AND event_id = ?", provider, event.ID).Count(&handled).Error; err != nil {
+		return nil, err
+	}
+	if handled > 0 {
+		return &p, nil
+	}
+
+	open := p.Status == models.PaymentStatusPending || p.Status == models.PaymentStatusAuthorized
+	switch {
+	case event.Type == payment.EventAuthorized && p.Status == models.PaymentStatusPending:
+		p.Status = models.PaymentStatusAuthorized
+	case event.Type == payment.EventCaptured && open:
+		p.Status = models.PaymentStatusCaptured
+		p.CapturedAmount = p.Amount
+		// Tidak pernah lebih dari yang diotorisasi, apa pun kata webhook
+		if event.Amount.IsPositive() && event.Amount.LessThan(p.Amount.Decimal) {
+			p.CapturedAmount = event.Amount
+		}
+	case event.Type == payment.EventFailed && open:
+		p.Status = models.PaymentStatusFailed
+		p.FailureReason = event.FailureReason
+	case event.Type == payment.EventRefunded && p.Status == models.PaymentStatusCaptured:
+		if event.Amount.GreaterThan(p.RefundedAmount.Decimal) {
+			p.RefundedAmount = event.Amount
+		}
+		if p.RefundedAmount.GreaterThanOrEqual(p.CapturedAmount.Decimal) {
+			p.RefundedAmount = p.CapturedAmount
+			p.Status = models.PaymentStatusRefunded
+		}
+	}
+	if err := tx.Omit(clause.Associations).Save(&p).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&models.PaymentEvent{
+		Provider:  provider,
+		EventID:   event.ID,
+		Type:      event.Type,
+		PaymentID: p.ID,
+	}).Error; err != nil {
+		return nil, err
+	}
+	var err error
+	p.Order, err = SyncOrderStatus(tx, p.OrderID)
+	return &p, err
+}
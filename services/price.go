@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidPrice            = errors.New("price must not be negative")
+	ErrPriceChangeNotScheduled = errors.New("only scheduled price changes can be cancelled")
+)
+
+// RecordPriceChange adds an applied entry to the price history of a product
+// whose price has just been saved. oldPrice is nil for a new product; nothing
+// is recorded when the price did not change.
+func RecordPriceChange(tx *gorm.DB, product *models.Product, oldPrice *models.Money, userID *uint, note string) error {
+	if oldPrice != nil && oldPrice.Equal(product.Price) {
+		return nil
+	}
+	now := time.Now()
+	return tx.Create(&models.PriceChange{
+		ProductID:   product.ID,
+		OldPrice:    oldPrice,
+		NewPrice:    product.Price,
+		Status:      models.PriceChangeStatusApplied,
+		Note:        note,
+		EffectiveAt: now,
+		AppliedAt:   &now,
+		UserID:      userID,
+	}).Error
+}
+
+// SchedulePriceChange plans a new price for a product. A change that is
+// already due is applied right away; later ones are left to the scheduler.
+func SchedulePriceChange(tx *gorm.DB, productID uint, req models.PriceChangeRequest, userID *uint) (*models.PriceChange, error) {
+	if req.Price.IsNegative() {
+		return nil, ErrInvalidPrice
+	}
+	now := time.Now()
+	change := &models.PriceChange{
+		ProductID:   productID,
+		NewPrice:    req.Price.Round(),
+		Status:      models.PriceChangeStatusScheduled,
+		Note:        req.Note,
+		EffectiveAt: now,
+		UserID:      userID,
+	}
+	if req.EffectiveAt != nil {
+		change.EffectiveAt = *req.EffectiveAt
+	}
+	if err := tx.Create(change).Error; err != nil {
+		return nil, err
+	}
+	if change.EffectiveAt.After(now) {
+		return change, nil
+	}
+	return change, applyPriceChange(tx, change, now)
+}
+
+// applyPriceChange sets the price of a scheduled change on its product.
+func applyPriceChange(tx *gorm.DB, change *models.PriceChange, now time.Time) error {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, change.ProductID).Error; err != nil {
+		return err
+	}
+	oldPrice := product.Price
+	if err := tx.Model(&product).Update("price", change.NewPrice).Error; err != nil {
+		return err
+	}
+	change.OldPrice = &oldPrice
+	change.Status = models.PriceChangeStatusApplied
+	change.AppliedAt = &now
+	return tx.Save(change).Error
+}
+
+// ApplyDuePriceChanges applies every scheduled price change whose effective
+// time has passed, oldest first, each in its own transaction. Changes of
+// products that have been deleted are cancelled; a change that cannot be
+// applied for another reason is marked failed so it does not hold up the
+// changes after it, and its error is returned along with the others.
+func ApplyDuePriceChanges(db *gorm.DB) error {
+	now := time.Now()
+	var due []models.PriceChange
+	err := db.Where("status = ? AND effective_at <= ?", models.PriceChangeStatusScheduled, now).
+		Order("effective_at, id").
+		Find(&due).Error
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := range due {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Perubahan bisa saja dibatalkan sejak daftar di atas dibaca
+			var change models.PriceChange
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&change, due[i].ID).Error; err != nil {
+				return err
+			}
+			if change.Status != models.PriceChangeStatusScheduled {
+				return nil
+			}
+			due[i] = change
+			return applyPriceChange(tx, &due[i], now)
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = db.Model(&due[i]).Update("status", models.PriceChangeStatusCancelled).Error
+		} else if err != nil {
+			err = fmt.Errorf("price change %d: %w", due[i].ID, err)
+			// Tandai gagal agar tidak dicoba ulang tanpa henti di setiap tick
+			if markErr := db.Model(&due[i]).Update("status", models.PriceChangeStatusFailed).Error; markErr != nil {
+				err = errors.Join(err, markErr)
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CancelPriceChange withdraws a price change that has not been applied yet.
+func CancelPriceChange(tx *gorm.DB, change *models.PriceChange) error {
+	if change.Status != models.PriceChangeStatusScheduled {
+		return ErrPriceChangeNotScheduled
+	}
+	change.Status = models.PriceChangeStatusCancelled
+	return tx.Model(change).Update("status", change.Status).Error
+}
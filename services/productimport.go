@@ -0,0 +1,327 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+var ErrImportFile = errors.New("cannot read import file")
+
+// ProductImportColumns lists the columns understood by the product import.
+// category and supplier accept a name or an ID.
+var ProductImportColumns = []string{
+	"sku", "name", "description", "price", "category", "supplier",
+	"reorder_point", "reorder_quantity", "meta_title", "meta_description",
+}
+
+// ProductImportRow is a validated row of a product import.
+type ProductImportRow struct {
+	Line      int
+	ProductID uint // 0 when the SKU is new
+	Product   models.Product
+	// Columns holds the optional columns the file has; an existing product
+	// keeps the fields of the columns left out.
+	Columns map[string]bool
+}
+
+// ProductImportOptionalColumns lists the columns a product file may leave out.
+var ProductImportOptionalColumns = []string{"description", "meta_title", "meta_description", "reorder_point", "reorder_quantity"}
+
+// NormaliseSKU trims a SKU and turns an empty one into nil, so that products
+// without a SKU do not collide on the unique index.
+func NormaliseSKU(sku *string) *string {
+	if sku == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*sku)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// ReadImportFile returns the rows of a CSV or XLSX file, header included.
+// XLSX files are read from their first sheet; CSV files may use commas or
+// semicolons.
+func ReadImportFile(fileName string, data []byte) ([][]string, error) {
+	if strings.EqualFold(filepath.Ext(fileName), ".xlsx") || bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		book, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrImportFile, err.Error())
+		}
+		defer book.Close()
+		rows, err := book.GetRows(book.GetSheetName(0))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrImportFile, err.Error())
+		}
+		return rows, nil
+	}
+
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if firstLine, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrImportFile, err.Error())
+	}
+	return rows, nil
+}
+
+// importLookup resolves categories, suppliers and SKUs of an import in memory.
+type importLookup struct {
+	categories map[string]uint
+	suppliers  map[string]uint
+	skus       map[string]uint
+}
+
+func newImportLookup(db *gorm.DB) (*importLookup, error) {
+	lookup := &importLookup{
+		categories: map[string]uint{},
+		suppliers:  map[string]uint{},
+		skus:       map[string]uint{},
+	}
+	var categories []models.Category
+	if err := db.Select("id, name").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		lookup.categories[strconv.FormatUint(uint64(category.ID), 10)] = category.ID
+		lookup.categories[strings.ToLower(category.Name)] = category.ID
+	}
+	var suppliers []models.Supplier
+	if err := db.Select("id, name").Find(&suppliers).Error; err != nil {
+		return nil, err
+	}
+	for _, supplier := range suppliers {
+		lookup.suppliers[strconv.FormatUint(uint64(supplier.ID), 10)] = supplier.ID
+		lookup.suppliers[strings.ToLower(supplier.Name)] = supplier.ID
+	}
+	var products []models.Product
+	if err := db.Select("id, sku").Where("sku IS NOT NULL").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	for _, product := range products {
+		lookup.skus[*product.SKU] = product.ID
+	}
+	return lookup, nil
+}
+
+// ParseProductImport validates the rows of a product import file. It
+// returns the valid rows and an error for every rejected one; a file that
+// cannot be used at all, such as one without the required columns, yields a
+// single error for row 1.
+func ParseProductImport(db *gorm.DB, rows [][]string) ([]ProductImportRow, []models.ImportRowError, error) {
+	if len(rows) == 0 {
+		return nil, []models.ImportRowError{{Row: 1, Message: "file is empty"}}, nil
+	}
+	columns := make(map[string]int, len(rows[0]))
+	for i, name := range rows[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		// category_id dan supplier_id diterima sebagai nama lain kolomnya
+		name = strings.TrimSuffix(name, "_id")
+		columns[name] = i
+	}
+	var missing []string
+	for _, required := range []string{"sku", "name", "price", "category", "supplier"} {
+		if _, ok := columns[required]; !ok {
+			missing = append(missing, required)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, []models.ImportRowError{{Row: 1, Message: "missing columns: " + strings.Join(missing, ", ")}}, nil
+	}
+
+	present := map[string]bool{}
+	for _, optional := range ProductImportOptionalColumns {
+		_, present[optional] = columns[optional]
+	}
+
+	lookup, err := newImportLookup(db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var valid []ProductImportRow
+	var rowErrors []models.ImportRowError
+	seen := map[string]int{}
+	for i, record := range rows[1:] {
+		line := i + 2
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		reject := func(field, message string) {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: line, Field: field, Message: message})
+		}
+
+		sku := field("sku")
+		if sku == "" {
+			reject("sku", "is required")
+			continue
+		}
+		if first, ok := seen[sku]; ok {
+			reject("sku", fmt.Sprintf("duplicates row %d", first))
+			continue
+		}
+		seen[sku] = line
+
+		row := ProductImportRow{Line: line, ProductID: lookup.skus[sku], Columns: present}
+		product := &row.Product
+		product.SKU = &sku
+		product.Name = field("name")
+		product.Description = field("description")
+		product.MetaTitle = field("meta_title")
+		product.MetaDescription = field("meta_description")
+
+		ok := true
+		if product.Name == "" {
+			reject("name", "is required")
+			ok = false
+		}
+		if product.Price, err = models.ParseMoney(field("price")); err != nil || product.Price.IsNegative() {
+			reject("price", "must be a non-negative number")
+			ok = false
+		}
+		product.Price = product.Price.Round()
+		if product.CategoryID = lookup.categories[strings.ToLower(field("category"))]; product.CategoryID == 0 {
+			reject("category", fmt.Sprintf("unknown category %q", field("category")))
+			ok = false
+		}
+		if product.SupplierID = lookup.suppliers[strings.ToLower(field("supplier"))]; product.SupplierID == 0 {
+			reject("supplier", fmt.Sprintf("unknown supplier %q", field("supplier")))
+			ok = false
+		}
+		for name, target := range map[string]*int{"reorder_point": &product.ReorderPoint, "reorder_quantity": &product.ReorderQuantity} {
+			if s := field(name); s != "" {
+				n, err := strconv.Atoi(s)
+				if err != nil || n < 0 {
+					reject(name, "must be a non-negative whole number")
+					ok = false
+				}
+				*target = n
+			}
+		}
+		if ok {
+			valid = append(valid, row)
+		}
+	}
+	return valid, rowErrors, nil
+}
+
+// ImportProductRow creates the product of a row, or updates the product with
+// the same SKU, the same way the product endpoints do. Fields of optional
+// columns the file does not have are left as they are on existing products.
+// userID is recorded as the author of price changes.
+func ImportProductRow(tx *gorm.DB, row ProductImportRow, userID *uint) error {
+	product := row.Product
+	if row.ProductID != 0 {
+		var existing models.Product
+		if err := tx.First(&existing, row.ProductID).Error; err != nil {
+			return err
+		}
+		supplierChanged := existing.SupplierID != product.SupplierID
+		oldPrice := existing.Price
+		existing.Name = product.Name
+		existing.Price = product.Price
+		existing.CategoryID = product.CategoryID
+		existing.SupplierID = product.SupplierID
+		// Kolom opsional yang tidak ada di file tidak mengosongkan data produk
+		optional := map[string]func(){
+			"description":      func() { existing.Description = product.Description },
+			"meta_title":       func() { existing.MetaTitle = product.MetaTitle },
+			"meta_description": func() { existing.MetaDescription = product.MetaDescription },
+			"reorder_point":    func() { existing.ReorderPoint = product.ReorderPoint },
+			"reorder_quantity": func() { existing.ReorderQuantity = product.ReorderQuantity },
+		}
+		for column, assign := range optional {
+			if row.Columns[column] {
+				assign()
+			}
+		}
+		if err := tx.Save(&existing).Error; err != nil {
+			return err
+		}
+		if err := RecordPriceChange(tx, &existing, &oldPrice, userID, "import"); err != nil {
+			return err
+		}
+		if err := SetProductAttributes(tx, &existing, nil); err != nil {
+			return err
+		}
+		if !supplierChanged {
+			return nil
+		}
+		_, err := LinkSupplier(tx, existing.ID, models.ProductSupplierRequest{SupplierID: existing.SupplierID, Preferred: true})
+		return err
+	}
+
+	if err := tx.Create(&product).Error; err != nil {
+		return err
+	}
+	if err := RecordPriceChange(tx, &product, nil, userID, "import"); err != nil {
+		return err
+	}
+	if _, err := LinkSupplier(tx, product.ID, models.ProductSupplierRequest{SupplierID: product.SupplierID, Preferred: true}); err != nil {
+		return err
+	}
+	if err := SetProductAttributes(tx, &product, nil); err != nil {
+		return err
+	}
+	slug, err := AssignSlug(tx, models.SlugTypeProduct, product.ID, product.Name)
+	if err != nil {
+		return err
+	}
+	return tx.Model(&product).UpdateColumn("slug", slug).Error
+}
+
+// RunProductImport imports the rows of a job, each in its own transaction so
+// that one bad row does not undo the others, and records the outcome on the
+// job as it goes.
+func RunProductImport(db *gorm.DB, job *models.ImportJob, rows [][]string) error {
+	valid, rowErrors, err := ParseProductImport(db, rows)
+	if err != nil {
+		return err
+	}
+	job.TotalRows = len(valid) + len(rowErrors)
+	job.RowErrors = rowErrors
+	job.Failed = len(rowErrors)
+
+	for i, row := range valid {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return ImportProductRow(tx, row, job.UserID)
+		})
+		switch {
+		case err != nil:
+			job.Failed++
+			job.RowErrors = append(job.RowErrors, models.ImportRowError{Row: row.Line, Message: err.Error()})
+		case row.ProductID != 0:
+			job.Updated++
+		default:
+			job.Created++
+		}
+		// Kemajuan disimpan berkala agar endpoint status bisa mengikutinya
+		if i%50 == 49 {
+			if err := db.Select("created", "updated", "failed", "row_errors", "total_rows").Save(job).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+)
+
+var ErrPreferredSupplier = errors.New("the preferred supplier cannot be removed, choose another preferred supplier first")
+
+// LinkSupplier adds or updates the link between a product and a supplier.
+// When the link is preferred, or the product has no other supplier, it
+// becomes the product's preferred supplier.
+func LinkSupplier(tx *gorm.DB, productID uint, req models.ProductSupplierRequest) (*models.ProductSupplier, error) {
+	var supplier models.Supplier
+	if err := tx.First(&supplier, req.SupplierID).Error; err != nil {
+		return nil, err
+	}
+
+	link := models.ProductSupplier{ProductID: productID, SupplierID: supplier.ID}
+	if err := tx.Where("product_id = ? AND supplier_id = ?", productID, supplier.ID).FirstOrInit(&link).Error; err != nil {
+		return nil, err
+	}
+	link.SupplierSKU = req.SupplierSKU
+	if err := tx.Save(&link).Error; err != nil {
+		return nil, err
+	}
+
+	var others int64
+	if err := tx.Model(&models.ProductSupplier{}).Where("product_id = ? AND supplier_id <> ?", productID, supplier.ID).Count(&others).Error; err != nil {
+		return nil, err
+	}
+	if req.Preferred || others == 0 {
+		if err := SetPreferredSupplier(tx, productID, supplier.ID); err != nil {
+			return nil, err
+		}
+		link.Preferred = true
+	}
+	link.Supplier = supplier
+	return &link, nil
+}
+
+// SetPreferredSupplier marks one linked supplier as preferred and mirrors it
+// in Product.SupplierID, which purchasing and reports group by.
+func SetPreferredSupplier(tx *gorm.DB, productID, supplierID uint) error {
+	err := tx.Model(&models.ProductSupplier{}).
+		Where("product_id = ?", productID).
+		Update("preferred", gorm.Expr("supplier_id = ?", supplierID)).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.Product{}).Where("id = ?", productID).Update("supplier_id", supplierID).Error
+}
+
+// UnlinkSupplier removes a non-preferred supplier from a product.
+func UnlinkSupplier(tx *gorm.DB, productID, supplierID uint) error {
+	var link models.ProductSupplier
+	if err := tx.Where("product_id = ? AND supplier_id = ?", productID, supplierID).First(&link).Error; err != nil {
+		return err
+	}
+	if link.Preferred {
+		return ErrPreferredSupplier
+	}
+	return tx.Unscoped().Delete(&link).Error
+}
+
+// SeedProductSuppliers links every product to its current SupplierID as the
+// preferred supplier when the link does not exist yet.
+func SeedProductSuppliers(db *gorm.DB) error {
+	return db.Exec(`INSERT INTO product_suppliers (created_at, updated_at, product_id, supplier_id, preferred)
+		SELECT NOW(), NOW(), p.id, p.supplier_id, TRUE FROM products p
+		WHERE p.deleted_at IS NULL AND p.supplier_id <> 0
+		AND NOT EXISTS (SELECT 1 FROM product_suppliers ps WHERE ps.product_id = p.id)`).Error
+}
@@ -0,0 +1,464 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidPromotion    = errors.New("invalid promotion")
+	ErrDuplicateCouponCode = errors.New("coupon code is already in use")
+	ErrUnknownCoupon       = errors.New("unknown coupon code")
+	ErrCouponNotValid      = errors.New("coupon is not valid at this time")
+	ErrCouponUsedUp        = errors.New("coupon has reached its usage limit")
+	ErrCouponMinimumSpend  = errors.New("order does not reach the minimum spend of the coupon")
+	ErrCouponNotApplicable = errors.New("coupon does not apply to any of the ordered products")
+)
+
+// DiscountDecimals is the number of decimal places discounts are rounded to.
+const DiscountDecimals = 2
+
+// ShippingFee returns the flat shipping fee charged on every order, as set
+// with the SHIPPING_FEE environment variable. Orders ship for free by
+// default.
+func ShippingFee() models.Money {
+	fee, err := models.ParseMoney(os.Getenv("SHIPPING_FEE"))
+	if err != nil || fee.IsNegative() {
+		return models.Money{}
+	}
+	return fee
+}
+
+// NormaliseCouponCode trims and upper-cases a coupon code, so codes match
+// regardless of case.
+func NormaliseCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// SavePromotion applies the request to a promotion after validating it and
+// replaces its product and category restrictions.
+func SavePromotion(tx *gorm.DB, promotion *models.Promotion, req models.PromotionRequest) error {
+	promotion.Name = strings.TrimSpace(req.Name)
+	promotion.Type = req.Type
+	promotion.Value = req.Value.Round()
+	promotion.BuyQuantity = req.BuyQuantity
+	promotion.GetQuantity = req.GetQuantity
+	promotion.MinSpend = req.MinSpend.Round()
+	promotion.UsageLimit = req.UsageLimit
+	promotion.StartsAt = req.StartsAt
+	promotion.EndsAt = req.EndsAt
+	promotion.Active = req.Active == nil || *req.Active
+	promotion.Code = nil
+	if code := NormaliseCouponCode(req.Code); code != "" {
+		promotion.Code = &code
+	}
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+
+	if promotion.Code != nil {
+		var count int64
+		err := tx.Model(&models.Promotion{}).Where("code = ? AND id <> ?", *promotion.Code, promotion.ID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDuplicateCouponCode
+		}
+	}
+
+	var products []models.Product
+	if len(req.ProductIDs) > 0 {
+		if err := tx.Where("id IN ?", req.ProductIDs).Find(&products).Error; err != nil {
+			return err
+		}
+		if len(products) != len(req.ProductIDs) {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	var categories []models.Category
+	if len(req.CategoryIDs) > 0 {
+		if err := tx.Where("id IN ?", req.CategoryIDs).Find(&categories).Error; err != nil {
+			return err
+		}
+		if len(categories) != len(req.CategoryIDs) {
+			return gorm.ErrRecordNotFound
+		}
+	}
+
+	if err := tx.Omit("Products", "Categories").Save(promotion).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(promotion).Association("Products").Replace(products); err != nil {
+		return err
+	}
+	if err := tx.Model(promotion).Association("Categories").Replace(categories); err != nil {
+		return err
+	}
+	promotion.Products = products
+	promotion.Categories = categories
+	return nil
+}
+
+// validatePromotion checks the settings of a promotion for its type.
+func validatePromotion(promotion *models.Promotion) error {
+	if promotion.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPromotion)
+	}
+	switch promotion.Type {
+	case models.PromotionTypePercentage:
+		if !promotion.Value.IsPositive() || promotion.Value.GreaterThan(hundred) {
+			return fmt.Errorf("%w: percentage must be greater than 0 and at most 100", ErrInvalidPromotion)
+		}
+	case models.PromotionTypeFixedAmount:
+		if !promotion.Value.IsPositive() {
+			return fmt.Errorf("%w: amount must be greater than zero", ErrInvalidPromotion)
+		}
+	case models.PromotionTypeFreeShipping:
+		promotion.Value = models.Money{}
+	case models.PromotionTypeBuyXGetY:
+		if promotion.BuyQuantity == 0 || promotion.GetQuantity == 0 {
+			return fmt.Errorf("%w: buy_quantity and get_quantity are required", ErrInvalidPromotion)
+		}
+		if promotion.Value.IsZero() {
+			promotion.Value = models.Money{Decimal: hundred}
+		}
+		if promotion.Value.IsNegative() || promotion.Value.GreaterThan(hundred) {
+			return fmt.Errorf("%w: percentage must be greater than 0 and at most 100", ErrInvalidPromotion)
+		}
+	default:
+		return fmt.Errorf("%w: type must be percentage, fixed_amount, free_shipping or buy_x_get_y", ErrInvalidPromotion)
+	}
+	if promotion.Type != models.PromotionTypeBuyXGetY {
+		promotion.BuyQuantity, promotion.GetQuantity = 0, 0
+	}
+	if promotion.MinSpend.IsNegative() {
+		return fmt.Errorf("%w: min_spend cannot be negative", ErrInvalidPromotion)
+	}
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidPromotion)
+	}
+	return nil
+}
+
+// DeletePromotion removes a promotion and its restrictions. Orders keep the
+// discounts it gave them.
+func DeletePromotion(tx *gorm.DB, promotion *models.Promotion) error {
+	if err := tx.Model(promotion).Association("Products").Clear(); err != nil {
+		return err
+	}
+	if err := tx.Model(promotion).Association("Categories").Clear(); err != nil {
+		return err
+	}
+	// Dihapus permanen agar kodenya bisa dipakai lagi
+	return tx.Unscoped().Delete(promotion).Error
+}
+
+// PromotionUsage returns how many orders that have not been deleted use each
+// of the promotions.
+func PromotionUsage(db *gorm.DB, promotionIDs []uint) (map[uint]int64, error) {
+	usage := make(map[uint]int64, len(promotionIDs))
+	if len(promotionIDs) == 0 {
+		return usage, nil
+	}
+	var rows []struct {
+		PromotionID uint
+		Count       int64
+	}
+	err := db.Model(&models.OrderDiscount{}).
+		Select("order_discounts.promotion_id, COUNT(DISTINCT order_discounts.order_id) AS count").
+		Joins("JOIN orders ON orders.id = order_discounts.order_id AND orders.deleted_at IS NULL").
+		Where("order_discounts.promotion_id IN ?", promotionIDs).
+		Group("order_discounts.promotion_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		usage[row.PromotionID] = row.Count
+	}
+	return usage, nil
+}
+
+// promotionUsedUp reports whether a promotion has reached its usage limit
+// on orders other than the given one. Promotions with a limit are locked
+// until the transaction ends, automatic promotions as well as coupons.
+func promotionUsedUp(tx *gorm.DB, promotion models.Promotion, orderID uint) (bool, error) {
+	if promotion.UsageLimit == nil {
+		return false, nil
+	}
+	// Baris promosi dikunci sampai transaksi selesai agar pesanan bersamaan
+	// tidak melampaui batas pemakaiannya
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Promotion{}, promotion.ID).Error; err != nil {
+		return false, err
+	}
+	var count int64
+	err := tx.Model(&models.OrderDiscount{}).
+		Joins("JOIN orders ON orders.id = order_discounts.order_id AND orders.deleted_at IS NULL").
+		Where("order_discounts.promotion_id = ? AND order_discounts.order_id <> ?", promotion.ID, orderID).
+		Distinct("order_discounts.order_id").
+		Count(&count).Error
+	return count >= int64(*promotion.UsageLimit), err
+}
+
+func promotionCurrent(promotion models.Promotion, now time.Time) bool {
+	if !promotion.Active {
+		return false
+	}
+	if promotion.StartsAt != nil && now.Before(*promotion.StartsAt) {
+		return false
+	}
+	return promotion.EndsAt == nil || now.Before(*promotion.EndsAt)
+}
+
+// promotionMatches reports whether a promotion covers a product, given the
+// product's category followed by its parent categories.
+func promotionMatches(promotion models.Promotion, productID uint, categories []uint) bool {
+	if len(promotion.Products) == 0 && len(promotion.Categories) == 0 {
+		return true
+	}
+	for _, product := range promotion.Products {
+		if product.ID == productID {
+			return true
+		}
+	}
+	for _, category := range promotion.Categories {
+		for _, id := range categories {
+			if category.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// promotionDiscount returns the discount a promotion gives on a line worth
+// amount after earlier discounts, or on the shipping fee for free shipping.
+func promotionDiscount(promotion models.Promotion, unitPrice models.Money, quantity uint, amount, shipping decimal.Decimal) decimal.Decimal {
+	var discount decimal.Decimal
+	limit := amount
+	switch promotion.Type {
+	case models.PromotionTypePercentage:
+		discount = amount.Mul(promotion.Value.Decimal).Div(hundred)
+	case models.PromotionTypeFixedAmount:
+		discount = promotion.Value.Decimal
+	case models.PromotionTypeBuyXGetY:
+		free := quantity / (promotion.BuyQuantity + promotion.GetQuantity) * promotion.GetQuantity
+		discount = unitPrice.Times(int64(free)).Mul(promotion.Value.Decimal).Div(hundred)
+	case models.PromotionTypeFreeShipping:
+		discount = shipping
+		limit = shipping
+	}
+	discount = discount.Round(DiscountDecimals)
+	if discount.GreaterThan(limit) {
+		discount = limit
+	}
+	return discount
+}
+
+func orderDiscount(promotion models.Promotion, amount decimal.Decimal) models.OrderDiscount {
+	discount := models.OrderDiscount{
+		PromotionID: promotion.ID,
+		Name:        promotion.Name,
+		Type:        promotion.Type,
+		Amount:      models.Money{Decimal: amount},
+	}
+	if promotion.Code != nil {
+		discount.Code = *promotion.Code
+	}
+	return discount
+}
+
+// promotionLineDiscounts returns the discount a promotion gives on each of
+// the lines it covers, on what is left of them after earlier discounts. A
+// fixed amount comes off the covered lines once, spread over them in
+// proportion to what is left of each.
+func promotionLineDiscounts(promotion models.Promotion, lines []pricedLine) []decimal.Decimal {
+	amounts := make([]decimal.Decimal, len(lines))
+	var covered []int
+	left := decimal.Zero
+	for i, line := range lines {
+		if !promotionMatches(promotion, line.product.ID, line.categories) {
+			continue
+		}
+		rest := line.gross.Sub(line.discount)
+		if promotion.Type != models.PromotionTypeFixedAmount {
+			amounts[i] = promotionDiscount(promotion, line.item.UnitPrice, line.item.Quantity, rest, decimal.Zero)
+			continue
+		}
+		covered = append(covered, i)
+		left = left.Add(rest)
+	}
+	if len(covered) == 0 || !left.IsPositive() {
+		return amounts
+	}
+	total := promotionDiscount(promotion, models.Money{}, 0, left, decimal.Zero)
+	spread := decimal.Zero
+	for n, i := range covered {
+		rest := lines[i].gross.Sub(lines[i].discount)
+		share := total.Mul(rest).Div(left).Round(DiscountDecimals)
+		// Sisa pembulatan jatuh pada baris terakhir
+		if n == len(covered)-1 {
+			share = total.Sub(spread)
+		}
+		if share.GreaterThan(rest) {
+			share = rest
+		}
+		amounts[i] = share
+		spread = spread.Add(share)
+	}
+	return amounts
+}
+
+// promotionInCurrency returns a promotion with its amounts, which are in the
+// base currency, in the currency of an order.
+func promotionInCurrency(promotion models.Promotion, order *models.Order) models.Promotion {
+	promotion.MinSpend = models.Money{Decimal: inOrderCurrency(order, promotion.MinSpend.Decimal)}
+	if promotion.Type == models.PromotionTypeFixedAmount {
+		promotion.Value = models.Money{Decimal: inOrderCurrency(order, promotion.Value.Decimal)}
+	}
+	return promotion
+}
+
+func sumDecimals(amounts []decimal.Decimal) decimal.Decimal {
+	sum := decimal.Zero
+	for _, amount := range amounts {
+		sum = sum.Add(amount)
+	}
+	return sum
+}
+
+// applyPromotions works out the discounts of an order whose lines are being
+// priced and whose shipping fee is shipping, adding the discount of each
+// line to it. The automatic promotion giving the largest discount on the
+// order applies, as does the first automatic free shipping promotion; the
+// coupon of the order then applies to what is left. Minimum spends are
+// checked against the order before discounts. Automatic promotions that do
+// not match are skipped, while a coupon that does not apply to any line is
+// an error. Only promotions giving a discount count as used.
+func applyPromotions(tx *gorm.DB, order *models.Order, lines []pricedLine, shipping decimal.Decimal) (shippingDiscount decimal.Decimal, discounts []models.OrderDiscount, err error) {
+	gross := decimal.Zero
+	for _, line := range lines {
+		gross = gross.Add(line.gross)
+	}
+	covers := func(promotion models.Promotion) bool {
+		for _, line := range lines {
+			if promotionMatches(promotion, line.product.ID, line.categories) {
+				return true
+			}
+		}
+		return false
+	}
+	now := time.Now()
+	eligible := func(promotion models.Promotion) (bool, error) {
+		if !promotionCurrent(promotion, now) || !covers(promotion) {
+			return false, nil
+		}
+		if gross.LessThan(promotion.MinSpend.Decimal) {
+			return false, nil
+		}
+		usedUp, err := promotionUsedUp(tx, promotion, order.ID)
+		return !usedUp, err
+	}
+
+	var automatic []models.Promotion
+	err = tx.Preload("Products").Preload("Categories").Where("code IS NULL AND active").Order("id").Find(&automatic).Error
+	if err != nil {
+		return
+	}
+	for i := range automatic {
+		automatic[i] = promotionInCurrency(automatic[i], order)
+	}
+	var best, freeShipping *models.Promotion
+	var bestAmounts []decimal.Decimal
+	var bestAmount decimal.Decimal
+	for i, promotion := range automatic {
+		var ok bool
+		if ok, err = eligible(promotion); err != nil {
+			return
+		}
+		if !ok {
+			continue
+		}
+		if promotion.Type == models.PromotionTypeFreeShipping {
+			if freeShipping == nil {
+				freeShipping = &automatic[i]
+			}
+			continue
+		}
+		amounts := promotionLineDiscounts(promotion, lines)
+		if amount := sumDecimals(amounts); best == nil || amount.GreaterThan(bestAmount) {
+			best, bestAmounts, bestAmount = &automatic[i], amounts, amount
+		}
+	}
+	if best != nil && bestAmount.IsPositive() {
+		for i := range lines {
+			lines[i].discount = lines[i].discount.Add(bestAmounts[i])
+		}
+		discounts = append(discounts, orderDiscount(*best, bestAmount))
+	}
+	if freeShipping != nil && shipping.IsPositive() {
+		shippingDiscount = shipping
+		discounts = append(discounts, orderDiscount(*freeShipping, shipping))
+	}
+
+	order.CouponCode = NormaliseCouponCode(order.CouponCode)
+	if order.CouponCode == "" {
+		return
+	}
+	// Baris kupon dikunci agar batas pemakaiannya tidak terlampaui oleh pesanan bersamaan
+	var coupon models.Promotion
+	if err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", order.CouponCode).First(&coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrUnknownCoupon
+		}
+		return
+	}
+	if err = tx.Preload("Products").Preload("Categories").First(&coupon, coupon.ID).Error; err != nil {
+		return
+	}
+	coupon = promotionInCurrency(coupon, order)
+	switch {
+	case !promotionCurrent(coupon, now):
+		err = ErrCouponNotValid
+	case !covers(coupon):
+		err = ErrCouponNotApplicable
+	case gross.LessThan(coupon.MinSpend.Decimal):
+		err = ErrCouponMinimumSpend
+	}
+	if err != nil {
+		return
+	}
+	var usedUp bool
+	if usedUp, err = promotionUsedUp(tx, coupon, order.ID); err != nil {
+		return
+	}
+	if usedUp {
+		err = ErrCouponUsedUp
+		return
+	}
+	var amount decimal.Decimal
+	if coupon.Type == models.PromotionTypeFreeShipping {
+		amount = promotionDiscount(coupon, models.Money{}, 0, decimal.Zero, shipping.Sub(shippingDiscount))
+		shippingDiscount = shippingDiscount.Add(amount)
+	} else {
+		amounts := promotionLineDiscounts(coupon, lines)
+		for i := range lines {
+			lines[i].discount = lines[i].discount.Add(amounts[i])
+		}
+		amount = sumDecimals(amounts)
+	}
+	// Kupon tanpa potongan tidak dihitung sebagai pemakaian
+	if amount.IsPositive() {
+		discounts = append(discounts, orderDiscount(coupon, amount))
+	}
+	return
+}
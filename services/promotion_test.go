@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// testLines returns lines of one unit each worth the given amounts, for
+// products 1, 2, 3 and so on.
+func testLines(t *testing.T, amounts ...string) []pricedLine {
+	t.Helper()
+	lines := make([]pricedLine, len(amounts))
+	for i, amount := range amounts {
+		price := models.Money{Decimal: dec(t, amount)}
+		lines[i] = pricedLine{
+			item:    &models.OrderItem{ProductID: uint(i + 1), Quantity: 1, UnitPrice: price},
+			product: models.Product{Model: gorm.Model{ID: uint(i + 1)}},
+			gross:   price.Decimal,
+		}
+	}
+	return lines
+}
+
+func TestPromotionLineDiscounts(t *testing.T) {
+	fixed := func(value int64) models.Promotion {
+		return models.Promotion{Type: models.PromotionTypeFixedAmount, Value: models.NewMoney(value)}
+	}
+	onProducts := fixed(10)
+	onProducts.Products = []models.Product{{Model: gorm.Model{ID: 1}}, {Model: gorm.Model{ID: 3}}}
+
+	tests := []struct {
+		name      string
+		promotion models.Promotion
+		lines     []pricedLine
+		earlier   []string // diskon yang sudah ada per baris
+		want      []string
+	}{
+		{"fixed amount spread with the remainder on the last line", fixed(10), testLines(t, "3", "14", "5"), nil, []string{"1.36", "6.36", "2.28"}},
+		{"fixed amount above the order", fixed(100), testLines(t, "3", "14", "5"), nil, []string{"3", "14", "5"}},
+		{"fixed amount on some products", onProducts, testLines(t, "3", "14", "5"), nil, []string{"3", "0", "5"}},
+		{"fixed amount after earlier discounts", fixed(10), testLines(t, "3", "14", "5"), []string{"1", "4", "0"}, []string{"1.18", "5.88", "2.94"}},
+		{"fixed amount on three equal lines", fixed(10), testLines(t, "10", "10", "10"), nil, []string{"3.33", "3.33", "3.34"}},
+		{"percentage", models.Promotion{Type: models.PromotionTypePercentage, Value: models.NewMoney(10)}, testLines(t, "3", "14", "5"), nil, []string{"0.3", "1.4", "0.5"}},
+	}
+	for _, tt := range tests {
+		for i, discount := range tt.earlier {
+			tt.lines[i].discount = dec(t, discount)
+		}
+		got := promotionLineDiscounts(tt.promotion, tt.lines)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: %d discounts, want %d", tt.name, len(got), len(tt.want))
+		}
+		for i, discount := range got {
+			if !discount.Equal(dec(t, tt.want[i])) {
+				t.Errorf("%s: line %d discount %s, want %s", tt.name, i, discount, tt.want[i])
+			}
+		}
+		if tt.promotion.Type == models.PromotionTypeFixedAmount {
+			left := decimal.Zero
+			for _, line := range tt.lines {
+				if promotionMatches(tt.promotion, line.product.ID, nil) {
+					left = left.Add(line.gross.Sub(line.discount))
+				}
+			}
+			if want := decimal.Min(tt.promotion.Value.Decimal, left); !sumDecimals(got).Equal(want) {
+				t.Errorf("%s: discounts add up to %s, want %s", tt.name, sumDecimals(got), want)
+			}
+		}
+	}
+}
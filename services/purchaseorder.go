@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOverReceipt      = errors.New("received quantity exceeds outstanding quantity")
+	ErrUnknownItem      = errors.New("item does not belong to the purchase order")
+	ErrNoItems          = errors.New("no items given")
+	ErrUnknownWarehouse = errors.New("warehouse not found")
+)
+
+func savePurchaseOrder(tx *gorm.DB, po *models.PurchaseOrder) error {
+	return tx.Omit(clause.Associations).Save(po).Error
+}
+
+// SendPurchaseOrder marks a draft purchase order as sent to the supplier.
+func SendPurchaseOrder(tx *gorm.DB, po *models.PurchaseOrder, userID *uint) error {
+	if po.Status != models.PurchaseOrderStatusDraft {
+		return ErrInvalidTransition
+	}
+	if len(po.Items) == 0 {
+		return ErrNoItems
+	}
+	now := time.Now()
+	po.Status = models.PurchaseOrderStatusSent
+	po.SentAt = &now
+	return savePurchaseOrder(tx, po)
+}
+
+// AcknowledgePurchaseOrder records that the supplier confirmed a sent
+// purchase order.
+func AcknowledgePurchaseOrder(tx *gorm.DB, po *models.PurchaseOrder, userID *uint) error {
+	if po.Status != models.PurchaseOrderStatusSent || po.AcknowledgedAt != nil {
+		return ErrInvalidTransition
+	}
+	now := time.Now()
+	po.AcknowledgedAt = &now
+	po.AcknowledgedByID = userID
+	return savePurchaseOrder(tx, po)
+}
+
+// ClosePurchaseOrder closes a purchase order once nothing more is expected,
+// including one that was only partially delivered.
+func ClosePurchaseOrder(tx *gorm.DB, po *models.PurchaseOrder, userID *uint) error {
+	if po.Status != models.PurchaseOrderStatusReceived && po.Status != models.PurchaseOrderStatusPartiallyReceived {
+		return ErrInvalidTransition
+	}
+	now := time.Now()
+	po.Status = models.PurchaseOrderStatusClosed
+	po.ClosedAt = &now
+	return savePurchaseOrder(tx, po)
+}
+
+// CancelPurchaseOrder cancels a purchase order before anything was received.
+func CancelPurchaseOrder(tx *gorm.DB, po *models.PurchaseOrder, userID *uint) error {
+	if po.Status != models.PurchaseOrderStatusDraft && po.Status != models.PurchaseOrderStatusSent {
+		return ErrInvalidTransition
+	}
+	po.Status = models.PurchaseOrderStatusCancelled
+	return savePurchaseOrder(tx, po)
+}
+
+// ReceiveGoods books a delivery against a sent purchase order: the received
+// quantities go into stock and the order becomes partially received or
+// received.
+func ReceiveGoods(tx *gorm.DB, po *models.PurchaseOrder, req models.GoodsReceiptRequest, userID *uint) (*models.GoodsReceipt, error) {
+	if po.Status != models.PurchaseOrderStatusSent && po.Status != models.PurchaseOrderStatusPartiallyReceived {
+		return nil, ErrInvalidTransition
+	}
+
+	items := make(map[uint]*models.PurchaseOrderItem, len(po.Items))
+	for i := range po.Items {
+		items[po.Items[i].ID] = &po.Items[i]
+	}
+
+	receipt := models.GoodsReceipt{
+		PurchaseOrderID: po.ID,
+		WarehouseID:     req.WarehouseID,
+		Note:            req.Note,
+		UserID:          userID,
+		ReceivedAt:      time.Now(),
+	}
+	if receipt.WarehouseID == 0 {
+		receipt.WarehouseID = po.WarehouseID
+	} else if err := tx.Select("id").First(&models.Warehouse{}, receipt.WarehouseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrUnknownWarehouse
+		}
+		return nil, err
+	}
+
+	for _, line := range req.Items {
+		item, ok := items[line.PurchaseOrderItemID]
+		if !ok {
+			return nil, ErrUnknownItem
+		}
+		if line.Quantity == 0 {
+			continue
+		}
+		if item.ReceivedQuantity+line.Quantity > item.Quantity {
+			return nil, ErrOverReceipt
+		}
+		unitCost := item.UnitCost
+		if line.UnitCost != nil {
+			unitCost = *line.UnitCost
+		}
+		item.ReceivedQuantity += line.Quantity
+		receipt.Items = append(receipt.Items, models.GoodsReceiptItem{
+			PurchaseOrderItemID: item.ID,
+			ProductID:           item.ProductID,
+			VariantID:           item.VariantID,
+			Quantity:            line.Quantity,
+			UnitCost:            unitCost,
+		})
+	}
+	if len(receipt.Items) == 0 {
+		return nil, ErrNoItems
+	}
+
+	if err := tx.Create(&receipt).Error; err != nil {
+		return nil, err
+	}
+	for _, line := range receipt.Items {
+		if err := UpdateProductCost(tx, line.ProductID, line.Quantity, line.UnitCost); err != nil {
+			return nil, err
+		}
+		err := MoveStock(tx, &models.StockMovement{
+			WarehouseID:     receipt.WarehouseID,
+			ProductID:       line.ProductID,
+			VariantID:       line.VariantID,
+			Type:            models.MovementTypeReceipt,
+			Quantity:        int(line.Quantity),
+			PurchaseOrderID: &po.ID,
+			UserID:          userID,
+			OccurredAt:      receipt.ReceivedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Model(items[line.PurchaseOrderItemID]).Update("received_quantity", items[line.PurchaseOrderItemID].ReceivedQuantity).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	complete := true
+	for _, item := range po.Items {
+		if item.ReceivedQuantity < item.Quantity {
+			complete = false
+			break
+		}
+	}
+	if complete {
+		po.Status = models.PurchaseOrderStatusReceived
+		po.ReceivedAt = &receipt.ReceivedAt
+	} else {
+		po.Status = models.PurchaseOrderStatusPartiallyReceived
+	}
+	if err := savePurchaseOrder(tx, po); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+)
+
+// LowStockProduct is a product whose total stock is at or below its reorder point.
+type LowStockProduct struct {
+	ProductID       uint
+	Name            string
+	SupplierID      uint
+	ReorderPoint    int
+	ReorderQuantity int
+	OnHand          int
+	OnOrder         int
+}
+
+// SuggestedQuantity returns how much to order: the reorder quantity, but at
+// least enough to bring the stock back above the reorder point. Quantities
+// still outstanding on open purchase orders count as stock; zero means
+// nothing has to be ordered.
+func (p LowStockProduct) SuggestedQuantity() int {
+	needed := p.ReorderPoint - p.OnHand - p.OnOrder + 1
+	if needed <= 0 {
+		return 0
+	}
+	if p.ReorderQuantity > needed {
+		return p.ReorderQuantity
+	}
+	return needed
+}
+
+// LowStockProducts lists products at or below their reorder point, summing
+// stock over all warehouses. A supplierID of zero returns every supplier.
+func LowStockProducts(db *gorm.DB, supplierID uint) ([]LowStockProduct, error) {
+	onOrder := db.Table("purchase_order_items").
+		Select("COALESCE(SUM(purchase_order_items.quantity - purchase_order_items.received_quantity), 0)").
+		Joins("JOIN purchase_orders ON purchase_orders.id = purchase_order_items.purchase_order_id AND purchase_orders.deleted_at IS NULL").
+		Where("purchase_order_items.product_id = products.id AND purchase_order_items.deleted_at IS NULL").
+		Where("purchase_orders.status IN ?", []string{
+			models.PurchaseOrderStatusDraft,
+			models.PurchaseOrderStatusSent,
+			models.PurchaseOrderStatusPartiallyReceived,
+		})
+	query := db.Table("products").
+		Select("products.id AS product_id, products.name, products.supplier_id, products.reorder_point, products.reorder_quantity, COALESCE(SUM(warehouse_stocks.quantity), 0) AS on_hand, (?) AS on_order", onOrder).
+		Joins("LEFT JOIN warehouse_stocks ON warehouse_stocks.product_id = products.id AND warehouse_stocks.deleted_at IS NULL").
+		Where("products.deleted_at IS NULL AND products.reorder_point > 0")
+	if supplierID != 0 {
+		query = query.Where("products.supplier_id = ?", supplierID)
+	}
+
+	var products []LowStockProduct
+	err := query.Group("products.id").
+		Having("COALESCE(SUM(warehouse_stocks.quantity), 0) <= products.reorder_point").
+		Order("products.id").
+		Scan(&products).Error
+	return products, err
+}
+
+// DraftPurchaseOrder turns the reorder suggestions of a supplier into a draft
+// purchase order delivered to the given warehouse, priced from the supplier's
+// price list. It returns nil when there is nothing to order.
+func DraftPurchaseOrder(tx *gorm.DB, supplierID, warehouseID uint) (*models.PurchaseOrder, error) {
+	products, err := LowStockProducts(tx, supplierID)
+	if err != nil {
+		return nil, err
+	}
+
+	po := models.PurchaseOrder{
+		SupplierID:  supplierID,
+		WarehouseID: warehouseID,
+		Status:      models.PurchaseOrderStatusDraft,
+		Note:        "generated from reorder suggestions",
+	}
+	now := time.Now()
+	for _, p := range products {
+		quantity := p.SuggestedQuantity()
+		if quantity == 0 {
+			continue
+		}
+		unitCost, orderQuantity, ok, err := SupplierQuote(tx, supplierID, p.ProductID, uint(quantity), now)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if unitCost, err = lastReceivedCost(tx, p.ProductID); err != nil {
+				return nil, err
+			}
+		}
+		po.Items = append(po.Items, models.PurchaseOrderItem{
+			ProductID: p.ProductID,
+			Quantity:  orderQuantity,
+			UnitCost:  unitCost,
+		})
+	}
+	if len(po.Items) == 0 {
+		return nil, nil
+	}
+	if err := tx.Create(&po).Error; err != nil {
+		return nil, err
+	}
+	return &po, nil
+}
+
+// lastReceivedCost returns the unit cost of the latest goods receipt of a
+// product, or zero when it was never received. It is the fallback when the
+// supplier has no valid price list entry.
+func lastReceivedCost(tx *gorm.DB, productID uint) (models.Money, error) {
+	var items []models.GoodsReceiptItem
+	err := tx.Where("product_id = ?", productID).Order("id DESC").Limit(1).Find(&items).Error
+	if err != nil || len(items) == 0 {
+		return models.Money{}, err
+	}
+	return items[0].UnitCost, nil
+}
+
+// DetectLowStock opens an alert for every product that went low on stock and
+// resolves the open alerts of products that have been replenished.
+func DetectLowStock(db *gorm.DB) error {
+	products, err := LowStockProducts(db, 0)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var open []models.LowStockAlert
+		if err := tx.Where("status = ?", models.AlertStatusOpen).Find(&open).Error; err != nil {
+			return err
+		}
+		openByProduct := make(map[uint]models.LowStockAlert, len(open))
+		for _, alert := range open {
+			openByProduct[alert.ProductID] = alert
+		}
+
+		now := time.Now()
+		for _, p := range products {
+			if alert, ok := openByProduct[p.ProductID]; ok {
+				delete(openByProduct, p.ProductID)
+				if alert.OnHand != p.OnHand {
+					if err := tx.Model(&alert).Update("on_hand", p.OnHand).Error; err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			alert := models.LowStockAlert{
+				ProductID:    p.ProductID,
+				SupplierID:   p.SupplierID,
+				OnHand:       p.OnHand,
+				ReorderPoint: p.ReorderPoint,
+				Status:       models.AlertStatusOpen,
+				DetectedAt:   now,
+			}
+			if err := tx.Create(&alert).Error; err != nil {
+				return err
+			}
+		}
+
+		// Yang tersisa sudah tidak kekurangan stok lagi.
+		for _, alert := range openByProduct {
+			err := tx.Model(&alert).Updates(map[string]interface{}{
+				"status":      models.AlertStatusResolved,
+				"resolved_at": now,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/DewiKresnawati/DewiWebService/payment"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrReturnQuantity   = errors.New("quantity must be greater than zero and at most what is left to return of the order line")
+	ErrReturnWarehouse  = errors.New("a warehouse is needed to restock the returned goods")
+	ErrReturnItem       = errors.New("order_item_id must be a line of the order")
+	ErrReturnNotPaid    = errors.New("only goods of paid orders can be returned")
+	ErrReturnNotShipped = errors.New("only goods that were shipped can be returned")
+	ErrRefundAboveValue = errors.New("refund amount must not exceed the value of the returned goods")
+)
+
+func saveReturn(tx *gorm.DB, ret *models.OrderReturn) error {
+	return tx.Omit(clause.Associations).Save(ret).Error
+}
+
+// RequestReturn opens a return of some of the goods of a line of an order.
+// The order must be paid and the line shipped from a warehouse. Goods of
+// returns that have not been rejected cannot be returned again.
+func RequestReturn(tx *gorm.DB, orderID uint, req models.OrderReturnRequest, userID *uint) (*models.OrderReturn, error) {
+	valid := false
+	for _, reason := range models.ReturnReasons {
+		if req.Reason == reason {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, ErrInvalidReason
+	}
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&order, orderID).Error; err != nil {
+		return nil, err
+	}
+	var item *models.OrderItem
+	for i := range order.Items {
+		if order.Items[i].ID == req.OrderItemID || (req.OrderItemID == 0 && len(order.Items) == 1) {
+			item = &order.Items[i]
+		}
+	}
+	if item == nil {
+		return nil, ErrReturnItem
+	}
+	if order.Status != models.OrderStatusPaid && order.Status != models.OrderStatusPartiallyRefunded {
+		return nil, ErrReturnNotPaid
+	}
+	if item.WarehouseID == nil {
+		return nil, ErrReturnNotShipped
+	}
+	var returned int64
+	if err := tx.Model(&models.OrderReturn{}).Where("order_item_id = ? AND status <> ?", item.ID, models.ReturnStatusRejected).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&returned).Error; err != nil {
+		return nil, err
+	}
+	if req.Quantity == 0 || int64(req.Quantity) > int64(item.Quantity)-returned {
+		return nil, ErrReturnQuantity
+	}
+
+	ret := &models.OrderReturn{
+		OrderID:     order.ID,
+		Order:       &order,
+		OrderItemID: item.ID,
+		CustomerID:  order.CustomerID,
+		Quantity:    req.Quantity,
+		Reason:      req.Reason,
+		Note:        req.Note,
+		Status:      models.ReturnStatusRequested,
+		UserID:      userID,
+	}
+	return ret, tx.Omit(clause.Associations).Create(ret).Error
+}
+
+// ApproveReturn accepts a requested return; the goods may then be sent back.
+func ApproveReturn(tx *gorm.DB, ret *models.OrderReturn, note string) error {
+	if ret.Status != models.ReturnStatusRequested {
+		return ErrInvalidTransition
+	}
+	now := time.Now()
+	ret.Status = models.ReturnStatusApproved
+	ret.DecisionNote = note
+	ret.ApprovedAt = &now
+	return saveReturn(tx, ret)
+}
+
+// RejectReturn turns down a requested return; its goods may be asked for
+// again in another return.
+func RejectReturn(tx *gorm.DB, ret *models.OrderReturn, note string) error {
+	if ret.Status != models.ReturnStatusRequested {
+		return ErrInvalidTransition
+	}
+	ret.Status = models.ReturnStatusRejected
+	ret.DecisionNote = note
+	return saveReturn(tx, ret)
+}
+
+// ReceiveReturn records the goods of an approved return as arrived, booking
+// them back into stock unless asked not to.
+func ReceiveReturn(tx *gorm.DB, ret *models.OrderReturn, req models.ReturnReceiptRequest, userID *uint) error {
+	if ret.Status != models.ReturnStatusApproved {
+		return ErrInvalidTransition
+	}
+	var item models.OrderItem
+	if err := tx.First(&item, ret.OrderItemID).Error; err != nil {
+		return err
+	}
+	if req.Restock == nil || *req.Restock {
+		warehouseID := req.WarehouseID
+		if warehouseID == nil {
+			warehouseID = item.WarehouseID
+		}
+		if warehouseID == nil {
+			return ErrReturnWarehouse
+		}
+		var variantID uint
+		if item.VariantID != nil {
+			variantID = *item.VariantID
+		}
+		err := MoveStock(tx, &models.StockMovement{
+			WarehouseID: *warehouseID,
+			ProductID:   item.ProductID,
+			VariantID:   variantID,
+			Type:        models.MovementTypeReturn,
+			Quantity:    int(ret.Quantity),
+			ReasonCode:  ret.Reason,
+			Note:        fmt.Sprintf("return #%d", ret.ID),
+			OrderID:     &ret.OrderID,
+			UserID:      userID,
+		})
+		if err != nil {
+			return err
+		}
+		ret.WarehouseID = warehouseID
+		ret.Restocked = true
+	}
+	now := time.Now()
+	ret.Status = models.ReturnStatusReceived
+	ret.ReceivedAt = &now
+	return saveReturn(tx, ret)
+}
+
+// ReturnValue returns the value of the goods of a return, taxes included
+// and shipping excluded, as a share of what was paid for the order line.
+func ReturnValue(item models.OrderItem, quantity uint) models.Money {
+	if item.Quantity == 0 {
+		return models.Money{}
+	}
+	line := item.Subtotal.Add(item.TaxTotal)
+	share := line.Decimal.Mul(decimal.NewFromInt(int64(quantity))).Div(decimal.NewFromInt(int64(item.Quantity)))
+	return models.Money{Decimal: share.Round(DiscountDecimals)}
+}
+
+// RefundReturn issues a credit note for a received return, by default for
+// the value of the returned goods, and pays it back through the captured
+// payments of the order, most recent first. A smaller amount may be given,
+// but never more than the value of the returned goods. The refund cannot
+// exceed what was captured for the order less what was refunded already, and the credit
+// notes of an order never add up to more than its total.
+func RefundReturn(ctx context.Context, tx *gorm.DB, provider payment.Provider, ret *models.OrderReturn, amount *models.Money) error {
+	if ret.Status != models.ReturnStatusReceived {
+		return ErrInvalidTransition
+	}
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, ret.OrderID).Error; err != nil {
+		return err
+	}
+	var item models.OrderItem
+	if err := tx.First(&item, ret.OrderItemID).Error; err != nil {
+		return err
+	}
+	total := ReturnValue(item, ret.Quantity)
+	if amount != nil {
+		// Kredit nota hanya untuk barang yang diretur, bukan bagian lain pesanan
+		if amount.Round().GreaterThan(total.Decimal) {
+			return ErrRefundAboveValue
+		}
+		total = amount.Round()
+	}
+	var credited models.Money
+	if err := tx.Model(&models.CreditNote{}).Where("order_id = ?", order.ID).
+		Select("COALESCE(SUM(total), 0)").Scan(&credited).Error; err != nil {
+		return err
+	}
+	var payments []models.Payment
+	if err := tx.Where("order_id = ? AND status = ?", order.ID, models.PaymentStatusCaptured).
+		Order("id DESC").Find(&payments).Error; err != nil {
+		return err
+	}
+	var refundable models.Money
+	for _, p := range payments {
+		refundable = refundable.Add(p.CapturedAmount.Sub(p.RefundedAmount))
+	}
+	if !total.IsPositive() || total.GreaterThan(refundable.Decimal) || credited.Add(total).GreaterThan(order.Total.Decimal) {
+		return ErrInvalidPaymentAmount
+	}
+
+	var refunded models.Money
+	for _, p := range payments {
+		left := total.Sub(refunded)
+		if !left.IsPositive() {
+			break
+		}
+		refund := p.CapturedAmount.Sub(p.RefundedAmount)
+		if refund.GreaterThan(left.Decimal) {
+			refund = left
+		}
+		if !refund.IsPositive() {
+			continue
+		}
+		if _, err := RefundPayment(ctx, tx, provider, p.ID, &refund); err != nil {
+			return err
+		}
+		refunded = refunded.Add(refund)
+	}
+
+	// Pajak dibagi sebanding dengan porsi pajak pada baris pesanan
+	var taxTotal models.Money
+	if line := item.Subtotal.Add(item.TaxTotal); line.IsPositive() {
+		taxTotal = models.Money{Decimal: total.Decimal.Mul(item.TaxTotal.Decimal).Div(line.Decimal).Round(TaxDecimals)}
+	}
+	now := time.Now()
+	note := &models.CreditNote{
+		Number:         fmt.Sprintf("CN-%06d", ret.ID),
+		OrderID:        order.ID,
+		OrderReturnID:  ret.ID,
+		Subtotal:       total.Sub(taxTotal),
+		TaxTotal:       taxTotal,
+		Total:          total,
+		Currency:       order.Currency,
+		RefundedAmount: refunded,
+		Reason:         ret.Reason,
+		IssuedAt:       now,
+	}
+	if err := tx.Create(note).Error; err != nil {
+		return err
+	}
+	ret.CreditNote = note
+	ret.Status = models.ReturnStatusRefunded
+	ret.RefundedAt = &now
+	return saveReturn(tx, ret)
+}
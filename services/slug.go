@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+)
+
+// Slugify turns a name into a lowercase, hyphen separated URL segment.
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			hyphen = false
+		case b.Len() > 0 && !hyphen:
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) > 180 {
+		slug = strings.TrimSuffix(slug[:180], "-")
+	}
+	return slug
+}
+
+// AssignSlug makes a slug derived from desired the current slug of the
+// entity, adding a numeric suffix when it is taken by another entity. The
+// previous slug stays registered so that it keeps resolving.
+func AssignSlug(tx *gorm.DB, entityType string, entityID uint, desired string) (string, error) {
+	base := Slugify(desired)
+	if base == "" {
+		base = fmt.Sprintf("%s-%d", entityType, entityID)
+	}
+
+	candidate := base
+	var existing models.Slug
+	for n := 2; ; n++ {
+		err := tx.Where("entity_type = ? AND slug = ?", entityType, candidate).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			existing = models.Slug{}
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if existing.EntityID == entityID {
+			break
+		}
+		candidate = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	err := tx.Model(&models.Slug{}).
+		Where("entity_type = ? AND entity_id = ? AND is_current = ?", entityType, entityID, true).
+		Update("is_current", false).Error
+	if err != nil {
+		return "", err
+	}
+	if existing.ID != 0 {
+		err = tx.Model(&existing).Update("is_current", true).Error
+	} else {
+		err = tx.Create(&models.Slug{
+			EntityType: entityType,
+			Slug:       candidate,
+			EntityID:   entityID,
+			IsCurrent:  true,
+		}).Error
+	}
+	return candidate, err
+}
+
+// ResolveSlug finds the entity a slug belongs to and its current slug, which
+// differs from the given one when the slug is historical.
+func ResolveSlug(db *gorm.DB, entityType, slug string) (entityID uint, currentSlug string, err error) {
+	var found models.Slug
+	if err := db.Where("entity_type = ? AND slug = ?", entityType, slug).First(&found).Error; err != nil {
+		return 0, "", err
+	}
+	if found.IsCurrent {
+		return found.EntityID, found.Slug, nil
+	}
+	var current models.Slug
+	err = db.Where("entity_type = ? AND entity_id = ? AND is_current = ?", entityType, found.EntityID, true).First(&current).Error
+	if err != nil {
+		return 0, "", err
+	}
+	return found.EntityID, current.Slug, nil
+}
+
+// BackfillSlugs generates slugs for products and categories created before
+// slugs existed.
+func BackfillSlugs(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var products []models.Product
+		if err := tx.Where("slug = '' OR slug IS NULL").Find(&products).Error; err != nil {
+			return err
+		}
+		for _, product := range products {
+			slug, err := AssignSlug(tx, models.SlugTypeProduct, product.ID, product.Name)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&product).UpdateColumn("slug", slug).Error; err != nil {
+				return err
+			}
+		}
+
+		var categories []models.Category
+		if err := tx.Where("slug = '' OR slug IS NULL").Find(&categories).Error; err != nil {
+			return err
+		}
+		for _, category := range categories {
+			slug, err := AssignSlug(tx, models.SlugTypeCategory, category.ID, category.Name)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&category).UpdateColumn("slug", slug).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
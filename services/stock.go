@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrInvalidReason     = errors.New("invalid reason code")
+	ErrZeroQuantity      = errors.New("quantity must not be zero")
+	ErrUnknownProduct    = errors.New("product not found")
+	ErrUnknownVariant    = errors.New("variant not found")
+)
+
+// lockStock loads the stock row of a product variant in a warehouse for
+// update, creating an empty one when it does not exist yet.
+func lockStock(tx *gorm.DB, warehouseID, productID, variantID uint) (*models.WarehouseStock, error) {
+	stock := models.WarehouseStock{WarehouseID: warehouseID, ProductID: productID, VariantID: variantID}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("warehouse_id = ? AND product_id = ? AND variant_id = ?", warehouseID, productID, variantID).
+		FirstOrCreate(&stock).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stock, nil
+}
+
+// MoveStock applies movement.Quantity (which may be negative) to the stock of
+// the product in the warehouse and appends the movement to the ledger. Stock
+// never goes below zero. This is the only place stock quantities change.
+func MoveStock(tx *gorm.DB, movement *models.StockMovement) error {
+	stock, err := lockStock(tx, movement.WarehouseID, movement.ProductID, movement.VariantID)
+	if err != nil {
+		return err
+	}
+	if stock.Quantity+movement.Quantity < 0 {
+		return ErrInsufficientStock
+	}
+	stock.Quantity += movement.Quantity
+	if err := tx.Save(stock).Error; err != nil {
+		return err
+	}
+
+	movement.BalanceAfter = stock.Quantity
+	if movement.OccurredAt.IsZero() {
+		movement.OccurredAt = time.Now()
+	}
+	return tx.Create(movement).Error
+}
+
+// SetStock overwrites the quantity of a product variant in a warehouse,
+// booking the difference as a stock count adjustment.
+func SetStock(tx *gorm.DB, warehouseID, productID, variantID uint, quantity int, userID *uint) error {
+	if quantity < 0 {
+		return ErrInsufficientStock
+	}
+	stock, err := lockStock(tx, warehouseID, productID, variantID)
+	if err != nil {
+		return err
+	}
+	if stock.Quantity == quantity {
+		return nil
+	}
+	return MoveStock(tx, &models.StockMovement{
+		WarehouseID: warehouseID,
+		ProductID:   productID,
+		VariantID:   variantID,
+		Type:        models.MovementTypeAdjustment,
+		Quantity:    quantity - stock.Quantity,
+		ReasonCode:  models.ReasonStockCount,
+		UserID:      userID,
+	})
+}
+
+// Adjust books a manual stock adjustment with one of the accepted reason codes.
+// The quantity must not be zero, and the warehouse, product and variant must
+// exist, the variant being one of the product.
+func Adjust(tx *gorm.DB, req models.StockAdjustmentRequest, userID *uint) (*models.StockMovement, error) {
+	valid := false
+	for _, reason := range models.AdjustmentReasons {
+		if req.ReasonCode == reason {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, ErrInvalidReason
+	}
+	if req.Quantity == 0 {
+		return nil, ErrZeroQuantity
+	}
+	// Cek lebih dulu, agar referensi yang salah tidak menjadi baris stok yatim atau 500
+	if err := tx.Select("id").First(&models.Warehouse{}, req.WarehouseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrUnknownWarehouse
+		}
+		return nil, err
+	}
+	if err := tx.Select("id").First(&models.Product{}, req.ProductID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrUnknownProduct
+		}
+		return nil, err
+	}
+	if err := CheckVariant(tx, req.ProductID, req.VariantID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrUnknownVariant
+		}
+		return nil, err
+	}
+
+	movement := models.StockMovement{
+		WarehouseID: req.WarehouseID,
+		ProductID:   req.ProductID,
+		VariantID:   req.VariantID,
+		Type:        models.MovementTypeAdjustment,
+		Quantity:    req.Quantity,
+		ReasonCode:  req.ReasonCode,
+		Note:        req.Note,
+		UserID:      userID,
+	}
+	if err := MoveStock(tx, &movement); err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+
+// SeedOpeningBalances books an opening balance movement for every stock row
+// that has no ledger history yet, so that the ledger adds up to the current
+// stock.
+func SeedOpeningBalances(db *gorm.DB) error {
+	var stocks []models.WarehouseStock
+	err := db.Where("quantity <> 0").
+		Where("NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.warehouse_id = warehouse_stocks.warehouse_id AND m.product_id = warehouse_stocks.product_id AND m.variant_id = warehouse_stocks.variant_id AND m.deleted_at IS NULL)").
+		Find(&stocks).Error
+	if err != nil {
+		return err
+	}
+	for _, stock := range stocks {
+		movement := models.StockMovement{
+			WarehouseID:  stock.WarehouseID,
+			ProductID:    stock.ProductID,
+			VariantID:    stock.VariantID,
+			Type:         models.MovementTypeAdjustment,
+			Quantity:     stock.Quantity,
+			BalanceAfter: stock.Quantity,
+			ReasonCode:   models.ReasonOpeningBalance,
+			OccurredAt:   stock.UpdatedAt,
+		}
+		if err := db.Create(&movement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StockOnDate reconstructs the quantity of each product at the given moment
+// by summing the ledger. When byWarehouse is set the result is split per
+// warehouse.
+func StockOnDate(db *gorm.DB, at time.Time, productID, warehouseID uint, byWarehouse bool) ([]models.StockOnDateResponse, error) {
+	query := db.Model(&models.StockMovement{}).Where("occurred_at <= ?", at)
+	if productID != 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+
+	result := []models.StockOnDateResponse{}
+	if byWarehouse {
+		err := query.Select("product_id, warehouse_id, SUM(quantity) AS quantity").
+			Group("product_id, warehouse_id").Order("product_id, warehouse_id").
+			Scan(&result).Error
+		return result, err
+	}
+	err := query.Select("product_id, SUM(quantity) AS quantity").
+		Group("product_id").Order("product_id").
+		Scan(&result).Error
+	return result, err
+}
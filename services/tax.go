@@ -0,0 +1,339 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownTaxClass = errors.New("unknown tax class")
+	ErrTaxClassInUse   = errors.New("tax class is still used by products or categories")
+	ErrInvalidTaxRate  = errors.New("tax rate must be a percentage between 0 and 100")
+)
+
+// TaxDecimals is the number of decimal places tax amounts are rounded to.
+const TaxDecimals = 2
+
+// DefaultTaxRegion is used when the TAX_REGION environment variable is not set.
+const DefaultTaxRegion = "ID"
+
+var hundred = decimal.NewFromInt(100)
+
+// PricesIncludeTax reports whether product prices include tax, as set with
+// the PRICES_INCLUDE_TAX environment variable. Prices exclude tax by default.
+func PricesIncludeTax() bool {
+	include, _ := strconv.ParseBool(os.Getenv("PRICES_INCLUDE_TAX"))
+	return include
+}
+
+// NormaliseTaxRegion upper-cases a region code, falling back to the
+// TAX_REGION environment variable for an empty one.
+func NormaliseTaxRegion(region string) string {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if region == "" {
+		region = strings.ToUpper(strings.TrimSpace(os.Getenv("TAX_REGION")))
+	}
+	if region == "" {
+		region = DefaultTaxRegion
+	}
+	return region
+}
+
+// CheckTaxClass returns ErrUnknownTaxClass when a tax class is given that
+// does not exist.
+func CheckTaxClass(db *gorm.DB, id *uint) error {
+	if id == nil {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&models.TaxClass{}).Where("id = ?", *id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrUnknownTaxClass
+	}
+	return nil
+}
+
+// SetDefaultTaxClass makes a class the default one, taking the flag away
+// from the class that had it.
+func SetDefaultTaxClass(tx *gorm.DB, class *models.TaxClass) error {
+	err := tx.Model(&models.TaxClass{}).Where("id <> ? AND is_default", class.ID).UpdateColumn("is_default", false).Error
+	if err != nil {
+		return err
+	}
+	class.IsDefault = true
+	return tx.Model(class).UpdateColumn("is_default", true).Error
+}
+
+// DeleteTaxClass deletes a class and its rates unless products or
+// categories still use it.
+func DeleteTaxClass(tx *gorm.DB, class *models.TaxClass) error {
+	var products, categories int64
+	if err := tx.Model(&models.Product{}).Where("tax_class_id = ?", class.ID).Count(&products).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Category{}).Where("tax_class_id = ?", class.ID).Count(&categories).Error; err != nil {
+		return err
+	}
+	if products+categories > 0 {
+		return ErrTaxClassInUse
+	}
+	if err := tx.Where("tax_class_id = ?", class.ID).Delete(&models.TaxRate{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(class).Error
+}
+
+// ValidateTaxRate normalises the region of a rate and checks its percentage.
+func ValidateTaxRate(rate *models.TaxRate) error {
+	rate.Region = strings.ToUpper(strings.TrimSpace(rate.Region))
+	rate.Name = strings.TrimSpace(rate.Name)
+	if rate.Rate.IsNegative() || rate.Rate.GreaterThan(hundred) || rate.Name == "" {
+		return ErrInvalidTaxRate
+	}
+	return nil
+}
+
+// ProductTaxClass returns the tax class of a product: its own, that of its
+// category or the nearest parent category with one, or the default class.
+// It returns nil when none applies, in which case the product is untaxed.
+func ProductTaxClass(db *gorm.DB, product models.Product) (*uint, error) {
+	if product.TaxClassID != nil {
+		return product.TaxClassID, nil
+	}
+	ids, err := CategoryAncestry(db, product.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	var categories []models.Category
+	if err := db.Where("id IN ? AND tax_class_id IS NOT NULL", ids).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	classes := make(map[uint]*uint, len(categories))
+	for _, category := range categories {
+		classes[category.ID] = category.TaxClassID
+	}
+	for _, id := range ids {
+		if class, ok := classes[id]; ok {
+			return class, nil
+		}
+	}
+
+	var defaults []models.TaxClass
+	if err := db.Where("is_default").Limit(1).Find(&defaults).Error; err != nil {
+		return nil, err
+	}
+	if len(defaults) == 0 {
+		return nil, nil
+	}
+	return &defaults[0].ID, nil
+}
+
+// TaxRatesFor returns the rates of a class in a region, looking at the
+// region itself, then its country, then the rates for every region.
+func TaxRatesFor(db *gorm.DB, classID uint, region string) ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	if err := db.Where("tax_class_id = ?", classID).Order("id").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	country, _, _ := strings.Cut(region, "-")
+	for _, candidate := range []string{region, country, ""} {
+		var matching []models.TaxRate
+		for _, rate := range rates {
+			if rate.Region == candidate {
+				matching = append(matching, rate)
+			}
+		}
+		if len(matching) > 0 {
+			return matching, nil
+		}
+	}
+	return nil, nil
+}
+
+// pricedLine is a line of an order being priced.
+type pricedLine struct {
+	item       *models.OrderItem
+	product    models.Product
+	categories []uint // kategori produk diikuti induk-induknya
+	rates      []models.TaxRate
+	gross      decimal.Decimal // sebelum diskon
+	discount   decimal.Decimal
+}
+
+// inOrderCurrency converts an amount in the base currency to the currency of
+// an order at the exchange rate of the order.
+func inOrderCurrency(order *models.Order, amount decimal.Decimal) decimal.Decimal {
+	if order.Currency == BaseCurrency() {
+		return amount
+	}
+	return amount.Mul(order.ExchangeRate.Decimal).Round(ConvertedDecimals)
+}
+
+// lineTaxes splits the amount of a line after discounts into its subtotal
+// and the tax of each rate, rounded to TaxDecimals. With prices that include
+// tax the tax is taken out of the amount, otherwise it comes on top of it.
+func lineTaxes(net decimal.Decimal, rates []models.TaxRate, includeTax bool) (subtotal decimal.Decimal, amounts []decimal.Decimal) {
+	taxable := net
+	if includeTax {
+		totalRate := decimal.Zero
+		for _, rate := range rates {
+			totalRate = totalRate.Add(rate.Rate)
+		}
+		taxable = net.Mul(hundred).Div(hundred.Add(totalRate))
+	}
+	amounts = make([]decimal.Decimal, len(rates))
+	for i, rate := range rates {
+		amounts[i] = taxable.Mul(rate.Rate).Div(hundred).Round(TaxDecimals)
+	}
+	// Pajak yang dibulatkan menentukan subtotal, sehingga subtotal + pajak = total
+	if includeTax {
+		return net.Sub(sumDecimals(amounts)), amounts
+	}
+	return net, amounts
+}
+
+// PriceOrder works out the unit prices, discounts, subtotals and taxes of
+// the lines of an order from the current price of their product or variant,
+// the promotions and coupon that apply and the tax rates of its region, and
+// from them the totals of the order with its shipping fee, charged once.
+// The order is priced in its currency at today's exchange rate, which is
+// kept on the order; a price set for the product in that currency is used
+// rather than the converted price, as in the product listing.
+// Discounts come off the lines before tax. With prices that include tax the
+// tax is taken out of the discounted line, otherwise it is added on top;
+// shipping is not taxed. The discounts and taxes replace those stored
+// earlier and are saved together with the order.
+func PriceOrder(tx *gorm.DB, order *models.Order) error {
+	if len(order.Items) == 0 {
+		return ErrNoItems
+	}
+	currency, err := NormaliseCurrency(order.Currency)
+	if err != nil {
+		return err
+	}
+	order.Currency = currency
+	order.ExchangeRate = models.NewMoney(1)
+	overrides := map[uint]models.Money{}
+	if currency != BaseCurrency() {
+		if order.ExchangeRate, err = NewCurrencyConverter(tx).rate(currency, time.Now()); err != nil {
+			return err
+		}
+		ids := make([]uint, len(order.Items))
+		for i, item := range order.Items {
+			ids[i] = item.ProductID
+		}
+		if overrides, err = ProductPricesIn(tx, currency, ids); err != nil {
+			return err
+		}
+	}
+	// Tanpa wilayah pajak, pajak mengikuti negara tujuan pengiriman
+	if order.TaxRegion == "" {
+		order.TaxRegion = order.ShippingAddress.Country
+	}
+	order.TaxRegion = NormaliseTaxRegion(order.TaxRegion)
+	order.PricesIncludeTax = PricesIncludeTax()
+
+	lines := make([]pricedLine, len(order.Items))
+	for i := range order.Items {
+		item := &order.Items[i]
+		line := &lines[i]
+		line.item = item
+		if err := tx.First(&line.product, item.ProductID).Error; err != nil {
+			return err
+		}
+		item.UnitPrice = line.product.Price
+		ownPrice := false
+		if item.VariantID != nil {
+			var variant models.ProductVariant
+			if err := tx.First(&variant, *item.VariantID).Error; err != nil {
+				return err
+			}
+			item.UnitPrice = variant.EffectivePrice(line.product)
+			ownPrice = variant.Price != nil
+		}
+		// Sama seperti daftar produk: harga khusus mata uang dulu, kurs jika tidak ada;
+		// varian dengan harga sendiri selalu dikonversi
+		if price, ok := overrides[item.ProductID]; ok && !ownPrice {
+			item.UnitPrice = price
+		} else {
+			item.UnitPrice = models.Money{Decimal: inOrderCurrency(order, item.UnitPrice.Decimal)}
+		}
+		line.gross = item.UnitPrice.Times(int64(item.Quantity)).Decimal
+
+		var err error
+		if line.categories, err = CategoryAncestry(tx, line.product.CategoryID); err != nil {
+			return err
+		}
+		classID, err := ProductTaxClass(tx, line.product)
+		if err != nil {
+			return err
+		}
+		if classID != nil {
+			if line.rates, err = TaxRatesFor(tx, *classID, order.TaxRegion); err != nil {
+				return err
+			}
+		}
+	}
+
+	shipping := inOrderCurrency(order, ShippingFee().Decimal)
+	shippingDiscount, discounts, err := applyPromotions(tx, order, lines, shipping)
+	if err != nil {
+		return err
+	}
+
+	// Pajak dihitung per baris lalu dijumlahkan per tarif
+	var taxes []models.OrderTax
+	byRate := make(map[uint]int)
+	subtotal, taxTotal, lineDiscount := decimal.Zero, decimal.Zero, decimal.Zero
+	for _, line := range lines {
+		lineSubtotal, amounts := lineTaxes(line.gross.Sub(line.discount), line.rates, order.PricesIncludeTax)
+		lineTax := sumDecimals(amounts)
+		for i, rate := range line.rates {
+			j, ok := byRate[rate.ID]
+			if !ok {
+				j = len(taxes)
+				byRate[rate.ID] = j
+				taxes = append(taxes, models.OrderTax{
+					TaxRateID: rate.ID,
+					Name:      rate.Name,
+					Region:    order.TaxRegion,
+					Rate:      rate.Rate,
+				})
+			}
+			taxes[j].TaxableAmount = taxes[j].TaxableAmount.Add(models.Money{Decimal: lineSubtotal})
+			taxes[j].Amount = taxes[j].Amount.Add(models.Money{Decimal: amounts[i]})
+		}
+		line.item.DiscountTotal = models.Money{Decimal: line.discount}
+		line.item.Subtotal = models.Money{Decimal: lineSubtotal}
+		line.item.TaxTotal = models.Money{Decimal: lineTax}
+		subtotal = subtotal.Add(lineSubtotal)
+		taxTotal = taxTotal.Add(lineTax)
+		lineDiscount = lineDiscount.Add(line.discount)
+	}
+	order.Subtotal = models.Money{Decimal: subtotal}
+	order.TaxTotal = models.Money{Decimal: taxTotal}
+	order.DiscountTotal = models.Money{Decimal: lineDiscount.Add(shippingDiscount)}
+	order.ShippingTotal = models.Money{Decimal: shipping.Sub(shippingDiscount)}
+	order.Total = order.Subtotal.Add(order.TaxTotal).Add(order.ShippingTotal)
+
+	if order.ID != 0 {
+		if err := tx.Unscoped().Where("order_id = ?", order.ID).Delete(&models.OrderTax{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("order_id = ?", order.ID).Delete(&models.OrderDiscount{}).Error; err != nil {
+			return err
+		}
+	}
+	order.Taxes = taxes
+	order.Discounts = discounts
+	return nil
+}
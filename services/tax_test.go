@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"github.com/shopspring/decimal"
+)
+
+func dec(t *testing.T, value string) decimal.Decimal {
+	t.Helper()
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		t.Fatalf("parse %q: %v", value, err)
+	}
+	return d
+}
+
+func taxRates(t *testing.T, rates ...string) []models.TaxRate {
+	t.Helper()
+	result := make([]models.TaxRate, len(rates))
+	for i, rate := range rates {
+		result[i] = models.TaxRate{Name: "tax " + rate, Rate: dec(t, rate)}
+	}
+	return result
+}
+
+func TestLineTaxes(t *testing.T) {
+	tests := []struct {
+		name       string
+		net        string
+		rates      []string
+		includeTax bool
+		subtotal   string
+		taxes      []string
+	}{
+		{"exclusive", "100", []string{"11"}, false, "100", []string{"11"}},
+		{"inclusive", "111", []string{"11"}, true, "100", []string{"11"}},
+		{"exclusive rounded", "9.99", []string{"11"}, false, "9.99", []string{"1.1"}},
+		{"inclusive rounded", "10", []string{"11"}, true, "9.01", []string{"0.99"}},
+		{"inclusive with two rates", "115", []string{"10", "5"}, true, "100", []string{"10", "5"}},
+		{"exclusive with two rates", "33.33", []string{"10", "5"}, false, "33.33", []string{"3.33", "1.67"}},
+		{"untaxed", "50", nil, true, "50", nil},
+	}
+	for _, tt := range tests {
+		subtotal, taxes := lineTaxes(dec(t, tt.net), taxRates(t, tt.rates...), tt.includeTax)
+		if !subtotal.Equal(dec(t, tt.subtotal)) {
+			t.Errorf("%s: subtotal %s, want %s", tt.name, subtotal, tt.subtotal)
+		}
+		if len(taxes) != len(tt.taxes) {
+			t.Fatalf("%s: %d taxes, want %d", tt.name, len(taxes), len(tt.taxes))
+		}
+		for i, tax := range taxes {
+			if !tax.Equal(dec(t, tt.taxes[i])) {
+				t.Errorf("%s: tax %d is %s, want %s", tt.name, i, tax, tt.taxes[i])
+			}
+		}
+		// Harga termasuk pajak: subtotal + pajak harus tetap sama dengan harga baris
+		if tt.includeTax && !subtotal.Add(sumDecimals(taxes)).Equal(dec(t, tt.net)) {
+			t.Errorf("%s: subtotal %s and taxes %v do not add up to %s", tt.name, subtotal, taxes, tt.net)
+		}
+	}
+}
+
+func TestInOrderCurrency(t *testing.T) {
+	t.Setenv("CURRENCY", "IDR")
+	tests := []struct {
+		currency string
+		rate     string
+		amount   string
+		want     string
+	}{
+		{"IDR", "1", "150000.5", "150000.5"},
+		{"USD", "0.000065", "150000", "9.75"},
+		{"USD", "0.000065", "100", "0.01"},
+		{"USD", "0.000065", "12345", "0.8"},
+		{"JPY", "0.0095", "99999", "949.99"},
+	}
+	for _, tt := range tests {
+		order := &models.Order{Currency: tt.currency, ExchangeRate: models.Money{Decimal: dec(t, tt.rate)}}
+		if got := inOrderCurrency(order, dec(t, tt.amount)); !got.Equal(dec(t, tt.want)) {
+			t.Errorf("%s %s at %s: %s, want %s", tt.amount, tt.currency, tt.rate, got, tt.want)
+		}
+	}
+}
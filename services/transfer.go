@@ -0,0 +1,72 @@
+package services
+
+import (
+	"time"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func moveTransferItem(tx *gorm.DB, transfer *models.StockTransfer, warehouseID uint, item models.StockTransferItem, quantity int, userID *uint) error {
+	return MoveStock(tx, &models.StockMovement{
+		WarehouseID:     warehouseID,
+		ProductID:       item.ProductID,
+		VariantID:       item.VariantID,
+		Type:            models.MovementTypeTransfer,
+		Quantity:        quantity,
+		StockTransferID: &transfer.ID,
+		UserID:          userID,
+	})
+}
+
+// ShipTransfer takes the transfer items out of the source warehouse and marks
+// the transfer as in transit.
+func ShipTransfer(tx *gorm.DB, transfer *models.StockTransfer, userID *uint) error {
+	if transfer.Status != models.TransferStatusDraft {
+		return ErrInvalidTransition
+	}
+	for _, item := range transfer.Items {
+		if err := moveTransferItem(tx, transfer, transfer.FromWarehouseID, item, -int(item.Quantity), userID); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	transfer.Status = models.TransferStatusInTransit
+	transfer.ShippedAt = &now
+	return tx.Omit(clause.Associations).Save(transfer).Error
+}
+
+// ReceiveTransfer books the transfer items into the destination warehouse.
+func ReceiveTransfer(tx *gorm.DB, transfer *models.StockTransfer, userID *uint) error {
+	if transfer.Status != models.TransferStatusInTransit {
+		return ErrInvalidTransition
+	}
+	for _, item := range transfer.Items {
+		if err := moveTransferItem(tx, transfer, transfer.ToWarehouseID, item, int(item.Quantity), userID); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	transfer.Status = models.TransferStatusReceived
+	transfer.ReceivedAt = &now
+	return tx.Omit(clause.Associations).Save(transfer).Error
+}
+
+// CancelTransfer cancels a transfer. Goods already in transit are returned
+// to the source warehouse.
+func CancelTransfer(tx *gorm.DB, transfer *models.StockTransfer, userID *uint) error {
+	switch transfer.Status {
+	case models.TransferStatusDraft:
+	case models.TransferStatusInTransit:
+		for _, item := range transfer.Items {
+			if err := moveTransferItem(tx, transfer, transfer.FromWarehouseID, item, int(item.Quantity), userID); err != nil {
+				return err
+			}
+		}
+	default:
+		return ErrInvalidTransition
+	}
+	transfer.Status = models.TransferStatusCancelled
+	return tx.Omit(clause.Associations).Save(transfer).Error
+}
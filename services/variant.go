@@ -0,0 +1,156 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDuplicateOption = errors.New("a variant can have only one value per option type")
+	ErrVariantMismatch = errors.New("variant does not belong to the product")
+)
+
+// SaveOptionType saves an option type and replaces its values with the given
+// list. Values that are kept retain their ID, so variants using them are not
+// affected; removed values are detached from their variants.
+func SaveOptionType(tx *gorm.DB, optionType *models.OptionType, values []string) error {
+	if err := tx.Save(optionType).Error; err != nil {
+		return err
+	}
+
+	var existing []models.OptionValue
+	if err := tx.Where("option_type_id = ?", optionType.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+	byValue := make(map[string]models.OptionValue, len(existing))
+	for _, value := range existing {
+		byValue[value.Value] = value
+	}
+
+	kept := make([]models.OptionValue, 0, len(values))
+	for position, name := range values {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		value, ok := byValue[name]
+		if !ok {
+			value = models.OptionValue{OptionTypeID: optionType.ID, Value: name}
+		}
+		delete(byValue, name)
+		value.Position = position
+		if err := tx.Save(&value).Error; err != nil {
+			return err
+		}
+		kept = append(kept, value)
+	}
+
+	for _, removed := range byValue {
+		if err := tx.Exec("DELETE FROM variant_option_values WHERE option_value_id = ?", removed.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&removed).Error; err != nil {
+			return err
+		}
+	}
+	optionType.Values = kept
+	return nil
+}
+
+// SaveVariant applies the request to the variant of a product and replaces
+// its option values.
+func SaveVariant(tx *gorm.DB, variant *models.ProductVariant, req models.ProductVariantRequest) error {
+	var options []models.OptionValue
+	if len(req.OptionValueIDs) > 0 {
+		if err := tx.Where("id IN ?", req.OptionValueIDs).Find(&options).Error; err != nil {
+			return err
+		}
+		if len(options) != len(req.OptionValueIDs) {
+			return gorm.ErrRecordNotFound
+		}
+		seen := make(map[uint]bool, len(options))
+		for _, option := range options {
+			if seen[option.OptionTypeID] {
+				return ErrDuplicateOption
+			}
+			seen[option.OptionTypeID] = true
+		}
+	}
+
+	variant.SKU = strings.TrimSpace(req.SKU)
+	variant.Barcode = strings.TrimSpace(req.Barcode)
+	variant.Price = nil
+	if req.Price != nil {
+		price := req.Price.Round()
+		variant.Price = &price
+	}
+	if err := tx.Omit("Options").Save(variant).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(variant).Association("Options").Replace(options); err != nil {
+		return err
+	}
+	variant.Options = options
+	return nil
+}
+
+// DeleteVariant removes a variant and its option links. Its stock rows and
+// ledger entries are kept for history.
+func DeleteVariant(tx *gorm.DB, variant *models.ProductVariant) error {
+	if err := tx.Model(variant).Association("Options").Clear(); err != nil {
+		return err
+	}
+	return tx.Delete(variant).Error
+}
+
+// VariantStock returns the on-hand quantity of each variant summed over all
+// warehouses.
+func VariantStock(db *gorm.DB, variantIDs []uint) (map[uint]int, error) {
+	stock := make(map[uint]int, len(variantIDs))
+	if len(variantIDs) == 0 {
+		return stock, nil
+	}
+	var rows []struct {
+		VariantID uint
+		Quantity  int
+	}
+	err := db.Model(&models.WarehouseStock{}).
+		Select("variant_id, SUM(quantity) AS quantity").
+		Where("variant_id IN ?", variantIDs).
+		Group("variant_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		stock[row.VariantID] = row.Quantity
+	}
+	return stock, nil
+}
+
+// CheckVariant checks that a variant given for a stock line belongs to its
+// product; variant 0 stands for the product itself.
+func CheckVariant(tx *gorm.DB, productID, variantID uint) error {
+	if variantID == 0 {
+		return nil
+	}
+	_, err := OrderVariant(tx, productID, variantID)
+	return err
+}
+
+// OrderVariant loads the variant an order line refers to and checks that it
+// belongs to the ordered product. A zero productID takes the variant's
+// product.
+func OrderVariant(tx *gorm.DB, productID uint, variantID uint) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	if err := tx.First(&variant, variantID).Error; err != nil {
+		return nil, err
+	}
+	if productID != 0 && variant.ProductID != productID {
+		return nil, ErrVariantMismatch
+	}
+	return &variant, nil
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"github.com/DewiKresnawati/DewiWebService/models"
+	"gorm.io/gorm"
+)
+
+// deletedWarehouseCode is the code a warehouse gets when it is deleted, so
+// that the unique index on codes lets a new warehouse take the old code.
+var deletedWarehouseCode = gorm.Expr("CONCAT(code, '#deleted-', id)")
+
+// DeleteWarehouse soft-deletes a warehouse and frees its code.
+func DeleteWarehouse(tx *gorm.DB, id string) error {
+	var warehouse models.Warehouse
+	if err := tx.First(&warehouse, id).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&warehouse).UpdateColumn("code", deletedWarehouseCode).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&warehouse).Error
+}
+
+// FreeDeletedWarehouseCodes renames the codes of warehouses deleted before
+// DeleteWarehouse freed them.
+func FreeDeletedWarehouseCodes(db *gorm.DB) error {
+	return db.Unscoped().Model(&models.Warehouse{}).
+		Where("deleted_at IS NOT NULL AND code NOT LIKE ?", "%#deleted-%").
+		UpdateColumn("code", deletedWarehouseCode).Error
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is an in-memory stand-in for an S3 server, answering the path-style
+// requests the storage makes: checking and creating the bucket, and putting
+// and removing objects.
+type fakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]bool
+	objects map[string][]byte // bucket/key
+	types   map[string]string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, string) {
+	f := &fakeS3{buckets: map[string]bool{}, objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, strings.TrimPrefix(server.URL, "http://")
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if key == "" {
+		switch r.Method {
+		case http.MethodHead:
+			if !f.buckets[bucket] {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			f.buckets[bucket] = true
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+		return
+	}
+	if !f.buckets[bucket] {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		body, err := readS3Body(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[bucket+"/"+key] = body
+		f.types[bucket+"/"+key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodDelete:
+		delete(f.objects, bucket+"/"+key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// readS3Body reads the object of a put, decoding the chunks of a streaming
+// upload.
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+	var body bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return body.Bytes(), nil
+		}
+		if _, err := io.CopyN(&body, reader, size); err != nil {
+			return nil, err
+		}
+		if _, err := reader.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func newTestS3(t *testing.T, publicURL string) (*S3, *fakeS3, string) {
+	t.Helper()
+	fake, endpoint := newFakeS3(t)
+	s3, err := NewS3(S3Config{
+		Endpoint:  endpoint,
+		AccessKey: "access",
+		SecretKey: "secret",
+		Bucket:    "media",
+		Region:    "us-east-1",
+		PublicURL: publicURL,
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	return s3, fake, endpoint
+}
+
+func TestS3CreatesBucket(t *testing.T) {
+	_, fake, _ := newTestS3(t, "")
+	if !fake.buckets["media"] {
+		t.Fatal("bucket not created")
+	}
+}
+
+func TestS3PutAndDelete(t *testing.T) {
+	s3, fake, _ := newTestS3(t, "")
+	ctx := context.Background()
+	content := []byte("image data")
+
+	if err := s3.Put(ctx, "products/1/a.png", bytes.NewReader(content), int64(len(content)), "image/png"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if got := fake.objects["media/products/1/a.png"]; !bytes.Equal(got, content) {
+		t.Fatalf("stored %q, want %q", got, content)
+	}
+	if got := fake.types["media/products/1/a.png"]; got != "image/png" {
+		t.Errorf("content type %q, want image/png", got)
+	}
+
+	if err := s3.Delete(ctx, "products/1/a.png"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := fake.objects["media/products/1/a.png"]; ok {
+		t.Fatal("object not deleted")
+	}
+	if err := s3.Delete(ctx, "products/1/a.png"); err != nil {
+		t.Fatalf("delete of missing object: %v", err)
+	}
+}
+
+func TestS3URL(t *testing.T) {
+	s3, _, endpoint := newTestS3(t, "")
+	if got, want := s3.URL("products/1/a.png"), "http://"+endpoint+"/media/products/1/a.png"; got != want {
+		t.Errorf("URL %q, want %q", got, want)
+	}
+
+	s3, _, _ = newTestS3(t, "https://cdn.example.com/")
+	if got, want := s3.URL("/products/1/a.png"), "https://cdn.example.com/products/1/a.png"; got != want {
+		t.Errorf("URL %q, want %q", got, want)
+	}
+}
+
+func TestNewS3NeedsEndpointAndBucket(t *testing.T) {
+	if _, err := NewS3(S3Config{Bucket: "media"}); err == nil {
+		t.Error("no error without an endpoint")
+	}
+	if _, err := NewS3(S3Config{Endpoint: "localhost:9000"}); err == nil {
+		t.Error("no error without a bucket")
+	}
+}
@@ -0,0 +1,61 @@
+// Package storage keeps uploaded files such as product images on the local
+// filesystem or in an S3-compatible object store.
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+)
+
+// Storage stores objects by key and tells where they can be downloaded.
+type Storage interface {
+	// Put writes the object, replacing any object with the same key.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete removes the object; removing a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public address of the object.
+	URL(key string) string
+}
+
+// Default is the storage used by the handlers, set up by Init.
+var Default Storage
+
+// Init configures Default from the environment. STORAGE_DRIVER selects
+// "local" (the default) or "s3".
+//
+// Local storage writes below MEDIA_DIR (default "media") and serves files
+// from MEDIA_URL (default "/media").
+//
+// S3 storage uses S3_ENDPOINT, S3_ACCESS_KEY, S3_SECRET_KEY, S3_BUCKET,
+// S3_REGION and S3_USE_SSL, and serves files from S3_PUBLIC_URL, falling back
+// to the endpoint and bucket. Any S3-compatible server such as MinIO works.
+func Init() error {
+	switch strings.ToLower(os.Getenv("STORAGE_DRIVER")) {
+	case "s3":
+		s3, err := NewS3(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			UseSSL:    os.Getenv("S3_USE_SSL") == "true",
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		})
+		if err != nil {
+			return err
+		}
+		Default = s3
+	default:
+		Default = NewLocal(envOr("MEDIA_DIR", "media"), envOr("MEDIA_URL", "/media"))
+	}
+	return nil
+}
+
+func envOr(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
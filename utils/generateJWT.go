@@ -1,15 +1,20 @@
 package utils
 
 import (
+	"github.com/DewiKresnawati/DewiWebService/models"
 	"github.com/golang-jwt/jwt/v4"
 	"time"
 )
 
-func GenerateToken(userID uint) (string, error){
+func GenerateToken(user models.User) (string, error){
 	claims := jwt.MapClaims{
-		"user_id": userID,
+		"user_id": user.ID,
+		"role":    user.Role,
         "exp":     time.Now().Add(time.Hour * 24).Unix(),
 	}
+	if user.SupplierID != nil {
+		claims["supplier_id"] = *user.SupplierID
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	signedToken, err := token.SignedString([]byte("jwtsecretkey"))